@@ -27,6 +27,10 @@ Built packages are served up by the Elastic Package Registry running locally (se
 
 Built packages can also be published to the global package registry service.
 
+Builds are incremental: the hashes of the package files are recorded in the "build/" folder, and steps of the build whose inputs haven't changed since the previous build are skipped. Use --force to build the whole package.
+
+Zipped packages built with --zip are reproducible: the same source always produces a byte-identical archive. A build manifest is written along the zipped package ("<name>-<version>.build-manifest.json"), listing the SHA-256 checksum of every file in the archive, the ECS reference used, the version of elastic-package and the git commit of the source. Use "elastic-package check --verify-zip" to verify a zipped package against its source.
+
 For details on how to enable dependency management, see the [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/dependency_management.md).`
 
 func setupBuildCommand() *cobraext.Command {
@@ -40,6 +44,7 @@ func setupBuildCommand() *cobraext.Command {
 	cmd.Flags().Bool(cobraext.BuildZipFlagName, true, cobraext.BuildZipFlagDescription)
 	cmd.Flags().Bool(cobraext.SignPackageFlagName, false, cobraext.SignPackageFlagDescription)
 	cmd.Flags().Bool(cobraext.BuildSkipValidationFlagName, false, cobraext.BuildSkipValidationFlagDescription)
+	cmd.Flags().Bool(cobraext.BuildForceFlagName, false, cobraext.BuildForceFlagDescription)
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
 
@@ -49,6 +54,7 @@ func buildCommandAction(cmd *cobra.Command, args []string) error {
 	createZip, _ := cmd.Flags().GetBool(cobraext.BuildZipFlagName)
 	signPackage, _ := cmd.Flags().GetBool(cobraext.SignPackageFlagName)
 	skipValidation, _ := cmd.Flags().GetBool(cobraext.BuildSkipValidationFlagName)
+	force, _ := cmd.Flags().GetBool(cobraext.BuildForceFlagName)
 
 	if signPackage && !createZip {
 		return errors.New("can't sign the unzipped package, please use also the --zip switch")
@@ -87,6 +93,7 @@ func buildCommandAction(cmd *cobra.Command, args []string) error {
 		CreateZip:      createZip,
 		SignPackage:    signPackage,
 		SkipValidation: skipValidation,
+		Force:          force,
 	})
 	if err != nil {
 		return fmt.Errorf("building package failed: %w", err)
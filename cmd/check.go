@@ -5,16 +5,26 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/jedib0t/go-pretty/table"
 	"github.com/spf13/cobra"
 
+	"github.com/elastic/elastic-package/internal/builder"
 	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/fields"
+	"github.com/elastic/elastic-package/internal/packages"
 )
 
 const checkLongDescription = `Use this command to verify if the package is correct in terms of formatting, validation and building.
 
-It will execute the lint and build commands all at once, in that order.`
+It will execute the lint and build commands all at once, in that order.
+
+Use --mappings to also analyze the size of the mappings of each data stream in the built package. The total number of fields, including objects and multi-fields, is calculated after resolving external fields and adding dynamic templates, and compared with the "index.mapping.total_fields.limit" setting of the data stream (1000 by default). The check fails if any data stream exceeds its limit.
+
+Use --verify-zip to rebuild the package from source and compare the result with a zipped package, like a released artifact. Zipped packages are reproducible, so the check fails if the archives are not byte-identical. The files whose content differ are reported, as well as the source commit recorded in the build manifest written along the zipped package, if found.`
 
 func setupCheckCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -30,10 +40,109 @@ func setupCheckCommand() *cobraext.Command {
 			if err != nil {
 				return fmt.Errorf("checking package failed: %w", err)
 			}
+
+			err = checkMappingsCommandAction(cmd, args)
+			if err != nil {
+				return fmt.Errorf("checking package failed: %w", err)
+			}
+
+			err = checkVerifyZipCommandAction(cmd, args)
+			if err != nil {
+				return fmt.Errorf("checking package failed: %w", err)
+			}
 			return nil
 		},
 	}
 	cmd.PersistentFlags().BoolP(cobraext.FailFastFlagName, "f", true, cobraext.FailFastFlagDescription)
+	cmd.Flags().Bool(cobraext.CheckMappingsFlagName, false, cobraext.CheckMappingsFlagDescription)
+	cmd.Flags().String(cobraext.CheckVerifyZipFlagName, "", cobraext.CheckVerifyZipFlagDescription)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
+
+func checkMappingsCommandAction(cmd *cobra.Command, _ []string) error {
+	checkMappings, err := cmd.Flags().GetBool(cobraext.CheckMappingsFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.CheckMappingsFlagName)
+	}
+	if !checkMappings {
+		return nil
+	}
+
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+	builtPackageRoot, err := builder.BuildPackagesDirectory(packageRoot)
+	if err != nil {
+		return fmt.Errorf("locating built package failed: %w", err)
+	}
+
+	cmd.Println("Analyze mappings size")
+	sizes, err := fields.AnalyzeMappingsSize(builtPackageRoot)
+	if err != nil {
+		return fmt.Errorf("analyzing mappings size failed: %w", err)
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Data stream", "Fields", "Limit", "Multi-fields", "Dynamic templates", "Largest subtrees"})
+	var exceeded []string
+	for _, size := range sizes {
+		var subtrees []string
+		for _, subtree := range size.LargestSubtrees {
+			subtrees = append(subtrees, fmt.Sprintf("%s (%d)", subtree.Path, subtree.Fields))
+		}
+		t.AppendRow(table.Row{size.DataStream, size.TotalFields, size.Limit, size.MultiFields, size.DynamicTemplates, strings.Join(subtrees, ", ")})
+		if size.ExceedsLimit() {
+			exceeded = append(exceeded, fmt.Sprintf("%s (%d > %d)", size.DataStream, size.TotalFields, size.Limit))
+		}
+	}
+	t.SetStyle(table.StyleRounded)
+	cmd.Println(t.Render())
+
+	if len(exceeded) > 0 {
+		return fmt.Errorf("total fields limit exceeded in data streams: %s", strings.Join(exceeded, ", "))
+	}
+	return nil
+}
+
+func checkVerifyZipCommandAction(cmd *cobra.Command, _ []string) error {
+	zippedPackagePath, err := cmd.Flags().GetString(cobraext.CheckVerifyZipFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.CheckVerifyZipFlagName)
+	}
+	if zippedPackagePath == "" {
+		return nil
+	}
+
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+
+	cmd.Printf("Verify zipped package %s\n", zippedPackagePath)
+	verification, err := builder.VerifyZippedPackage(cmd.Context(), packageRoot, zippedPackagePath)
+	if err != nil {
+		return fmt.Errorf("verifying zipped package failed: %w", err)
+	}
+
+	if manifest := verification.Manifest; manifest != nil && manifest.Source.Commit != "" {
+		cmd.Printf("Zipped package was built from commit %s", manifest.Source.Commit)
+		if manifest.Source.Dirty {
+			cmd.Print(" with uncommitted changes")
+		}
+		cmd.Println()
+	}
+
+	if verification.Reproduced {
+		cmd.Println("Zipped package is identical to the package built from source")
+		return nil
+	}
+	if len(verification.Differences) == 0 {
+		return errors.New("zipped package differs from the package built from source, but files have the same content (timestamps, permissions or order of the entries differ)")
+	}
+	for _, difference := range verification.Differences {
+		cmd.Printf("- %s\n", difference)
+	}
+	return fmt.Errorf("zipped package differs from the package built from source in %d files", len(verification.Differences))
+}
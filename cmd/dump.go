@@ -14,6 +14,7 @@ import (
 	"github.com/elastic/elastic-package/internal/elasticsearch"
 	"github.com/elastic/elastic-package/internal/install"
 	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/packages"
 	"github.com/elastic/elastic-package/internal/stack"
 )
 
@@ -21,7 +22,9 @@ const dumpLongDescription = `Use this command as an exploratory tool to dump res
 
 const dumpInstalledObjectsLongDescription = `Use this command to dump objects installed by Fleet as part of a package.
 
-Use this command as an exploratory tool to dump objects as they are installed by Fleet when installing a package. Dumped objects are stored in files as they are returned by APIs of the stack, without any processing.`
+Use this command as an exploratory tool to dump objects as they are installed by Fleet when installing a package. Dumped objects are stored in files as they are returned by APIs of the stack, without any processing.
+
+If --diff flag is provided, instead of dumping the objects, this command compares them with the objects expected from the package in the current directory. Index templates, component templates, ILM policies, ingest pipelines, ML models and Kibana assets are compared with the ones that Fleet would install for the installed version of the package. Missing, extra and modified objects are reported, with the differences found in modified objects, and the command fails if any difference is found.`
 
 const dumpAgentPoliciesLongDescription = `Use this command to dump agent policies created by Fleet as part of a package installation.
 
@@ -31,6 +34,10 @@ If no flag is provided, by default this command dumps all agent policies created
 
 If --package flag is provided, this command dumps all agent policies that the given package has been assigned to it.`
 
+const dumpDataStreamsLongDescription = `Use this command to dump diagnostic information about the data ingested in the data streams of a package.
+
+For each data stream of the package, this command dumps the effective mappings, the settings and the ILM explain output of the backing indices, statistics about ignored fields, fields with conflicting types in the backing indices, document counts per namespace and a few sample documents. Information of each data stream is stored in its own directory.`
+
 func setupDumpCommand() *cobraext.Command {
 	dumpInstalledObjectsCmd := &cobra.Command{
 		Use:   "installed-objects",
@@ -42,6 +49,7 @@ func setupDumpCommand() *cobraext.Command {
 	dumpInstalledObjectsCmd.Flags().Bool(cobraext.TLSSkipVerifyFlagName, false, cobraext.TLSSkipVerifyFlagDescription)
 	dumpInstalledObjectsCmd.Flags().StringP(cobraext.PackageFlagName, cobraext.PackageFlagShorthand, "", cobraext.PackageFlagDescription)
 	dumpInstalledObjectsCmd.MarkFlagRequired(cobraext.PackageFlagName)
+	dumpInstalledObjectsCmd.Flags().Bool(cobraext.DumpDiffFlagName, false, cobraext.DumpDiffFlagDescription)
 
 	dumpAgentPoliciesCmd := &cobra.Command{
 		Use:   "agent-policies",
@@ -53,6 +61,17 @@ func setupDumpCommand() *cobraext.Command {
 	dumpAgentPoliciesCmd.Flags().StringP(cobraext.AgentPolicyFlagName, "", "", cobraext.AgentPolicyDescription)
 	dumpAgentPoliciesCmd.Flags().StringP(cobraext.PackageFlagName, cobraext.PackageFlagShorthand, "", cobraext.PackageFlagDescription)
 
+	dumpDataStreamsCmd := &cobra.Command{
+		Use:   "data-streams",
+		Short: "Dump data stream diagnostics",
+		Long:  dumpDataStreamsLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  dumpDataStreamsCmdAction,
+	}
+	dumpDataStreamsCmd.Flags().Bool(cobraext.TLSSkipVerifyFlagName, false, cobraext.TLSSkipVerifyFlagDescription)
+	dumpDataStreamsCmd.Flags().StringP(cobraext.PackageFlagName, cobraext.PackageFlagShorthand, "", cobraext.PackageFlagDescription)
+	dumpDataStreamsCmd.MarkFlagRequired(cobraext.PackageFlagName)
+
 	cmd := &cobra.Command{
 		Use:   "dump",
 		Short: "Dump package assets",
@@ -63,6 +82,7 @@ func setupDumpCommand() *cobraext.Command {
 
 	cmd.AddCommand(dumpInstalledObjectsCmd)
 	cmd.AddCommand(dumpAgentPoliciesCmd)
+	cmd.AddCommand(dumpDataStreamsCmd)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextGlobal)
 }
@@ -83,6 +103,26 @@ func dumpInstalledObjectsCmdAction(cmd *cobra.Command, args []string) error {
 		return cobraext.FlagParsingError(err, cobraext.TLSSkipVerifyFlagName)
 	}
 
+	diff, err := cmd.Flags().GetBool(cobraext.DumpDiffFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DumpDiffFlagName)
+	}
+
+	var packageRoot string
+	if diff {
+		packageRoot, err = packages.MustFindPackageRoot()
+		if err != nil {
+			return fmt.Errorf("locating package root failed: %w", err)
+		}
+		manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+		if err != nil {
+			return fmt.Errorf("reading package manifest failed: %w", err)
+		}
+		if manifest.Name != packageName {
+			return fmt.Errorf("package in current directory (%s) doesn't match the selected package (%s)", manifest.Name, packageName)
+		}
+	}
+
 	profile, err := cobraext.GetProfileFlag(cmd)
 	if err != nil {
 		return err
@@ -115,6 +155,9 @@ func dumpInstalledObjectsCmdAction(cmd *cobra.Command, args []string) error {
 	}
 
 	dumper := dump.NewInstalledObjectsDumper(client.API, packageName)
+	if diff {
+		return diffInstalledObjects(cmd, dumper, kibanaClient, installedPackage, packageRoot)
+	}
 	n, err := dumper.DumpAll(cmd.Context(), outputPath)
 	if err != nil {
 		return fmt.Errorf("dump failed: %w", err)
@@ -130,6 +173,82 @@ func dumpInstalledObjectsCmdAction(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func diffInstalledObjects(cmd *cobra.Command, dumper *dump.InstalledObjectsDumper, kibanaClient *kibana.Client, installedPackage *kibana.FleetPackage, packageRoot string) error {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return fmt.Errorf("reading package manifest failed: %w", err)
+	}
+	if manifest.Version != installedPackage.Version {
+		cmd.Printf("Warning: installed version of package %s (%s) differs from the version in the current directory (%s)\n",
+			installedPackage.Name, installedPackage.Version, manifest.Version)
+	}
+
+	diffs, err := dumper.Diff(cmd.Context(), packageRoot, installedPackage.Version)
+	if err != nil {
+		return fmt.Errorf("comparing installed objects failed: %w", err)
+	}
+	savedObjectDiffs, err := dump.DiffSavedObjects(cmd.Context(), kibanaClient, packageRoot, installedPackage.Name, installedPackage.KibanaAssets())
+	if err != nil {
+		return fmt.Errorf("comparing saved objects failed: %w", err)
+	}
+	diffs = append(diffs, savedObjectDiffs...)
+
+	if len(diffs) == 0 {
+		cmd.Printf("Installed objects match package %s\n", installedPackage.Name)
+		return nil
+	}
+	for _, diff := range diffs {
+		cmd.Println(diff.String())
+		for _, difference := range diff.Differences {
+			cmd.Printf("  %s\n", difference)
+		}
+	}
+	return fmt.Errorf("found %d differences between installed objects and package %s", len(diffs), installedPackage.Name)
+}
+
+func dumpDataStreamsCmdAction(cmd *cobra.Command, args []string) error {
+	packageName, err := cmd.Flags().GetString(cobraext.PackageFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.PackageFlagName)
+	}
+
+	outputPath, err := cmd.Flags().GetString(cobraext.DumpOutputFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DumpOutputFlagName)
+	}
+
+	tlsSkipVerify, err := cmd.Flags().GetBool(cobraext.TLSSkipVerifyFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.TLSSkipVerifyFlagName)
+	}
+
+	profile, err := cobraext.GetProfileFlag(cmd)
+	if err != nil {
+		return err
+	}
+
+	var clientOptions []elasticsearch.ClientOption
+	if tlsSkipVerify {
+		clientOptions = append(clientOptions, elasticsearch.OptionWithSkipTLSVerify())
+	}
+	client, err := stack.NewElasticsearchClientFromProfile(profile, clientOptions...)
+	if err != nil {
+		return fmt.Errorf("failed to initialize Elasticsearch client: %w", err)
+	}
+
+	dumper := dump.NewDataStreamsDumper(client, packageName)
+	n, err := dumper.DumpAll(cmd.Context(), outputPath)
+	if err != nil {
+		return fmt.Errorf("dump failed: %w", err)
+	}
+	if n == 0 {
+		cmd.Printf("No data streams found for package %s\n", packageName)
+		return nil
+	}
+	cmd.Printf("Dumped %d data streams for package %s to %s\n", n, packageName, outputPath)
+	return nil
+}
+
 func dumpAgentPoliciesCmdAction(cmd *cobra.Command, args []string) error {
 	packageName, err := cmd.Flags().GetString(cobraext.PackageFlagName)
 	if err != nil {
@@ -0,0 +1,159 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/fields"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
+)
+
+const ecsLongDescription = `Use this command to manage the cache of ECS schemas used to resolve external ECS fields.
+
+ECS schemas are downloaded when needed for the reference defined in the _dev/build/build.yml file of the package, and stored in the cache directory of elastic-package. These commands allow to populate the cache in advance, so environments without network access can use it.
+
+When the ELASTIC_PACKAGE_OFFLINE environment variable is set to true, schemas are never downloaded, and commands resolving fields fail if the schema for the reference is not cached.`
+
+const ecsFetchLongDescription = `Use this command to download the ECS schema for a reference and store it in the cache.
+
+The reference is a git reference of the ECS repository, with or without the "git@" prefix (for example git@v8.11.0). If no reference is given, the one defined in the build manifest of the current package is used. Schemas already cached are downloaded again.`
+
+const ecsImportLongDescription = `Use this command to store an ECS schema file (ecs_nested.yml) in the cache.
+
+The schema is used for the reference passed with --reference, or for the one defined in the build manifest of the current package.`
+
+func setupECSCommand() *cobraext.Command {
+	fetchCmd := &cobra.Command{
+		Use:     "fetch [reference]",
+		Aliases: []string{"update"},
+		Short:   "Download an ECS schema into the cache",
+		Long:    ecsFetchLongDescription,
+		Args:    cobra.MaximumNArgs(1),
+		RunE:    ecsFetchCommandAction,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List cached ECS schemas",
+		Args:  cobra.NoArgs,
+		RunE:  ecsListCommandAction,
+	}
+
+	importCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import an ECS schema file into the cache",
+		Long:  ecsImportLongDescription,
+		Args:  cobra.ExactArgs(1),
+		RunE:  ecsImportCommandAction,
+	}
+	importCmd.Flags().String(cobraext.ECSImportReferenceFlagName, "", cobraext.ECSImportReferenceFlagDescription)
+
+	cmd := &cobra.Command{
+		Use:   "ecs",
+		Short: "Manage cached ECS schemas",
+		Long:  ecsLongDescription,
+	}
+	cmd.AddCommand(fetchCmd)
+	cmd.AddCommand(importCmd)
+	cmd.AddCommand(listCmd)
+
+	return cobraext.NewCommand(cmd, cobraext.ContextGlobal)
+}
+
+func ecsFetchCommandAction(cmd *cobra.Command, args []string) error {
+	var reference string
+	if len(args) > 0 {
+		reference = args[0]
+	}
+	reference, err := resolveECSReference(reference)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Fetch ECS schema for reference %s\n", reference)
+	path, err := fields.FetchECSSchema(reference)
+	if err != nil {
+		return fmt.Errorf("fetching ECS schema failed: %w", err)
+	}
+	cmd.Printf("ECS schema cached in %s\n", path)
+	return nil
+}
+
+func ecsImportCommandAction(cmd *cobra.Command, args []string) error {
+	reference, err := cmd.Flags().GetString(cobraext.ECSImportReferenceFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.ECSImportReferenceFlagName)
+	}
+	reference, err = resolveECSReference(reference)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Import ECS schema for reference %s\n", reference)
+	path, err := fields.ImportECSSchema(reference, args[0])
+	if err != nil {
+		return fmt.Errorf("importing ECS schema failed: %w", err)
+	}
+	cmd.Printf("ECS schema cached in %s\n", path)
+	return nil
+}
+
+func ecsListCommandAction(cmd *cobra.Command, _ []string) error {
+	schemas, err := fields.ListCachedECSSchemas()
+	if err != nil {
+		return fmt.Errorf("listing cached ECS schemas failed: %w", err)
+	}
+	if len(schemas) == 0 {
+		cmd.Println("No ECS schemas cached")
+		return nil
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Reference", "Size", "Cached at", "Path"})
+	for _, schema := range schemas {
+		t.AppendRow(table.Row{schema.Reference, schema.Size, schema.ModTime.Format(time.RFC3339), schema.Path})
+	}
+	t.SetStyle(table.StyleRounded)
+	cmd.Println(t.Render())
+	return nil
+}
+
+// resolveECSReference normalizes the given ECS reference. If it is empty, the reference
+// defined in the build manifest of the current package is used.
+func resolveECSReference(reference string) (string, error) {
+	if reference == "" {
+		packageRoot, found, err := packages.FindPackageRoot()
+		if err != nil {
+			return "", fmt.Errorf("locating package root failed: %w", err)
+		}
+		if !found {
+			return "", errors.New("ECS reference is required when not running in a package")
+		}
+		buildManifest, ok, err := buildmanifest.ReadBuildManifest(packageRoot)
+		if err != nil {
+			return "", fmt.Errorf("can't read build manifest: %w", err)
+		}
+		if !ok || buildManifest.Dependencies.ECS.Reference == "" {
+			return "", errors.New("ECS reference is required, the package doesn't define an ECS dependency")
+		}
+		reference = buildManifest.Dependencies.ECS.Reference
+	}
+	if strings.HasPrefix(reference, "file://") {
+		return "", fmt.Errorf("local file reference %q doesn't use the cache", reference)
+	}
+	if !strings.HasPrefix(reference, "git@") {
+		reference = "git@" + reference
+	}
+	return reference, nil
+}
@@ -10,10 +10,11 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/export"
 	"github.com/elastic/elastic-package/internal/install"
 )
 
-const exportLongDescription = `Use this command to export assets relevant for the package, e.g. Kibana dashboards.`
+const exportLongDescription = `Use this command to export assets relevant for the package, e.g. Kibana dashboards, ingest pipelines, or other Kibana assets like security rules or Osquery packs.`
 
 func setupExportCommand() *cobraext.Command {
 	exportDashboardCmd := &cobra.Command{
@@ -24,6 +25,9 @@ func setupExportCommand() *cobraext.Command {
 		RunE:  exportDashboardsCmd,
 	}
 	exportDashboardCmd.Flags().StringSliceP(cobraext.DashboardIDsFlagName, "d", nil, cobraext.DashboardIDsFlagDescription)
+	exportDashboardCmd.Flags().Bool(cobraext.DashboardsAllFromPackageFlagName, false, cobraext.DashboardsAllFromPackageFlagDescription)
+	exportDashboardCmd.Flags().Bool(cobraext.DashboardsForceFlagName, false, cobraext.DashboardsForceFlagDescription)
+	exportDashboardCmd.Flags().String(cobraext.DashboardsTargetKibanaFlagName, "", cobraext.DashboardsTargetKibanaFlagDescription)
 	exportDashboardCmd.Flags().Bool(cobraext.TLSSkipVerifyFlagName, false, cobraext.TLSSkipVerifyFlagDescription)
 	exportDashboardCmd.Flags().Bool(cobraext.AllowSnapshotFlagName, false, cobraext.AllowSnapshotDescription)
 
@@ -39,6 +43,19 @@ func setupExportCommand() *cobraext.Command {
 	exportIngestPipelinesCmd.Flags().Bool(cobraext.TLSSkipVerifyFlagName, false, cobraext.TLSSkipVerifyFlagDescription)
 	exportIngestPipelinesCmd.Flags().Bool(cobraext.AllowSnapshotFlagName, false, cobraext.AllowSnapshotDescription)
 
+	exportDataStreamSettingsCmd := &cobra.Command{
+		Use:   "data-stream-settings",
+		Short: "Export data stream settings from Elasticsearch",
+		Long:  exportDataStreamSettingsLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  exportDataStreamSettingsCmd,
+	}
+
+	exportDataStreamSettingsCmd.Flags().StringSlice(cobraext.DataStreamSettingsComponentTemplatesFlagName, nil, cobraext.DataStreamSettingsComponentTemplatesFlagDescription)
+	exportDataStreamSettingsCmd.Flags().StringSlice(cobraext.DataStreamSettingsILMPoliciesFlagName, nil, cobraext.DataStreamSettingsILMPoliciesFlagDescription)
+	exportDataStreamSettingsCmd.Flags().Bool(cobraext.DataStreamSettingsDryRunFlagName, false, cobraext.DataStreamSettingsDryRunFlagDescription)
+	exportDataStreamSettingsCmd.Flags().Bool(cobraext.TLSSkipVerifyFlagName, false, cobraext.TLSSkipVerifyFlagDescription)
+
 	cmd := &cobra.Command{
 		Use:   "export",
 		Short: "Export package assets",
@@ -46,6 +63,10 @@ func setupExportCommand() *cobraext.Command {
 	}
 	cmd.AddCommand(exportDashboardCmd)
 	cmd.AddCommand(exportIngestPipelinesCmd)
+	cmd.AddCommand(exportDataStreamSettingsCmd)
+	for _, assetType := range export.AssetTypes {
+		cmd.AddCommand(setupExportAssetsCommand(assetType))
+	}
 	cmd.PersistentFlags().StringP(cobraext.ProfileFlagName, "p", "", fmt.Sprintf(cobraext.ProfileFlagDescription, install.ProfileNameEnvVar))
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
@@ -0,0 +1,92 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/export"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/stack"
+)
+
+const exportAssetsLongDescription = `Use this command to export %[1]s from the Kibana instance.
+
+Use this command to download the selected %[1]s from Kibana. This command removes the properties set by Kibana at runtime, adjusts the IDs according to package naming conventions when needed, and writes the assets locally into the "kibana/%[2]s" folder of the package.
+
+Local files modified since the last export or import are not overwritten without confirmation, the differences with the objects in Kibana are shown before asking. Use --force to overwrite them without asking.`
+
+func setupExportAssetsCommand(assetType export.AssetType) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   assetType.Name,
+		Short: fmt.Sprintf("Export %s from Kibana", assetType.Description),
+		Long:  fmt.Sprintf(exportAssetsLongDescription, assetType.Description, assetType.Folder),
+		Args:  cobra.NoArgs,
+		RunE:  exportAssetsCmd(assetType),
+	}
+	cmd.Flags().StringSliceP(cobraext.KibanaAssetIDsFlagName, "d", nil, cobraext.KibanaAssetIDsFlagDescription)
+	cmd.MarkFlagRequired(cobraext.KibanaAssetIDsFlagName)
+	cmd.Flags().Bool(cobraext.DashboardsForceFlagName, false, cobraext.DashboardsForceFlagDescription)
+	cmd.Flags().Bool(cobraext.TLSSkipVerifyFlagName, false, cobraext.TLSSkipVerifyFlagDescription)
+	return cmd
+}
+
+func exportAssetsCmd(assetType export.AssetType) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cmd.Printf("Export Kibana %s\n", assetType.Description)
+
+		ids, err := cmd.Flags().GetStringSlice(cobraext.KibanaAssetIDsFlagName)
+		if err != nil {
+			return cobraext.FlagParsingError(err, cobraext.KibanaAssetIDsFlagName)
+		}
+
+		common.TrimStringSlice(ids)
+		if len(ids) == 0 {
+			return cobraext.FlagParsingError(fmt.Errorf("at least one ID is required"), cobraext.KibanaAssetIDsFlagName)
+		}
+
+		force, err := cmd.Flags().GetBool(cobraext.DashboardsForceFlagName)
+		if err != nil {
+			return cobraext.FlagParsingError(err, cobraext.DashboardsForceFlagName)
+		}
+
+		var opts []kibana.ClientOption
+		tlsSkipVerify, _ := cmd.Flags().GetBool(cobraext.TLSSkipVerifyFlagName)
+		if tlsSkipVerify {
+			opts = append(opts, kibana.TLSSkipVerify())
+		}
+
+		profile, err := cobraext.GetProfileFlag(cmd)
+		if err != nil {
+			return err
+		}
+
+		kibanaClient, err := stack.NewKibanaClientFromProfile(profile, opts...)
+		if err != nil {
+			return fmt.Errorf("can't create Kibana client: %w", err)
+		}
+
+		var resolve export.ConflictResolver
+		if !force {
+			resolve = conflictResolver(cmd, "in the package")
+		}
+
+		err = export.Assets(cmd.Context(), kibanaClient, export.AssetsOptions{
+			Type:    assetType,
+			IDs:     ids,
+			Resolve: resolve,
+		})
+		if err != nil {
+			return fmt.Errorf("%s export failed: %w", assetType.Description, err)
+		}
+
+		cmd.Println("Done")
+		return nil
+	}
+}
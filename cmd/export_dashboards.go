@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/Masterminds/semver/v3"
 
 	"github.com/spf13/cobra"
 
@@ -16,12 +17,19 @@ import (
 	"github.com/elastic/elastic-package/internal/common"
 	"github.com/elastic/elastic-package/internal/export"
 	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/packages"
 	"github.com/elastic/elastic-package/internal/stack"
 )
 
 const exportDashboardsLongDescription = `Use this command to export dashboards with referenced objects from the Kibana instance.
 
-Use this command to download selected dashboards and other associated saved objects from Kibana. This command adjusts the downloaded saved objects according to package naming conventions (prefixes, unique IDs) and writes them locally into folders corresponding to saved object types (dashboard, visualization, map, etc.).`
+Use this command to download selected dashboards and other associated saved objects from Kibana. This command adjusts the downloaded saved objects according to package naming conventions (prefixes, unique IDs) and writes them locally into folders corresponding to saved object types (dashboard, visualization, map, etc.).
+
+Use --all-from-package to export again all the dashboards installed with the package.
+
+Exported objects are checked against the minimum Kibana version supported by the package, and a warning is shown for objects migrated to newer versions, that may not be installed in older versions. Use --target-kibana to fail the export if some object is not compatible with the given Kibana version, the panels of dashboards that need to be re-authored are listed. Objects using model versions can only be checked against a lower bound of the Kibana version they require, so a warning is shown when their compatibility cannot be verified.
+
+Local files modified since the last export or import are not overwritten without confirmation, the differences with the objects in Kibana are shown before asking. Use --force to overwrite them without asking.`
 
 func exportDashboardsCmd(cmd *cobra.Command, args []string) error {
 	cmd.Println("Export Kibana dashboards")
@@ -33,6 +41,31 @@ func exportDashboardsCmd(cmd *cobra.Command, args []string) error {
 
 	common.TrimStringSlice(dashboardIDs)
 
+	allFromPackage, err := cmd.Flags().GetBool(cobraext.DashboardsAllFromPackageFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DashboardsAllFromPackageFlagName)
+	}
+	if allFromPackage && len(dashboardIDs) > 0 {
+		return fmt.Errorf("--%s and --%s flags can't be used together", cobraext.DashboardIDsFlagName, cobraext.DashboardsAllFromPackageFlagName)
+	}
+
+	force, err := cmd.Flags().GetBool(cobraext.DashboardsForceFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DashboardsForceFlagName)
+	}
+
+	var targetKibanaVersion *semver.Version
+	targetKibana, err := cmd.Flags().GetString(cobraext.DashboardsTargetKibanaFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DashboardsTargetKibanaFlagName)
+	}
+	if targetKibana != "" {
+		targetKibanaVersion, err = semver.NewVersion(targetKibana)
+		if err != nil {
+			return cobraext.FlagParsingError(fmt.Errorf("invalid version %q: %w", targetKibana, err), cobraext.DashboardsTargetKibanaFlagName)
+		}
+	}
+
 	var opts []kibana.ClientOption
 	tlsSkipVerify, _ := cmd.Flags().GetBool(cobraext.TLSSkipVerifyFlagName)
 	if tlsSkipVerify {
@@ -67,6 +100,18 @@ func exportDashboardsCmd(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Warning: %s\n", message)
 	}
 
+	if allFromPackage {
+		dashboardIDs, err = packageDashboardIDs(cmd.Context(), kibanaClient)
+		if err != nil {
+			return err
+		}
+
+		if len(dashboardIDs) == 0 {
+			fmt.Println("No dashboards were installed with the package.")
+			return nil
+		}
+	}
+
 	if len(dashboardIDs) == 0 {
 		dashboardIDs, err = promptDashboardIDs(cmd.Context(), kibanaClient)
 		if err != nil {
@@ -79,7 +124,16 @@ func exportDashboardsCmd(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	err = export.Dashboards(cmd.Context(), kibanaClient, dashboardIDs)
+	var resolve export.ConflictResolver
+	if !force {
+		resolve = conflictResolver(cmd, "in the package")
+	}
+
+	err = export.Dashboards(cmd.Context(), kibanaClient, export.DashboardsOptions{
+		DashboardIDs:        dashboardIDs,
+		Resolve:             resolve,
+		TargetKibanaVersion: targetKibanaVersion,
+	})
 	if err != nil {
 		return fmt.Errorf("dashboards export failed: %w", err)
 	}
@@ -120,3 +174,50 @@ func promptDashboardIDs(ctx context.Context, kibanaClient *kibana.Client) ([]str
 	}
 	return selected, nil
 }
+
+// packageDashboardIDs returns the IDs of the dashboards installed with the current package.
+func packageDashboardIDs(ctx context.Context, kibanaClient *kibana.Client) ([]string, error) {
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return nil, fmt.Errorf("locating package root failed: %w", err)
+	}
+	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed (path: %s): %w", packageRoot, err)
+	}
+
+	installedPackage, err := kibanaClient.GetPackage(ctx, m.Name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get installed package %q: %w", m.Name, err)
+	}
+
+	var dashboardIDs []string
+	for _, asset := range installedPackage.Assets() {
+		if asset.Type == "dashboard" {
+			dashboardIDs = append(dashboardIDs, asset.ID)
+		}
+	}
+	return dashboardIDs, nil
+}
+
+// conflictResolver returns a resolver that shows the differences with the saved objects modified since
+// the last export or import, and asks for confirmation before overwriting them.
+func conflictResolver(cmd *cobra.Command, location string) export.ConflictResolver {
+	return func(conflict export.Conflict) (bool, error) {
+		cmd.Printf("Saved object %s %s was modified %s since the last export or import (path: %s). Changes to apply:\n", conflict.Type, conflict.ID, location, conflict.Path)
+		for _, difference := range conflict.Differences {
+			cmd.Printf("  %s\n", difference)
+		}
+
+		overwrite := false
+		prompt := &survey.Confirm{
+			Message: fmt.Sprintf("Overwrite %s %s %s?", conflict.Type, conflict.ID, location),
+			Default: false,
+		}
+		err := survey.AskOne(prompt, &overwrite)
+		if err != nil {
+			return false, err
+		}
+		return overwrite, nil
+	}
+}
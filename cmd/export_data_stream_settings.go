@@ -0,0 +1,91 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/export"
+	"github.com/elastic/elastic-package/internal/stack"
+)
+
+const exportDataStreamSettingsLongDescription = `Use this command to export data stream settings tuned in the Elasticsearch instance back into the package.
+
+Use this command to bring back the changes done in @package or @custom component templates and ILM policies of the data streams of the package. Settings and mapping options of component templates are merged into the "elasticsearch.index_template" section of the data stream manifests, settings managed by Fleet and field mappings are not exported. ILM policies overwrite their definitions in "data_stream/<data stream>/elasticsearch/ilm".
+
+The differences with the current files of the package are shown. Use --dry-run to only show them, without writing any change.`
+
+func exportDataStreamSettingsCmd(cmd *cobra.Command, args []string) error {
+	cmd.Println("Export data stream settings")
+
+	componentTemplates, err := cmd.Flags().GetStringSlice(cobraext.DataStreamSettingsComponentTemplatesFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DataStreamSettingsComponentTemplatesFlagName)
+	}
+	common.TrimStringSlice(componentTemplates)
+
+	ilmPolicies, err := cmd.Flags().GetStringSlice(cobraext.DataStreamSettingsILMPoliciesFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DataStreamSettingsILMPoliciesFlagName)
+	}
+	common.TrimStringSlice(ilmPolicies)
+
+	if len(componentTemplates) == 0 && len(ilmPolicies) == 0 {
+		return fmt.Errorf("at least one component template or ILM policy must be selected with --%s or --%s", cobraext.DataStreamSettingsComponentTemplatesFlagName, cobraext.DataStreamSettingsILMPoliciesFlagName)
+	}
+
+	dryRun, err := cmd.Flags().GetBool(cobraext.DataStreamSettingsDryRunFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DataStreamSettingsDryRunFlagName)
+	}
+
+	var opts []elasticsearch.ClientOption
+	tlsSkipVerify, _ := cmd.Flags().GetBool(cobraext.TLSSkipVerifyFlagName)
+	if tlsSkipVerify {
+		opts = append(opts, elasticsearch.OptionWithSkipTLSVerify())
+	}
+
+	profile, err := cobraext.GetProfileFlag(cmd)
+	if err != nil {
+		return err
+	}
+
+	esClient, err := stack.NewElasticsearchClientFromProfile(profile, opts...)
+	if err != nil {
+		return fmt.Errorf("can't create Elasticsearch client: %w", err)
+	}
+
+	changes, err := export.DataStreamSettings(cmd.Context(), esClient.API, export.DataStreamSettingsOptions{
+		ComponentTemplates: componentTemplates,
+		ILMPolicies:        ilmPolicies,
+		DryRun:             dryRun,
+	})
+	if err != nil {
+		return fmt.Errorf("data stream settings export failed: %w", err)
+	}
+
+	for _, change := range changes {
+		if len(change.Differences) == 0 {
+			cmd.Printf("%s: no changes (path: %s)\n", change.Source, change.Path)
+			continue
+		}
+		cmd.Printf("%s: changes in %s\n", change.Source, change.Path)
+		for _, difference := range change.Differences {
+			cmd.Printf("  %s\n", difference)
+		}
+	}
+
+	if dryRun {
+		cmd.Println("Dry run, no changes written")
+		return nil
+	}
+	cmd.Println("Done")
+	return nil
+}
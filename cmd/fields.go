@@ -0,0 +1,288 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/fields"
+	"github.com/elastic/elastic-package/internal/install"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/stack"
+)
+
+const fieldsLongDescription = `Use this command to manage the field definitions of the package.`
+
+const fieldsGenerateLongDescription = `Use this command to generate field definitions from observed documents.
+
+Documents are read from the files passed with --documents, from an index or data stream of the running stack passed with --index, or by default from the expected results of the pipeline tests of the data stream. Pipeline test expected files, system test documents dumped with ELASTIC_PACKAGE_TEST_DUMP_SCENARIO_DOCS and sample events are supported as document files.
+
+Fields are generated for the data stream passed with --data-stream, that is required for all packages except input packages, whose fields are defined at the package level.
+
+The type of each field not defined yet is inferred from its values. Fields available in ECS are added as "external: ecs" references to the ecs.yml file, other fields are added to the file passed with --output. Existing files are patched, keeping their definitions and comments.`
+
+const fieldsDiffLongDescription = `Use this command to compare the field definitions of the package with a previous version.
+
+The previous version is read from the git reference or the built package zip passed with --base (HEAD by default). Fields of both versions are flattened, resolving external fields, and the added, removed, type-changed and metadata-changed fields are reported per data stream.
+
+Type changes and removed fields still referenced by Kibana assets of the package are reported as breaking changes, and make the command fail.`
+
+const defaultFieldsGenerateIndexSize = 100
+
+func setupFieldsCommand() *cobraext.Command {
+	generateCmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate field definitions from documents",
+		Long:  fieldsGenerateLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  fieldsGenerateCommandAction,
+	}
+	generateCmd.Flags().StringP(cobraext.DataStreamFlagName, "d", "", cobraext.FieldsGenerateDataStreamFlagDescription)
+	generateCmd.Flags().StringSlice(cobraext.FieldsGenerateDocumentsFlagName, nil, cobraext.FieldsGenerateDocumentsFlagDescription)
+	generateCmd.Flags().String(cobraext.FieldsGenerateIndexFlagName, "", cobraext.FieldsGenerateIndexFlagDescription)
+	generateCmd.Flags().Int(cobraext.FieldsGenerateSizeFlagName, defaultFieldsGenerateIndexSize, cobraext.FieldsGenerateSizeFlagDescription)
+	generateCmd.Flags().String(cobraext.FieldsGenerateOutputFlagName, fields.DefaultGeneratedFieldsFile, cobraext.FieldsGenerateOutputFlagDescription)
+	generateCmd.Flags().StringP(cobraext.ProfileFlagName, "p", "", fmt.Sprintf(cobraext.ProfileFlagDescription, install.ProfileNameEnvVar))
+	generateCmd.MarkFlagsMutuallyExclusive(cobraext.FieldsGenerateDocumentsFlagName, cobraext.FieldsGenerateIndexFlagName)
+
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare field definitions with a previous version",
+		Long:  fieldsDiffLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  fieldsDiffCommandAction,
+	}
+	diffCmd.Flags().String(cobraext.FieldsDiffBaseFlagName, "HEAD", cobraext.FieldsDiffBaseFlagDescription)
+
+	cmd := &cobra.Command{
+		Use:   "fields",
+		Short: "Manage field definitions",
+		Long:  fieldsLongDescription,
+	}
+	cmd.AddCommand(diffCmd)
+	cmd.AddCommand(generateCmd)
+
+	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
+}
+
+func fieldsGenerateCommandAction(cmd *cobra.Command, _ []string) error {
+	dataStream, err := cmd.Flags().GetString(cobraext.DataStreamFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DataStreamFlagName)
+	}
+	documentFiles, err := cmd.Flags().GetStringSlice(cobraext.FieldsGenerateDocumentsFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FieldsGenerateDocumentsFlagName)
+	}
+	index, err := cmd.Flags().GetString(cobraext.FieldsGenerateIndexFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FieldsGenerateIndexFlagName)
+	}
+	size, err := cmd.Flags().GetInt(cobraext.FieldsGenerateSizeFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FieldsGenerateSizeFlagName)
+	}
+	output, err := cmd.Flags().GetString(cobraext.FieldsGenerateOutputFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FieldsGenerateOutputFlagName)
+	}
+	if output != filepath.Base(output) || filepath.Ext(output) != ".yml" {
+		return cobraext.FlagParsingError(errors.New("a yml file name in the fields directory is expected"), cobraext.FieldsGenerateOutputFlagName)
+	}
+
+	packageRoot, found, err := packages.FindPackageRoot()
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+	if !found {
+		return errors.New("package root not found")
+	}
+
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRoot, err)
+	}
+	if dataStream == "" && manifest.Type != "input" {
+		return fmt.Errorf("a data stream is required for %s packages, use --%s to select it", manifest.Type, cobraext.DataStreamFlagName)
+	}
+
+	fieldsParentDir := packageRoot
+	if dataStream != "" {
+		fieldsParentDir = filepath.Join(packageRoot, "data_stream", dataStream)
+		_, err := os.Stat(filepath.Join(fieldsParentDir, packages.DataStreamManifestFile))
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("data stream %q not found in package", dataStream)
+		}
+		if err != nil {
+			return fmt.Errorf("checking data stream %q failed: %w", dataStream, err)
+		}
+	}
+
+	var docs []common.MapStr
+	switch {
+	case index != "":
+		profile, err := cobraext.GetProfileFlag(cmd)
+		if err != nil {
+			return err
+		}
+		esClient, err := stack.NewElasticsearchClientFromProfile(profile)
+		if err != nil {
+			return fmt.Errorf("could not create elasticsearch client: %w", err)
+		}
+		docs, err = searchDocumentsForFields(cmd.Context(), esClient.API, index, size)
+		if err != nil {
+			return err
+		}
+	default:
+		if len(documentFiles) == 0 {
+			documentFiles, err = filepath.Glob(filepath.Join(fieldsParentDir, "_dev", "test", "pipeline", "*-expected.json"))
+			if err != nil {
+				return fmt.Errorf("looking for pipeline test results failed: %w", err)
+			}
+			if len(documentFiles) == 0 {
+				return fmt.Errorf("no pipeline test results found in %s, use --%s or --%s to select the documents", fieldsParentDir, cobraext.FieldsGenerateDocumentsFlagName, cobraext.FieldsGenerateIndexFlagName)
+			}
+		}
+		for _, path := range documentFiles {
+			fileDocs, err := fields.ReadDocumentsFile(path)
+			if err != nil {
+				return err
+			}
+			docs = append(docs, fileDocs...)
+		}
+	}
+	if len(docs) == 0 {
+		return errors.New("no documents found")
+	}
+
+	generated, err := fields.GenerateFieldDefinitions(fields.GenerateOptions{
+		PackageRoot:     packageRoot,
+		FieldsParentDir: fieldsParentDir,
+	}, docs)
+	if err != nil {
+		return fmt.Errorf("generating field definitions failed: %w", err)
+	}
+	if len(generated) == 0 {
+		cmd.Printf("All fields in %d documents are already defined.\n", len(docs))
+		return nil
+	}
+
+	files, err := fields.WriteGeneratedFields(fieldsParentDir, output, generated)
+	if err != nil {
+		return fmt.Errorf("writing field definitions failed: %w", err)
+	}
+
+	cmd.Printf("Generated definitions for %d fields found in %d documents:\n", len(generated), len(docs))
+	for _, field := range generated {
+		fieldType := field.Type
+		if field.External != "" {
+			fieldType = "external: " + field.External
+		}
+		cmd.Printf("  %s (%s)\n", field.Name, fieldType)
+	}
+	cmd.Printf("Updated files:\n  %s\n", strings.Join(files, "\n  "))
+	return nil
+}
+
+func searchDocumentsForFields(ctx context.Context, esAPI *elasticsearch.API, index string, size int) ([]common.MapStr, error) {
+	resp, err := esAPI.Search(
+		esAPI.Search.WithContext(ctx),
+		esAPI.Search.WithIndex(index),
+		esAPI.Search.WithSize(size),
+		esAPI.Search.WithSort("@timestamp:desc"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not search documents in %s: %w", index, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("failed to search documents in %s: %s", index, resp.String())
+	}
+
+	var results struct {
+		Hits struct {
+			Hits []struct {
+				Source common.MapStr `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	err = fields.DecodeJSONDocument(resp.Body, &results)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode search results: %w", err)
+	}
+
+	docs := make([]common.MapStr, 0, len(results.Hits.Hits))
+	for _, hit := range results.Hits.Hits {
+		docs = append(docs, hit.Source)
+	}
+	return docs, nil
+}
+
+func fieldsDiffCommandAction(cmd *cobra.Command, _ []string) error {
+	base, err := cmd.Flags().GetString(cobraext.FieldsDiffBaseFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FieldsDiffBaseFlagName)
+	}
+
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "elastic-package-fields-diff-")
+	if err != nil {
+		return fmt.Errorf("can't prepare a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	basePackageRoot, err := packages.ExtractPackageRevision(packageRoot, base, tempDir)
+	if err != nil {
+		return fmt.Errorf("can't read base version of the package: %w", err)
+	}
+
+	changes, err := fields.DiffPackageFields(basePackageRoot, packageRoot)
+	if err != nil {
+		return fmt.Errorf("comparing fields failed: %w", err)
+	}
+	if len(changes) == 0 {
+		cmd.Printf("No changes in field definitions since %s.\n", base)
+		return nil
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Data stream", "Field", "Change", "Details", "Breaking"})
+	breaking := 0
+	for _, change := range changes {
+		dataStream := change.DataStream
+		if dataStream == "" {
+			dataStream = "-"
+		}
+		breakingMark := ""
+		if change.Breaking {
+			breakingMark = "yes"
+			breaking++
+		}
+		t.AppendRow(table.Row{dataStream, change.Field, change.Change, change.Details, breakingMark})
+	}
+	t.SetStyle(table.StyleRounded)
+	cmd.Println(t.Render())
+
+	if breaking > 0 {
+		return fmt.Errorf("found %d breaking changes in field definitions since %s", breaking, base)
+	}
+	return nil
+}
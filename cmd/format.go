@@ -7,6 +7,8 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -17,7 +19,13 @@ import (
 
 const formatLongDescription = `Use this command to format the package files.
 
-The formatter supports JSON and YAML format, and skips "ingest_pipeline" directories as it's hard to correctly format Handlebars template files. Formatted files are being overwritten.`
+The formatter supports JSON and YAML format, and skips "ingest_pipeline" directories as it's hard to correctly format Handlebars template files. Formatted files are being overwritten.
+
+Use --strict to enable additional rules:
+- Ingest pipelines in YAML format are formatted, unless they contain template tags out of strings. Options of processors are sorted in a consistent order, a tag is added to processors without one, and sources of Painless scripts are written as block scalars.
+- Kibana saved objects are canonicalized. Their keys are sorted as when exporting them, including the keys of the objects encoded as JSON strings, and their references are sorted by name.
+
+Use --check to report the files that would be changed, and the rules that change them, without overwriting them. The command fails if any file requires updates.`
 
 func setupFormatCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -28,6 +36,8 @@ func setupFormatCommand() *cobraext.Command {
 		RunE:  formatCommandAction,
 	}
 	cmd.Flags().BoolP(cobraext.FailFastFlagName, "f", false, cobraext.FailFastFlagDescription)
+	cmd.Flags().Bool(cobraext.FormatCheckFlagName, false, cobraext.FormatCheckFlagDescription)
+	cmd.Flags().Bool(cobraext.FormatStrictFlagName, false, cobraext.FormatStrictFlagDescription)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
@@ -48,11 +58,38 @@ func formatCommandAction(cmd *cobra.Command, args []string) error {
 		return cobraext.FlagParsingError(err, cobraext.FailFastFlagName)
 	}
 
-	err = formatter.Format(packageRoot, ff)
+	check, err := cmd.Flags().GetBool(cobraext.FormatCheckFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FormatCheckFlagName)
+	}
+
+	strict, err := cmd.Flags().GetBool(cobraext.FormatStrictFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.FormatStrictFlagName)
+	}
+
+	changes, err := formatter.FormatPackage(packageRoot, formatter.Options{
+		FailFast: ff,
+		Check:    check,
+		Strict:   strict,
+	})
 	if err != nil {
 		return fmt.Errorf("formatting the integration failed (path: %s, failFast: %t): %w", packageRoot, ff, err)
 	}
 
+	if check {
+		for _, change := range changes {
+			relPath, err := filepath.Rel(packageRoot, change.Path)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("%s: %s\n", relPath, strings.Join(change.Rules, ", "))
+		}
+		if len(changes) > 0 {
+			return fmt.Errorf("%d files require updates", len(changes))
+		}
+	}
+
 	cmd.Println("Done")
 	return nil
 }
@@ -0,0 +1,38 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/install"
+)
+
+const importLongDescription = `Use this command to import assets of the package, e.g. Kibana dashboards, into the Elastic stack.`
+
+func setupImportCommand() *cobraext.Command {
+	importDashboardsCmd := &cobra.Command{
+		Use:   "dashboards",
+		Short: "Import dashboards into Kibana",
+		Long:  importDashboardsLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  importDashboardsCmd,
+	}
+	importDashboardsCmd.Flags().Bool(cobraext.DashboardsForceFlagName, false, cobraext.DashboardsForceFlagDescription)
+	importDashboardsCmd.Flags().Bool(cobraext.TLSSkipVerifyFlagName, false, cobraext.TLSSkipVerifyFlagDescription)
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import package assets",
+		Long:  importLongDescription,
+	}
+	cmd.AddCommand(importDashboardsCmd)
+	cmd.PersistentFlags().StringP(cobraext.ProfileFlagName, "p", "", fmt.Sprintf(cobraext.ProfileFlagDescription, install.ProfileNameEnvVar))
+
+	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
+}
@@ -0,0 +1,65 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/export"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/stack"
+)
+
+const importDashboardsLongDescription = `Use this command to import the dashboards and other saved objects of the package into the Kibana instance.
+
+Use this command to upload the locally edited saved objects to Kibana, without reinstalling the package. Saved objects are encoded as Kibana expects them, and the existing ones are overwritten.
+
+Saved objects modified in Kibana since the last export or import are not overwritten without confirmation, the differences with the local files are shown before asking. Use --force to overwrite them without asking.`
+
+func importDashboardsCmd(cmd *cobra.Command, args []string) error {
+	cmd.Println("Import Kibana dashboards")
+
+	force, err := cmd.Flags().GetBool(cobraext.DashboardsForceFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DashboardsForceFlagName)
+	}
+
+	var opts []kibana.ClientOption
+	tlsSkipVerify, _ := cmd.Flags().GetBool(cobraext.TLSSkipVerifyFlagName)
+	if tlsSkipVerify {
+		opts = append(opts, kibana.TLSSkipVerify())
+	}
+
+	profile, err := cobraext.GetProfileFlag(cmd)
+	if err != nil {
+		return err
+	}
+
+	kibanaClient, err := stack.NewKibanaClientFromProfile(profile, opts...)
+	if err != nil {
+		return fmt.Errorf("can't create Kibana client: %w", err)
+	}
+
+	var resolve export.ConflictResolver
+	if !force {
+		resolve = conflictResolver(cmd, "in Kibana")
+	}
+
+	summary, err := export.ImportDashboards(cmd.Context(), kibanaClient, resolve)
+	if err != nil {
+		return fmt.Errorf("dashboards import failed: %w", err)
+	}
+
+	cmd.Printf("Imported saved objects: %d, up-to-date: %d, skipped: %d\n", len(summary.Imported), len(summary.UpToDate), len(summary.Skipped))
+	for _, key := range summary.Skipped {
+		cmd.Printf("Skipped %s\n", key)
+	}
+
+	cmd.Println("Done")
+	return nil
+}
@@ -5,22 +5,29 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
 	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/files"
 	"github.com/elastic/elastic-package/internal/install"
 	"github.com/elastic/elastic-package/internal/kibana"
 	"github.com/elastic/elastic-package/internal/packages"
 	"github.com/elastic/elastic-package/internal/packages/installer"
+	"github.com/elastic/elastic-package/internal/resources"
 	"github.com/elastic/elastic-package/internal/stack"
 )
 
 const installLongDescription = `Use this command to install the package in Kibana.
 
-The command uses Kibana API to install the package in Kibana. The package must be exposed via the Package Registry or built locally in zip format so they can be installed using --zip parameter. Zip packages can be installed directly in Kibana >= 8.7.0. More details in this [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/install_package.md).`
+The command uses Kibana API to install the package in Kibana. The package must be exposed via the Package Registry or built locally in zip format so they can be installed using --zip parameter. Zip packages can be installed directly in Kibana >= 8.7.0. More details in this [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/install_package.md).
+
+An agent policy with package policies can be created after installing the package, by passing a YAML file describing them with the --policy parameter. The --enroll-agent parameter can be used to assign this policy to the Elastic Agent of the stack.`
 
 func setupInstallCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -34,6 +41,8 @@ func setupInstallCommand() *cobraext.Command {
 	cmd.Flags().StringP(cobraext.PackageRootFlagName, cobraext.PackageRootFlagShorthand, "", cobraext.PackageRootFlagDescription)
 	cmd.Flags().StringP(cobraext.ZipPackageFilePathFlagName, cobraext.ZipPackageFilePathFlagShorthand, "", cobraext.ZipPackageFilePathFlagDescription)
 	cmd.Flags().Bool(cobraext.BuildSkipValidationFlagName, false, cobraext.BuildSkipValidationFlagDescription)
+	cmd.Flags().String(cobraext.InstallPolicyFlagName, "", cobraext.InstallPolicyFlagDescription)
+	cmd.Flags().Bool(cobraext.InstallEnrollAgentFlagName, false, cobraext.InstallEnrollAgentFlagDescription)
 	cmd.Flags().StringP(cobraext.ProfileFlagName, "p", "", fmt.Sprintf(cobraext.ProfileFlagDescription, install.ProfileNameEnvVar))
 	cmd.Flags().Bool(cobraext.TLSSkipVerifyFlagName, false, cobraext.TLSSkipVerifyFlagDescription)
 
@@ -53,6 +62,25 @@ func installCommandAction(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return cobraext.FlagParsingError(err, cobraext.BuildSkipValidationFlagName)
 	}
+	policyFilePath, err := cmd.Flags().GetString(cobraext.InstallPolicyFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.InstallPolicyFlagName)
+	}
+	enrollAgent, err := cmd.Flags().GetBool(cobraext.InstallEnrollAgentFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.InstallEnrollAgentFlagName)
+	}
+	if enrollAgent && policyFilePath == "" {
+		return fmt.Errorf("--%s requires --%s", cobraext.InstallEnrollAgentFlagName, cobraext.InstallPolicyFlagName)
+	}
+
+	var policyFile *resources.PolicyFile
+	if policyFilePath != "" {
+		policyFile, err = resources.ReadPolicyFile(policyFilePath)
+		if err != nil {
+			return err
+		}
+	}
 
 	profile, err := cobraext.GetProfileFlag(cmd)
 	if err != nil {
@@ -114,5 +142,129 @@ func installCommandAction(cmd *cobra.Command, _ []string) error {
 	}
 
 	_, err = installer.Install(cmd.Context())
-	return err
+	if err != nil {
+		return err
+	}
+
+	if policyFile == nil {
+		return nil
+	}
+
+	if zipPathFile != "" {
+		workDir, err := os.MkdirTemp("", "elastic-package-install-")
+		if err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+		defer os.RemoveAll(workDir)
+
+		packageRootPath, err = extractZipPackage(zipPathFile, workDir)
+		if err != nil {
+			return err
+		}
+	}
+
+	return createPackagePolicies(cmd, kibanaClient, packageRootPath, policyFile, enrollAgent)
+}
+
+// extractZipPackage extracts the zip package in the given directory and returns the root path of
+// the package, so its manifests can be read to build package policies.
+func extractZipPackage(zipPath, destinationDir string) (string, error) {
+	err := files.Unzip(zipPath, destinationDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract zip package %s: %w", zipPath, err)
+	}
+
+	manifests, err := filepath.Glob(filepath.Join(destinationDir, "*", packages.PackageManifestFile))
+	if err != nil {
+		return "", err
+	}
+	if len(manifests) != 1 {
+		return "", fmt.Errorf("expected one package in zip file %s, found %d", zipPath, len(manifests))
+	}
+	return filepath.Dir(manifests[0]), nil
+}
+
+func createPackagePolicies(cmd *cobra.Command, kibanaClient *kibana.Client, packageRootPath string, policyFile *resources.PolicyFile, enrollAgent bool) error {
+	ctx := cmd.Context()
+
+	policy, err := policyFile.FleetAgentPolicy(packageRootPath)
+	if err != nil {
+		return fmt.Errorf("invalid policy file: %w", err)
+	}
+
+	cmd.Printf("Create agent policy %q\n", policy.Name)
+	manager := resources.NewManager()
+	manager.RegisterProvider(resources.DefaultKibanaProviderName, &resources.KibanaProvider{Client: kibanaClient})
+	_, err = manager.ApplyCtx(ctx, resources.Resources{policy})
+	if err != nil {
+		return fmt.Errorf("failed to create policies: %w", err)
+	}
+
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+	if err != nil {
+		return fmt.Errorf("could not read package manifest at %s: %w", packageRootPath, err)
+	}
+	packagePolicies, err := kibanaClient.ListPackagePolicies(ctx, manifest.Name)
+	if err != nil {
+		return fmt.Errorf("could not list package policies: %w", err)
+	}
+
+	cmd.Printf("Agent policy ID: %s\n", policy.ID)
+	cmd.Println("Package policies:")
+	for _, packagePolicy := range packagePolicies {
+		if packagePolicy.PolicyID != policy.ID {
+			continue
+		}
+		cmd.Printf("- %s (ID: %s)\n", packagePolicy.Name, packagePolicy.ID)
+	}
+
+	if enrollAgent {
+		agent, err := assignPolicyToStackAgent(ctx, kibanaClient, policy.ID)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Agent %s (ID: %s) enrolled in the agent policy\n", agent.LocalMetadata.Host.Name, agent.ID)
+	}
+
+	cmd.Println("Done")
+	return nil
+}
+
+// assignPolicyToStackAgent assigns the agent policy to the Elastic Agent started with the stack.
+func assignPolicyToStackAgent(ctx context.Context, kibanaClient *kibana.Client, policyID string) (*kibana.Agent, error) {
+	agents, err := kibanaClient.ListAgents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list agents: %w", err)
+	}
+
+	var stackAgents []kibana.Agent
+	for _, agent := range agents {
+		// Ignore Fleet Server agents.
+		switch {
+		case agent.LocalMetadata.Host.Name == "docker-fleet-server",
+			agent.PolicyID == "fleet-server-policy",
+			agent.PolicyID == "policy-elastic-agent-on-cloud":
+			continue
+		}
+		stackAgents = append(stackAgents, agent)
+	}
+	if len(stackAgents) == 0 {
+		return nil, errors.New("no Elastic Agent found to enroll, check that the stack includes an Elastic Agent")
+	}
+	if len(stackAgents) > 1 {
+		return nil, fmt.Errorf("found %d Elastic Agents, cannot select the one to enroll", len(stackAgents))
+	}
+
+	// Get the policy to wait for its latest revision, that includes the package policies.
+	policy, err := kibanaClient.GetPolicy(ctx, policyID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get agent policy %s: %w", policyID, err)
+	}
+
+	agent := stackAgents[0]
+	err = kibanaClient.AssignPolicyToAgent(ctx, agent, *policy)
+	if err != nil {
+		return nil, fmt.Errorf("could not assign agent policy to agent %s: %w", agent.ID, err)
+	}
+	return &agent, nil
 }
@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/dashboards"
 	"github.com/elastic/elastic-package/internal/docs"
 	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/packages"
@@ -19,7 +20,11 @@ import (
 
 const lintLongDescription = `Use this command to validate the contents of a package using the package specification (see: https://github.com/elastic/package-spec).
 
-The command ensures that the package is aligned with the package spec and the README file is up-to-date with its template (if present).`
+The command ensures that the package is aligned with the package spec and the README file is up-to-date with its template (if present).
+
+Use --fields to also check field definitions for common issues: duplicated definitions (FLD00001), types conflicting with ECS (FLD00002), missing descriptions (FLD00003), metrics without metric_type or unit in time series data streams (FLD00004), keyword fields whose names suggest IP or date types (FLD00005) and objects without children fields (FLD00006). Checks can be skipped by adding their codes to the exclude_checks list of the validation.yml file of the package.
+
+Use --dashboards to also analyze the dashboards and other saved objects of the package: references to fields not defined in the package (DSH00001), data views not matching the data streams of the package (DSH00002) and deprecated visualization types (DSH00003). These checks can also be skipped with the exclude_checks list.`
 
 func setupLintCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -31,6 +36,8 @@ func setupLintCommand() *cobraext.Command {
 			err := cobraext.ComposeCommandActions(cmd, args,
 				lintCommandAction,
 				validateSourceCommandAction,
+				validateFieldsCommandAction,
+				validateDashboardsCommandAction,
 			)
 			if err != nil {
 				return err
@@ -39,6 +46,8 @@ func setupLintCommand() *cobraext.Command {
 			return nil
 		},
 	}
+	cmd.Flags().Bool(cobraext.LintDashboardsFlagName, false, cobraext.LintDashboardsFlagDescription)
+	cmd.Flags().Bool(cobraext.LintFieldsFlagName, false, cobraext.LintFieldsFlagDescription)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
@@ -78,3 +87,62 @@ func validateSourceCommandAction(cmd *cobra.Command, args []string) error {
 	}
 	return nil
 }
+
+func validateFieldsCommandAction(cmd *cobra.Command, args []string) error {
+	lintFields, err := cmd.Flags().GetBool(cobraext.LintFieldsFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.LintFieldsFlagName)
+	}
+	if !lintFields {
+		return nil
+	}
+
+	packageRootPath, found, err := packages.FindPackageRoot()
+	if !found {
+		return errors.New("package root not found")
+	}
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+	errs, skipped := validation.ValidateFieldsAndFilterFromPath(packageRootPath)
+	if skipped != nil {
+		logger.Infof("Skipped errors: %v", skipped)
+	}
+	if errs != nil {
+		return fmt.Errorf("linting fields failed: %w", errs)
+	}
+	return nil
+}
+
+func validateDashboardsCommandAction(cmd *cobra.Command, args []string) error {
+	lintDashboards, err := cmd.Flags().GetBool(cobraext.LintDashboardsFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.LintDashboardsFlagName)
+	}
+	if !lintDashboards {
+		return nil
+	}
+
+	packageRootPath, found, err := packages.FindPackageRoot()
+	if !found {
+		return errors.New("package root not found")
+	}
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+	allErrors, err := dashboards.LintPackage(packageRootPath)
+	if err != nil {
+		return fmt.Errorf("analyzing dashboards failed: %w", err)
+	}
+	if len(allErrors) == 0 {
+		return nil
+	}
+	errs, skipped := validation.FilterErrorsFromPath(packageRootPath, allErrors)
+	if skipped != nil {
+		logger.Infof("Skipped errors: %v", skipped)
+	}
+	if errs != nil {
+		return fmt.Errorf("linting dashboards failed: %w", errs)
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/elastic/elastic-package/internal/configuration/locations"
 	"github.com/elastic/elastic-package/internal/install"
 	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/stack"
 )
 
 // jsonFormat is the format for JSON output
@@ -181,11 +182,97 @@ User profiles can be configured with a "config.yml" file in the profile director
 		},
 	}
 
+	profileExportCommand := &cobra.Command{
+		Use:   "export [profile] [file]",
+		Short: "Export a profile to a portable archive",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profileName, archivePath := args[0], args[1]
+
+			redactSecrets, err := cmd.Flags().GetBool(cobraext.ProfileRedactSecretsFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.ProfileRedactSecretsFlagName)
+			}
+			excludeCerts, err := cmd.Flags().GetBool(cobraext.ProfileExcludeCertsFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.ProfileExcludeCertsFlagName)
+			}
+
+			manifest, err := profile.ExportProfile(profile.ExportOptions{
+				Name:                profileName,
+				Path:                archivePath,
+				RedactSecrets:       redactSecrets,
+				ExcludeCertificates: excludeCerts,
+			})
+			if err != nil {
+				return fmt.Errorf("error exporting profile %q: %w", profileName, err)
+			}
+
+			for _, redacted := range manifest.Redacted {
+				cmd.Printf("Redacted %s\n", redacted)
+			}
+			cmd.Printf("Exported profile %q to %s.\n", profileName, archivePath)
+			return nil
+		},
+	}
+	profileExportCommand.Flags().Bool(cobraext.ProfileRedactSecretsFlagName, false, cobraext.ProfileRedactSecretsFlagDescription)
+	profileExportCommand.Flags().Bool(cobraext.ProfileExcludeCertsFlagName, false, cobraext.ProfileExcludeCertsFlagDescription)
+
+	profileImportCommand := &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import a profile from an archive",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archivePath := args[0]
+
+			name, err := cmd.Flags().GetString(cobraext.ProfileImportAsFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.ProfileImportAsFlagName)
+			}
+
+			overwrite, err := cmd.Flags().GetBool(cobraext.ProfileImportOverwriteFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.ProfileImportOverwriteFlagName)
+			}
+
+			imported, manifest, err := profile.ImportProfile(profile.ImportOptions{
+				Path:              archivePath,
+				Name:              name,
+				OverwriteExisting: overwrite,
+			})
+			if err != nil {
+				return fmt.Errorf("error importing profile from %s: %w", archivePath, err)
+			}
+
+			if !manifest.IncludesCertificates {
+				cmd.Println("Certificates not included in the archive, generating new ones.")
+				err := stack.InitCertificates(imported)
+				if err != nil {
+					return fmt.Errorf("failed to generate certificates for profile %q: %w", imported.ProfileName, err)
+				}
+			}
+
+			if len(manifest.Redacted) > 0 {
+				cmd.Println("The following settings were redacted on export and need to be set again:")
+				for _, redacted := range manifest.Redacted {
+					cmd.Printf(" - %s\n", redacted)
+				}
+			}
+
+			cmd.Printf("Imported profile %q.\n", imported.ProfileName)
+			return nil
+		},
+	}
+	profileImportCommand.Flags().String(cobraext.ProfileImportAsFlagName, "", cobraext.ProfileImportAsFlagDescription)
+	profileImportCommand.Flags().Bool(cobraext.ProfileImportOverwriteFlagName, false, cobraext.ProfileImportOverwriteFlagDescription)
+
 	profileCommand.AddCommand(
 		profileNewCommand,
 		profileDeleteCommand,
 		profileListCommand,
 		profileUseCommand,
+		profileExportCommand,
+		profileImportCommand,
 	)
 
 	return cobraext.NewCommand(profileCommand, cobraext.ContextGlobal)
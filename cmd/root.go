@@ -24,9 +24,12 @@ var commands = []*cobraext.Command{
 	setupCleanCommand(),
 	setupCreateCommand(),
 	setupDumpCommand(),
+	setupECSCommand(),
 	setupEditCommand(),
 	setupExportCommand(),
+	setupFieldsCommand(),
 	setupFormatCommand(),
+	setupImportCommand(),
 	setupInstallCommand(),
 	setupLintCommand(),
 	setupProfilesCommand(),
@@ -5,9 +5,12 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/jedib0t/go-pretty/table"
 
 	"github.com/spf13/cobra"
@@ -15,6 +18,11 @@ import (
 	"github.com/elastic/elastic-package/internal/cobraext"
 	"github.com/elastic/elastic-package/internal/common"
 	"github.com/elastic/elastic-package/internal/install"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/installer"
+	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/seed"
 	"github.com/elastic/elastic-package/internal/stack"
 )
 
@@ -52,7 +60,13 @@ You can customize your stack using profile settings, see [Elastic Package profil
 There are different providers supported, that can be selected with the --provider flag.
 - compose: Starts a local stack using Docker Compose. This is the default.
 - environment: Prepares an existing stack to be used to test packages. Missing components are started locally using Docker Compose. Environment variables are used to configure the access to the existing Elasticsearch and Kibana instances. You can learn more about this in [this document](./docs/howto/use_existing_stack.md).
-- serverless: Uses Elastic Cloud to start a serverless project. Requires an Elastic Cloud API key. You can learn more about this in [this document](./docs/howto/use_serverless_stack.md).`
+- serverless: Uses Elastic Cloud to start a serverless project. Requires an Elastic Cloud API key. You can learn more about this in [this document](./docs/howto/use_serverless_stack.md).
+
+With the compose provider, the digests of the images used for each stack version are recorded in a lockfile in the profile (stack/images.lock.json) the first time the stack is booted up or updated. Only the images of the enabled services are recorded. Use the --locked flag to use only the recorded digests, it fails if the image of any enabled service is not recorded, so everyone sharing the profile runs the same builds, also with SNAPSHOT versions. Use "stack update --relock" to update the images and record their new digests.`
+
+const stackUpdateLongDescription = `Use this command to pull the most recent versions of the Docker images of the stack.
+
+The digests of the images are recorded in the lockfile of the profile if they were not recorded yet for the stack version. Use --relock to record the digests of the updated images, or --locked to pull the images already recorded.`
 
 const stackShellinitLongDescription = `Use this command to export to the current shell the configuration of the stack managed by elastic-package.
 
@@ -70,6 +84,31 @@ Relevant environment variables are:
 You can also provide these environment variables manually. In that case elastic-package commands will use these settings.
 `
 
+const stackStatusLongDescription = `Use this command to show the status of the services of the stack.
+
+Along with the health of each service, it shows the CPU, memory and disk used by its container, and the heap used by Elasticsearch and Kibana. Disk is the size of the writable layer of the container.
+
+Use the --watch flag to refresh the status periodically.`
+
+const stackSeedLongDescription = `Use this command to index sample documents of the current package in the stack, so dashboards can be developed with realistic data.
+
+The package is installed and documents are indexed in each one of its data streams, with timestamps distributed over a time window that ends now. Documents are obtained from the first source available for each data stream:
+
+- The corpus generator configured in a benchmark scenario of the data stream (_dev/benchmark/rally or _dev/benchmark/system). These documents go through the ingest pipeline of the data stream.
+- The expected results of the pipeline tests of the data stream.
+- The sample_event.json file of the data stream.
+
+Data streams without any of these sources are skipped.`
+
+// stackStatusWatchPeriod is the period between refreshes of stack status in watch mode.
+const stackStatusWatchPeriod = 5 * time.Second
+
+const stackLinkLongDescription = `Use this command to link the stack of the current profile with the stack of another profile, so they can use cross-cluster search between them.
+
+Both stacks must be running with the compose provider. As they run at the same time, one of the profiles needs to set "stack.ports_offset" to avoid conflicts in the published ports.
+
+The certificate authorities of both stacks are exchanged and Elasticsearch is restarted to expose its transport layer to the other stack. Each stack configures the other one as a remote cluster, using the name of its profile as alias, so indices in the linked stack can be queried with patterns like <profile>:logs-*.`
+
 func setupStackCommand() *cobraext.Command {
 	upCommand := &cobra.Command{
 		Use:   "up",
@@ -120,11 +159,17 @@ func setupStackCommand() *cobraext.Command {
 			}
 			profile.RuntimeOverrides(userParameters)
 
+			locked, err := cmd.Flags().GetBool(cobraext.StackLockedFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackLockedFlagName)
+			}
+
 			cmd.Printf("Using profile %s.\n", profile.ProfilePath)
 			err = provider.BootUp(cmd.Context(), stack.Options{
 				DaemonMode:   daemonMode,
 				StackVersion: stackVersion,
 				Services:     services,
+				Locked:       locked,
 				Profile:      profile,
 				Printer:      cmd,
 			})
@@ -142,6 +187,7 @@ func setupStackCommand() *cobraext.Command {
 	upCommand.Flags().StringP(cobraext.StackVersionFlagName, "", install.DefaultStackVersion, cobraext.StackVersionFlagDescription)
 	upCommand.Flags().String(cobraext.StackProviderFlagName, "", fmt.Sprintf(cobraext.StackProviderFlagDescription, strings.Join(stack.SupportedProviders, ", ")))
 	upCommand.Flags().StringSliceP(cobraext.StackUserParameterFlagName, cobraext.StackUserParameterFlagShorthand, nil, cobraext.StackUserParameterDescription)
+	upCommand.Flags().Bool(cobraext.StackLockedFlagName, false, cobraext.StackLockedFlagDescription)
 
 	downCommand := &cobra.Command{
 		Use:   "down",
@@ -176,6 +222,7 @@ func setupStackCommand() *cobraext.Command {
 	updateCommand := &cobra.Command{
 		Use:   "update",
 		Short: "Update the stack to the most recent versions",
+		Long:  stackUpdateLongDescription,
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.Println("Update the Elastic stack")
@@ -195,8 +242,24 @@ func setupStackCommand() *cobraext.Command {
 				return cobraext.FlagParsingError(err, cobraext.StackVersionFlagName)
 			}
 
+			locked, err := cmd.Flags().GetBool(cobraext.StackLockedFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackLockedFlagName)
+			}
+
+			relock, err := cmd.Flags().GetBool(cobraext.StackRelockFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackRelockFlagName)
+			}
+
+			if locked && relock {
+				return fmt.Errorf("--%s and --%s cannot be used at the same time", cobraext.StackLockedFlagName, cobraext.StackRelockFlagName)
+			}
+
 			err = provider.Update(cmd.Context(), stack.Options{
 				StackVersion: stackVersion,
+				Locked:       locked,
+				Relock:       relock,
 				Profile:      profile,
 				Printer:      cmd,
 			})
@@ -209,6 +272,8 @@ func setupStackCommand() *cobraext.Command {
 		},
 	}
 	updateCommand.Flags().StringP(cobraext.StackVersionFlagName, "", install.DefaultStackVersion, cobraext.StackVersionFlagDescription)
+	updateCommand.Flags().Bool(cobraext.StackLockedFlagName, false, cobraext.StackLockedFlagDescription)
+	updateCommand.Flags().Bool(cobraext.StackRelockFlagName, false, cobraext.StackRelockFlagDescription)
 
 	shellInitCommand := &cobra.Command{
 		Use:   "shellinit",
@@ -280,6 +345,7 @@ func setupStackCommand() *cobraext.Command {
 	statusCommand := &cobra.Command{
 		Use:   "status",
 		Short: "Show status of the stack services",
+		Long:  stackStatusLongDescription,
 		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			profile, err := cobraext.GetProfileFlag(cmd)
@@ -292,20 +358,102 @@ func setupStackCommand() *cobraext.Command {
 				return err
 			}
 
-			servicesStatus, err := provider.Status(cmd.Context(), stack.Options{
-				Profile: profile,
-				Printer: cmd,
+			watch, err := cmd.Flags().GetBool(cobraext.StackStatusWatchFlagName)
+			if err != nil {
+				return cobraext.FlagParsingError(err, cobraext.StackStatusWatchFlagName)
+			}
+
+			showStatus := func() error {
+				servicesStatus, err := provider.Status(cmd.Context(), stack.Options{
+					Profile: profile,
+					Printer: cmd,
+				})
+				if err != nil {
+					return fmt.Errorf("failed getting stack status: %w", err)
+				}
+
+				// Resource usage is only available for local stacks managed with docker compose.
+				var resourceUsage []stack.ServiceResourceUsage
+				if isComposeStack(profile) {
+					resourceUsage, err = stack.ResourceUsage(cmd.Context(), profile)
+					if err != nil {
+						logger.Warnf("Failed getting resource usage: %v", err)
+					}
+				}
+
+				cmd.Println("Status of Elastic stack services:")
+				printStatus(cmd, servicesStatus, resourceUsage)
+				return nil
+			}
+
+			if !watch {
+				return showStatus()
+			}
+
+			ticker := time.NewTicker(stackStatusWatchPeriod)
+			defer ticker.Stop()
+			for {
+				// Clear the screen before printing the status again.
+				cmd.Print("\033[H\033[2J")
+				if err := showStatus(); err != nil {
+					return err
+				}
+				select {
+				case <-cmd.Context().Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	statusCommand.Flags().Bool(cobraext.StackStatusWatchFlagName, false, cobraext.StackStatusWatchFlagDescription)
+
+	linkCommand := &cobra.Command{
+		Use:   "link [profile]",
+		Short: "Link the stack with the stack of another profile for cross-cluster search",
+		Long:  stackLinkLongDescription,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			localProfile, err := cobraext.GetProfileFlag(cmd)
+			if err != nil {
+				return err
+			}
+
+			remoteProfile, err := profile.LoadProfile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to load profile %q: %w", args[0], err)
+			}
+
+			cmd.Printf("Link the Elastic stacks of profiles %q and %q\n", localProfile.ProfileName, remoteProfile.ProfileName)
+			err = stack.Link(cmd.Context(), stack.LinkOptions{
+				Profile:       localProfile,
+				RemoteProfile: remoteProfile,
+				Printer:       cmd,
 			})
 			if err != nil {
-				return fmt.Errorf("failed getting stack status: %w", err)
+				return fmt.Errorf("linking stacks failed: %w", err)
 			}
 
-			cmd.Println("Status of Elastic stack services:")
-			printStatus(cmd, servicesStatus)
+			cmd.Printf("Remote cluster %q available in profile %q (e.g. %s:logs-*)\n", remoteProfile.ProfileName, localProfile.ProfileName, remoteProfile.ProfileName)
+			cmd.Printf("Remote cluster %q available in profile %q (e.g. %s:logs-*)\n", localProfile.ProfileName, remoteProfile.ProfileName, localProfile.ProfileName)
+			cmd.Println("Done")
 			return nil
 		},
 	}
 
+	seedCommand := &cobra.Command{
+		Use:   "seed",
+		Short: "Index sample documents of the package in the stack",
+		Long:  stackSeedLongDescription,
+		Args:  cobra.NoArgs,
+		RunE:  stackSeedCommandAction,
+	}
+	seedCommand.Flags().StringP(cobraext.PackageRootFlagName, cobraext.PackageRootFlagShorthand, "", cobraext.PackageRootFlagDescription)
+	seedCommand.Flags().StringSliceP(cobraext.DataStreamsFlagName, "d", nil, cobraext.DataStreamsFlagDescription)
+	seedCommand.Flags().Int(cobraext.StackSeedEventsFlagName, seed.DefaultEventsPerDataStream, cobraext.StackSeedEventsFlagDescription)
+	seedCommand.Flags().String(cobraext.StackSeedNamespaceFlagName, seed.DefaultNamespace, cobraext.StackSeedNamespaceFlagDescription)
+	seedCommand.Flags().Duration(cobraext.StackSeedTimeWindowFlagName, seed.DefaultTimeWindow, cobraext.StackSeedTimeWindowFlagDescription)
+
 	cmd := &cobra.Command{
 		Use:   "stack",
 		Short: "Manage the Elastic stack",
@@ -318,11 +466,101 @@ func setupStackCommand() *cobraext.Command {
 		updateCommand,
 		shellInitCommand,
 		dumpCommand,
-		statusCommand)
+		statusCommand,
+		linkCommand,
+		seedCommand)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextGlobal)
 }
 
+func stackSeedCommandAction(cmd *cobra.Command, _ []string) error {
+	packageRootPath, err := cmd.Flags().GetString(cobraext.PackageRootFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.PackageRootFlagName)
+	}
+	dataStreams, err := cmd.Flags().GetStringSlice(cobraext.DataStreamsFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.DataStreamsFlagName)
+	}
+	events, err := cmd.Flags().GetInt(cobraext.StackSeedEventsFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.StackSeedEventsFlagName)
+	}
+	namespace, err := cmd.Flags().GetString(cobraext.StackSeedNamespaceFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.StackSeedNamespaceFlagName)
+	}
+	timeWindow, err := cmd.Flags().GetDuration(cobraext.StackSeedTimeWindowFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.StackSeedTimeWindowFlagName)
+	}
+
+	if packageRootPath == "" {
+		var found bool
+		packageRootPath, found, err = packages.FindPackageRoot()
+		if err != nil {
+			return fmt.Errorf("locating package root failed: %w", err)
+		}
+		if !found {
+			return errors.New("package root not found")
+		}
+	}
+
+	profile, err := cobraext.GetProfileFlag(cmd)
+	if err != nil {
+		return err
+	}
+
+	kibanaClient, err := stack.NewKibanaClientFromProfile(profile)
+	if err != nil {
+		return fmt.Errorf("could not create kibana client: %w", err)
+	}
+	esClient, err := stack.NewElasticsearchClientFromProfile(profile)
+	if err != nil {
+		return fmt.Errorf("could not create elasticsearch client: %w", err)
+	}
+
+	packageInstaller, err := installer.NewForPackage(cmd.Context(), installer.Options{
+		Kibana:   kibanaClient,
+		RootPath: packageRootPath,
+	})
+	if err != nil {
+		return fmt.Errorf("package installation failed: %w", err)
+	}
+	_, err = packageInstaller.Install(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("package installation failed: %w", err)
+	}
+
+	cmd.Println("Index documents in the data streams of the package")
+	results, err := seed.Seed(cmd.Context(), seed.Options{
+		ESAPI:               esClient.API,
+		PackageRootPath:     packageRootPath,
+		DataStreams:         dataStreams,
+		Namespace:           namespace,
+		TimeWindow:          timeWindow,
+		EventsPerDataStream: events,
+	})
+	if err != nil {
+		return fmt.Errorf("seeding stack failed: %w", err)
+	}
+	if len(results) == 0 {
+		cmd.Println("No documents found to index, add sample events, pipeline tests or benchmark scenarios to the data streams of the package.")
+		return nil
+	}
+
+	t := table.NewWriter()
+	t.AppendHeader(table.Row{"Data stream", "Source", "Documents"})
+	for _, result := range results {
+		t.AppendRow(table.Row{result.DataStream, result.Source, result.Documents})
+	}
+	t.SetStyle(table.StyleRounded)
+	cmd.Println(t.Render())
+
+	cmd.Println("Done")
+	return nil
+}
+
 func availableServicesAsList() []string {
 	available := make([]string, len(availableServices))
 	i := 0
@@ -350,16 +588,55 @@ func validateServicesFlag(services []string) error {
 	return nil
 }
 
-func printStatus(cmd *cobra.Command, servicesStatus []stack.ServiceStatus) {
+func isComposeStack(profile *profile.Profile) bool {
+	config, err := stack.LoadConfig(profile)
+	if err != nil {
+		return false
+	}
+	return config.Provider == "" || config.Provider == stack.ProviderCompose
+}
+
+func printStatus(cmd *cobra.Command, servicesStatus []stack.ServiceStatus, resourceUsage []stack.ServiceResourceUsage) {
 	if len(servicesStatus) == 0 {
 		cmd.Printf(" - No service running\n")
 		return
 	}
 	t := table.NewWriter()
-	t.AppendHeader(table.Row{"Service", "Version", "Status"})
+	if len(resourceUsage) == 0 {
+		t.AppendHeader(table.Row{"Service", "Version", "Status"})
+		for _, service := range servicesStatus {
+			t.AppendRow(table.Row{service.Name, service.Version, service.Status})
+		}
+		t.SetStyle(table.StyleRounded)
+		cmd.Println(t.Render())
+		return
+	}
+	t.AppendHeader(table.Row{"Service", "Version", "Status", "CPU", "Memory", "Disk", "Heap"})
+
+	usageByService := make(map[string]stack.ServiceResourceUsage)
+	for _, usage := range resourceUsage {
+		usageByService[usage.Name] = usage
+	}
 
 	for _, service := range servicesStatus {
-		t.AppendRow(table.Row{service.Name, service.Version, service.Status})
+		usage, found := usageByService[service.Name]
+		if !found {
+			t.AppendRow(table.Row{service.Name, service.Version, service.Status, "-", "-", "-", "-"})
+			continue
+		}
+		heap := "-"
+		if usage.HeapLimit > 0 {
+			heap = fmt.Sprintf("%s / %s", humanize.IBytes(usage.HeapUsage), humanize.IBytes(usage.HeapLimit))
+		}
+		t.AppendRow(table.Row{
+			service.Name,
+			service.Version,
+			service.Status,
+			fmt.Sprintf("%.2f%%", usage.CPUPercentage),
+			fmt.Sprintf("%s / %s", humanize.IBytes(usage.MemoryUsage), humanize.IBytes(usage.MemoryLimit)),
+			humanize.IBytes(usage.DiskUsage),
+			heap,
+		})
 	}
 	t.SetStyle(table.StyleRounded)
 	cmd.Println(t.Render())
@@ -30,6 +30,7 @@ import (
 	"github.com/elastic/elastic-package/internal/testrunner/runners/policy"
 	"github.com/elastic/elastic-package/internal/testrunner/runners/static"
 	"github.com/elastic/elastic-package/internal/testrunner/runners/system"
+	"github.com/elastic/elastic-package/internal/testrunner/runners/upgrade"
 )
 
 const testLongDescription = `Use this command to run tests on a package. Currently, the following types of tests are available:
@@ -57,7 +58,12 @@ For details on how to configure and run system tests, review the [HOWTO guide](h
 #### Policy Tests
 These tests allow you to test different configuration options and the policies they generate, without needing to run a full scenario.
 
-For details on how to configure and run policy tests, review the [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/policy_testing.md).`
+For details on how to configure and run policy tests, review the [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/policy_testing.md).
+
+#### Upgrade Tests
+These tests allow you to verify that the package can be upgraded from its latest released version, with existing package policies and ingested data.
+
+For details on how to configure and run upgrade tests, review the [HOWTO guide](https://github.com/elastic/elastic-package/blob/main/docs/howto/upgrade_testing.md).`
 
 func setupTestCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -97,6 +103,9 @@ func setupTestCommand() *cobraext.Command {
 	policyCmd := getTestRunnerPolicyCommand()
 	cmd.AddCommand(policyCmd)
 
+	upgradeCmd := getTestRunnerUpgradeCommand()
+	cmd.AddCommand(upgradeCmd)
+
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
 
@@ -109,6 +118,8 @@ func getTestRunnerAssetCommand() *cobra.Command {
 		RunE:  testRunnerAssetCommandAction,
 	}
 
+	cmd.Flags().Bool(cobraext.TestDeepFlagName, false, cobraext.TestDeepFlagDescription)
+
 	return cmd
 }
 
@@ -145,6 +156,11 @@ func testRunnerAssetCommandAction(cmd *cobra.Command, args []string) error {
 		return cobraext.FlagParsingError(fmt.Errorf("coverage format not available: %s", testCoverageFormat), cobraext.TestCoverageFormatFlagName)
 	}
 
+	deep, err := cmd.Flags().GetBool(cobraext.TestDeepFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.TestDeepFlagName)
+	}
+
 	packageRootPath, found, err := packages.FindPackageRoot()
 	if !found {
 		return errors.New("package root not found")
@@ -177,6 +193,7 @@ func testRunnerAssetCommandAction(cmd *cobra.Command, args []string) error {
 		GlobalTestConfig: globalTestConfig.Asset,
 		WithCoverage:     testCoverage,
 		CoverageType:     testCoverageFormat,
+		Deep:             deep,
 	})
 
 	results, err := testrunner.RunSuite(ctx, runner)
@@ -701,6 +718,94 @@ func testRunnerPolicyCommandAction(cmd *cobra.Command, args []string) error {
 	return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, testCoverageFormat, testCoverage)
 }
 
+func getTestRunnerUpgradeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Run upgrade tests",
+		Long:  "Run upgrade tests for the package, from its latest released version to the local version.",
+		Args:  cobra.NoArgs,
+		RunE:  testRunnerUpgradeCommandAction,
+	}
+
+	cmd.Flags().StringSliceP(cobraext.DataStreamsFlagName, "d", nil, cobraext.DataStreamsFlagDescription)
+	return cmd
+}
+
+func testRunnerUpgradeCommandAction(cmd *cobra.Command, args []string) error {
+	cmd.Printf("Run upgrade tests for the package\n")
+	testType := testrunner.TestType("upgrade")
+
+	profile, err := cobraext.GetProfileFlag(cmd)
+	if err != nil {
+		return err
+	}
+
+	reportFormat, err := cmd.Flags().GetString(cobraext.ReportFormatFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.ReportFormatFlagName)
+	}
+
+	reportOutput, err := cmd.Flags().GetString(cobraext.ReportOutputFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.ReportOutputFlagName)
+	}
+
+	packageRootPath, found, err := packages.FindPackageRoot()
+	if !found {
+		return errors.New("package root not found")
+	}
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+
+	dataStreams, err := getDataStreamsFlag(cmd, packageRootPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.Enable(cmd.Context(), logger.Info)
+	defer stop()
+
+	kibanaClient, err := stack.NewKibanaClientFromProfile(profile)
+	if err != nil {
+		return fmt.Errorf("can't create Kibana client: %w", err)
+	}
+
+	esClient, err := stack.NewElasticsearchClientFromProfile(profile)
+	if err != nil {
+		return fmt.Errorf("can't create Elasticsearch client: %w", err)
+	}
+	err = esClient.CheckHealth(ctx)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+	if err != nil {
+		return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
+	}
+
+	globalTestConfig, err := testrunner.ReadGlobalTestConfig(packageRootPath)
+	if err != nil {
+		return fmt.Errorf("failed to read global config: %w", err)
+	}
+
+	runner := upgrade.NewUpgradeTestRunner(upgrade.UpgradeTestRunnerOptions{
+		PackageRootPath:  packageRootPath,
+		KibanaClient:     kibanaClient,
+		API:              esClient.API,
+		DataStreams:      dataStreams,
+		GlobalTestConfig: globalTestConfig.Upgrade,
+	})
+
+	results, err := testrunner.RunSuite(ctx, runner)
+	if err != nil {
+		return err
+	}
+
+	return processResults(results, testType, reportFormat, reportOutput, packageRootPath, manifest.Name, manifest.Type, "", false)
+}
+
 func processResults(results []testrunner.TestResult, testType testrunner.TestType, reportFormat, reportOutput, packageRootPath, packageName, packageType, testCoverageFormat string, testCoverage bool) error {
 	sort.Slice(results, func(i, j int) bool {
 		if results[i].Package != results[j].Package {
@@ -10,8 +10,11 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/elastic/elastic-package/internal/cleanup"
 	"github.com/elastic/elastic-package/internal/cobraext"
+	"github.com/elastic/elastic-package/internal/elasticsearch"
 	"github.com/elastic/elastic-package/internal/install"
+	"github.com/elastic/elastic-package/internal/kibana"
 	"github.com/elastic/elastic-package/internal/packages"
 	"github.com/elastic/elastic-package/internal/packages/installer"
 	"github.com/elastic/elastic-package/internal/stack"
@@ -19,7 +22,9 @@ import (
 
 const uninstallLongDescription = `Use this command to uninstall the package in Kibana.
 
-The command uses Kibana API to uninstall the package in Kibana. The package must be exposed via the Package Registry.`
+The command uses Kibana API to uninstall the package in Kibana. The package must be exposed via the Package Registry.
+
+Uninstallation fails if the package is still used by package policies, and it keeps the data ingested with the package. The --purge parameter can be used to also delete the package policies, the agent policies that only contain this package and have no agents enrolled, the data streams of the package and the index templates, component templates and ingest pipelines left behind. Use --dry-run to list what would be deleted without deleting it.`
 
 func setupUninstallCommand() *cobraext.Command {
 	cmd := &cobra.Command{
@@ -30,6 +35,8 @@ func setupUninstallCommand() *cobraext.Command {
 		RunE:  uninstallCommandAction,
 	}
 	cmd.Flags().StringP(cobraext.ProfileFlagName, "p", "", fmt.Sprintf(cobraext.ProfileFlagDescription, install.ProfileNameEnvVar))
+	cmd.Flags().Bool(cobraext.UninstallPurgeFlagName, false, cobraext.UninstallPurgeFlagDescription)
+	cmd.Flags().Bool(cobraext.UninstallDryRunFlagName, false, cobraext.UninstallDryRunFlagDescription)
 
 	return cobraext.NewCommand(cmd, cobraext.ContextPackage)
 }
@@ -43,6 +50,18 @@ func uninstallCommandAction(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("locating package root failed: %w", err)
 	}
 
+	purge, err := cmd.Flags().GetBool(cobraext.UninstallPurgeFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.UninstallPurgeFlagName)
+	}
+	dryRun, err := cmd.Flags().GetBool(cobraext.UninstallDryRunFlagName)
+	if err != nil {
+		return cobraext.FlagParsingError(err, cobraext.UninstallDryRunFlagName)
+	}
+	if dryRun && !purge {
+		return fmt.Errorf("--%s requires --%s", cobraext.UninstallDryRunFlagName, cobraext.UninstallPurgeFlagName)
+	}
+
 	profile, err := cobraext.GetProfileFlag(cmd)
 	if err != nil {
 		return err
@@ -52,6 +71,15 @@ func uninstallCommandAction(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("could not create kibana client: %w", err)
 	}
+
+	if purge {
+		esClient, err := stack.NewElasticsearchClientFromProfile(profile)
+		if err != nil {
+			return fmt.Errorf("could not create Elasticsearch client: %w", err)
+		}
+		return purgePackage(cmd, kibanaClient, esClient, packageRootPath, dryRun)
+	}
+
 	packageInstaller, err := installer.CreateForManifest(kibanaClient, packageRootPath)
 	if err != nil {
 		return fmt.Errorf("can't create the package installer: %w", err)
@@ -66,3 +94,33 @@ func uninstallCommandAction(cmd *cobra.Command, args []string) error {
 	cmd.Println("Done")
 	return nil
 }
+
+func purgePackage(cmd *cobra.Command, kibanaClient *kibana.Client, esClient *elasticsearch.Client, packageRootPath string, dryRun bool) error {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+	if err != nil {
+		return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRootPath, err)
+	}
+
+	if dryRun {
+		cmd.Println("Resources that would be deleted (dry run)")
+	} else {
+		cmd.Println("Uninstall the package and delete its resources")
+	}
+	deleted, err := cleanup.Package(cmd.Context(), cleanup.PackageOptions{
+		KibanaClient: kibanaClient,
+		ESAPI:        esClient.API,
+		PackageName:  manifest.Name,
+		DryRun:       dryRun,
+	})
+	for _, resource := range deleted {
+		cmd.Printf("- %s\n", resource)
+	}
+	if err != nil {
+		return fmt.Errorf("can't purge the package: %w", err)
+	}
+	if len(deleted) == 0 {
+		cmd.Println("Nothing to delete")
+	}
+	cmd.Println("Done")
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"sort"
 	"time"
 
@@ -90,6 +91,10 @@ func (r *runner) benchmarkPipeline(ctx context.Context, b *benchmark, entryPipel
 		if processor.Type == "pipeline" {
 			return ""
 		}
+		// Processors included from pipeline snippets are reported with their location in the snippet.
+		if path, line := pipeline.LineSource(processor.FirstLine); path != pipeline.Path {
+			return fmt.Sprintf("%s @ %s:%d", processor.Type, filepath.Base(path), line)
+		}
 		return fmt.Sprintf("%s @ %s:%d", processor.Type, pipeline.Filename(), processor.FirstLine)
 	}
 	byAbsoluteTime := func(record ingest.StatsRecord) int64 {
@@ -19,7 +19,9 @@ import (
 	"github.com/elastic/elastic-package/internal/elasticsearch"
 	"github.com/elastic/elastic-package/internal/elasticsearch/ingest"
 	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/profile"
 	"github.com/elastic/elastic-package/internal/servicedeployer"
+	"github.com/elastic/elastic-package/internal/stack"
 )
 
 type collector struct {
@@ -30,6 +32,7 @@ type collector struct {
 	interval       time.Duration
 	esAPI          *elasticsearch.API
 	metricsAPI     *elasticsearch.API
+	profile        *profile.Profile
 	datastream     string
 	pipelinePrefix string
 
@@ -45,12 +48,28 @@ type collector struct {
 	diskUsage          map[string]ingest.DiskUsage
 	startTotalHits     int
 	endTotalHits       int
+
+	resources      *stack.ResourceUsageCollector
+	stackResources map[string]*stackResourceSummary
 }
 
 type metrics struct {
-	ts        int64
-	dsMetrics *ingest.DataStreamStats
-	nMetrics  *ingest.NodesStats
+	ts             int64
+	dsMetrics      *ingest.DataStreamStats
+	nMetrics       *ingest.NodesStats
+	stackResources []stack.ServiceResourceUsage
+}
+
+// stackResourceSummary aggregates the samples of resources used by a service of the stack.
+type stackResourceSummary struct {
+	Samples          int
+	MaxCPUPercentage float64
+	AvgCPUPercentage float64
+	MaxMemoryUsage   uint64
+	MemoryLimit      uint64
+	MaxHeapUsage     uint64
+	HeapLimit        uint64
+	DiskUsage        uint64
 }
 
 type metricsSummary struct {
@@ -64,6 +83,7 @@ type metricsSummary struct {
 	DiskUsage           map[string]ingest.DiskUsage
 	TotalHits           int
 	NodesStats          map[string]ingest.NodeStats
+	StackResources      map[string]*stackResourceSummary
 }
 
 func newCollector(
@@ -71,6 +91,7 @@ func newCollector(
 	benchName string,
 	scenario scenario,
 	esAPI, metricsAPI *elasticsearch.API,
+	profile *profile.Profile,
 	interval time.Duration,
 	datastream, pipelinePrefix string,
 ) *collector {
@@ -84,15 +105,18 @@ func newCollector(
 		metadata:       meta,
 		esAPI:          esAPI,
 		metricsAPI:     metricsAPI,
+		profile:        profile,
 		datastream:     datastream,
 		pipelinePrefix: pipelinePrefix,
 		stopC:          make(chan struct{}),
+		stackResources: make(map[string]*stackResourceSummary),
 	}
 }
 
 func (c *collector) start(ctx context.Context) {
 	c.tick = time.NewTicker(c.interval)
 	c.createMetricsIndex()
+	c.createResourceUsageCollector()
 	var once sync.Once
 
 	c.wg.Add(1)
@@ -111,10 +135,10 @@ func (c *collector) start(ctx context.Context) {
 					c.waitUntilReady()
 					c.startIngestMetrics = c.collectIngestMetrics()
 					c.startTotalHits = c.collectTotalHits(ctx)
-					c.startMetrics = c.collect()
+					c.startMetrics = c.collect(ctx, true)
 					c.publish(c.createEventsFromMetrics(c.startMetrics))
 				})
-				m := c.collect()
+				m := c.collect(ctx, false)
 				c.publish(c.createEventsFromMetrics(m))
 			}
 		}
@@ -129,7 +153,23 @@ func (c *collector) stop() {
 	c.wg.Wait()
 }
 
-func (c *collector) collect() metrics {
+// createResourceUsageCollector looks for the containers of the stack, so they are not looked
+// for on every sample.
+func (c *collector) createResourceUsageCollector() {
+	if c.profile == nil {
+		return
+	}
+	resources, err := stack.NewResourceUsageCollector(c.profile)
+	if err != nil {
+		logger.Debugf("could not collect stack resource usage: %v", err)
+		return
+	}
+	c.resources = resources
+}
+
+// collect collects the metrics of a sample. Disk usage of the stack is expensive to obtain,
+// so it is only collected when requested.
+func (c *collector) collect(ctx context.Context, withDiskUsage bool) metrics {
 	m := metrics{
 		ts: time.Now().Unix(),
 	}
@@ -141,6 +181,16 @@ func (c *collector) collect() metrics {
 		m.nMetrics = nstats
 	}
 
+	if c.resources != nil {
+		usage, err := c.resources.Collect(ctx, m.nMetrics, withDiskUsage)
+		if err != nil {
+			logger.Debugf("could not get stack resource usage: %v", err)
+		} else {
+			m.stackResources = usage
+			c.aggregateStackResources(usage)
+		}
+	}
+
 	dsstats, err := ingest.GetDataStreamStats(c.esAPI, c.datastream)
 	if err != nil {
 		logger.Debug(err)
@@ -151,6 +201,26 @@ func (c *collector) collect() metrics {
 	return m
 }
 
+func (c *collector) aggregateStackResources(usage []stack.ServiceResourceUsage) {
+	for _, u := range usage {
+		summary, found := c.stackResources[u.Name]
+		if !found {
+			summary = &stackResourceSummary{}
+			c.stackResources[u.Name] = summary
+		}
+		summary.AvgCPUPercentage = (summary.AvgCPUPercentage*float64(summary.Samples) + u.CPUPercentage) / float64(summary.Samples+1)
+		summary.Samples++
+		summary.MaxCPUPercentage = max(summary.MaxCPUPercentage, u.CPUPercentage)
+		summary.MaxMemoryUsage = max(summary.MaxMemoryUsage, u.MemoryUsage)
+		summary.MemoryLimit = u.MemoryLimit
+		summary.MaxHeapUsage = max(summary.MaxHeapUsage, u.HeapUsage)
+		summary.HeapLimit = u.HeapLimit
+		if u.DiskUsage > 0 {
+			summary.DiskUsage = u.DiskUsage
+		}
+	}
+}
+
 func (c *collector) publish(events [][]byte) {
 	if c.metricsAPI == nil {
 		return
@@ -213,6 +283,7 @@ func (c *collector) summarize() (*metricsSummary, error) {
 		NodesStats:          make(map[string]ingest.NodeStats),
 		DiskUsage:           c.diskUsage,
 		TotalHits:           c.endTotalHits - c.startTotalHits,
+		StackResources:      c.stackResources,
 	}
 
 	sum.ClusterName = c.startMetrics.nMetrics.ClusterName
@@ -317,7 +388,7 @@ func (c *collector) collectMetricsPreviousToStop(ctx context.Context) {
 	c.endIngestMetrics = c.collectIngestMetrics()
 	c.diskUsage = c.collectDiskUsage()
 	c.endTotalHits = c.collectTotalHits(ctx)
-	c.endMetrics = c.collect()
+	c.endMetrics = c.collect(ctx, true)
 }
 
 func (c *collector) collectTotalHits(ctx context.Context) int {
@@ -359,6 +430,20 @@ func (c *collector) createEventsFromMetrics(m metrics) [][]byte {
 		})
 	}
 
+	type containerEvent struct {
+		Ts      int64                      `json:"@timestamp"`
+		Service stack.ServiceResourceUsage `json:"stack_service"`
+		Meta    benchMeta                  `json:"benchmark_metadata"`
+	}
+
+	for _, usage := range m.stackResources {
+		nEvents = append(nEvents, containerEvent{
+			Ts:      m.ts * 1000, // ms to s
+			Service: usage,
+			Meta:    c.metadata,
+		})
+	}
+
 	var events [][]byte
 	for _, e := range append(nEvents, dsEvent) {
 		b, err := json.Marshal(e)
@@ -7,6 +7,7 @@ package system
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -45,6 +46,7 @@ type report struct {
 	IngestPipelineStats map[string]ingest.PipelineStatsMap
 	DiskUsage           map[string]ingest.DiskUsage
 	TotalHits           int
+	StackResources      map[string]*stackResourceSummary
 }
 
 func createReport(benchName, corporaFile string, s *scenario, sum *metricsSummary) (reporters.Reportable, error) {
@@ -87,6 +89,7 @@ func newReport(benchName, corporaFile string, s *scenario, sum *metricsSummary)
 	report.IngestPipelineStats = sum.IngestPipelineStats
 	report.DiskUsage = sum.DiskUsage
 	report.TotalHits = sum.TotalHits
+	report.StackResources = sum.StackResources
 	return &report
 }
 
@@ -185,6 +188,29 @@ func reportHumanFormat(r *report) []byte {
 		) + "\n")
 	}
 
+	services := make([]string, 0, len(r.StackResources))
+	for service := range r.StackResources {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		res := r.StackResources[service]
+		kvs := []interface{}{
+			"samples", res.Samples,
+			"max cpu", fmt.Sprintf("%.2f%%", res.MaxCPUPercentage),
+			"avg cpu", fmt.Sprintf("%.2f%%", res.AvgCPUPercentage),
+			"max memory", fmt.Sprintf("%s / %s", humanize.IBytes(res.MaxMemoryUsage), humanize.IBytes(res.MemoryLimit)),
+			"disk", humanize.IBytes(res.DiskUsage),
+		}
+		if res.HeapLimit > 0 {
+			kvs = append(kvs, "max heap", fmt.Sprintf("%s / %s", humanize.IBytes(res.MaxHeapUsage), humanize.IBytes(res.HeapLimit)))
+		}
+		report.WriteString(renderBenchmarkTable(
+			fmt.Sprintf("resource usage of stack service %s", service),
+			kvs...,
+		) + "\n")
+	}
+
 	for node, pStats := range r.IngestPipelineStats {
 		for pipeline, stats := range pStats {
 			if stats.Count == 0 {
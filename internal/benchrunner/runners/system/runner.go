@@ -333,6 +333,7 @@ func (r *runner) startMetricsColletion(ctx context.Context) {
 		*r.scenario,
 		r.options.ESAPI,
 		r.options.ESMetricsAPI,
+		r.options.Profile,
 		r.options.MetricsInterval,
 		r.runtimeDataStream,
 		r.pipelinePrefix,
@@ -0,0 +1,368 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/magefile/mage/sh"
+
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/version"
+)
+
+const buildStateFolder = "build-state"
+
+var (
+	// Directories and files that are never part of the package inputs, as in files.CopyWithoutDev.
+	buildStateSkippedDirs      = []string{"build", ".git"}
+	buildStateSkippedFileGlobs = []string{".DS_Store", ".*.swp"}
+)
+
+// buildStep is a step of the build that modifies some files of the built package.
+type buildStep struct {
+	name string
+
+	// targets selects the files of the built package modified by the step. Target files are copied
+	// again from the source package before running the step, so steps don't need to be idempotent.
+	targets func(path string) bool
+
+	// inputs selects additional source files the result of the step depends on.
+	inputs func(path string) bool
+
+	// extraInput returns other inputs of the step that are not package files.
+	extraInput func() (string, error)
+
+	run func(packageRoot, destinationDir string) error
+}
+
+// buildSteps returns the steps of the build, in the order they are executed. Targets of the steps
+// must not overlap.
+func buildSteps() []buildStep {
+	return []buildStep{
+		{
+			name:       "copy license file",
+			targets:    matchPaths(licenseTextFileName),
+			extraInput: repositoryLicenseInput,
+			run: func(_, destinationDir string) error {
+				return copyLicenseTextFile(filepath.Join(destinationDir, licenseTextFileName))
+			},
+		},
+		{
+			name:    "encode dashboards",
+			targets: matchPaths("kibana/*/*"),
+			run: func(_, destinationDir string) error {
+				return encodeDashboards(destinationDir)
+			},
+		},
+		{
+			name:    "expand pipeline snippets",
+			targets: matchPaths("data_stream/*/elasticsearch/ingest_pipeline/*"),
+			inputs: func(p string) bool {
+				return strings.HasPrefix(p, "_dev/build/pipeline-snippets/")
+			},
+			run: expandPipelineSnippets,
+		},
+		{
+			name:    "resolve external fields",
+			targets: matchPaths("fields/*.yml", "data_stream/*/fields/*.yml", "elasticsearch/transform/*/fields/*.yml"),
+			inputs:  matchPaths("_dev/build/build.yml", "manifest.yml"),
+			run:     resolveExternalFields,
+		},
+		{
+			name:    "add dynamic mappings",
+			targets: matchPaths("manifest.yml", "data_stream/*/manifest.yml"),
+			inputs:  matchPaths("_dev/build/build.yml"),
+			run:     addDynamicMappings,
+		},
+	}
+}
+
+func matchPaths(patterns ...string) func(string) bool {
+	return func(p string) bool {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, p); matched {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func repositoryLicenseInput() (string, error) {
+	repositoryLicenseTextFileName, userDefined := os.LookupEnv(repositoryLicenseEnv)
+	if !userDefined {
+		repositoryLicenseTextFileName = licenseTextFileName
+	}
+	sourceLicensePath, err := findRepositoryLicense(repositoryLicenseTextFileName)
+	if err != nil {
+		// Errors are reported when running the step.
+		return "", nil
+	}
+	hash, err := fileHash(sourceLicensePath)
+	if err != nil {
+		return "", err
+	}
+	return sourceLicensePath + ":" + hash, nil
+}
+
+// buildState contains the hashes of the inputs used to build a package, so following builds
+// can skip the steps whose inputs haven't changed.
+type buildState struct {
+	ElasticPackageVersion string `json:"elastic_package_version"`
+
+	// Files contains the hash of each file of the source package, including development files.
+	Files map[string]string `json:"files"`
+
+	// Steps contains a hash of the inputs of each build step.
+	Steps map[string]string `json:"steps"`
+
+	Validated    bool `json:"validated"`
+	Zipped       bool `json:"zipped"`
+	ZipValidated bool `json:"zip_validated"`
+}
+
+func elasticPackageVersion() string {
+	return strings.Join([]string{version.Tag, version.CommitHash, version.BuildTime}, "-")
+}
+
+// newBuildState calculates the hashes of the inputs of the package.
+func newBuildState(packageRoot string, steps []buildStep) (*buildState, error) {
+	state := buildState{
+		ElasticPackageVersion: elasticPackageVersion(),
+		Files:                 make(map[string]string),
+		Steps:                 make(map[string]string),
+	}
+	err := filepath.WalkDir(packageRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(packageRoot, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Development directories can contain a build directory with inputs of the build.
+			if rel != "." && slices.Contains(buildStateSkippedDirs, d.Name()) && isPackagedFile(filepath.ToSlash(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		for _, glob := range buildStateSkippedFileGlobs {
+			if matched, _ := filepath.Match(glob, d.Name()); matched {
+				return nil
+			}
+		}
+		hash, err := fileHash(p)
+		if err != nil {
+			return err
+		}
+		state.Files[filepath.ToSlash(rel)] = hash
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("calculating hashes of package files failed: %w", err)
+	}
+
+	for _, step := range steps {
+		h := sha256.New()
+		for _, p := range slices.Sorted(maps.Keys(state.Files)) {
+			if step.targets(p) || (step.inputs != nil && step.inputs(p)) {
+				fmt.Fprintf(h, "%s:%s\n", p, state.Files[p])
+			}
+		}
+		if step.extraInput != nil {
+			extra, err := step.extraInput()
+			if err != nil {
+				return nil, fmt.Errorf("calculating inputs of step %q failed: %w", step.name, err)
+			}
+			fmt.Fprintln(h, extra)
+		}
+		state.Steps[step.name] = hex.EncodeToString(h.Sum(nil))
+	}
+	return &state, nil
+}
+
+func fileHash(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contentChanged checks if the content of the built package would be different for the given state.
+func (s *buildState) contentChanged(other *buildState) bool {
+	return !maps.Equal(s.packagedFiles(), other.packagedFiles()) || !maps.Equal(s.Steps, other.Steps)
+}
+
+func (s *buildState) packagedFiles() map[string]string {
+	packaged := maps.Clone(s.Files)
+	maps.DeleteFunc(packaged, func(p, _ string) bool {
+		return !isPackagedFile(p)
+	})
+	return packaged
+}
+
+func buildStatePath(destinationDir string) string {
+	// destinationDir is in the form of build/packages/<name>/<version>.
+	packagesDir := filepath.Dir(filepath.Dir(destinationDir))
+	name := filepath.Base(filepath.Dir(destinationDir)) + "-" + filepath.Base(destinationDir) + ".json"
+	return filepath.Join(filepath.Dir(packagesDir), buildStateFolder, name)
+}
+
+// readBuildState reads the state of a previous build. It returns nil if there is no previous
+// state, or it cannot be used.
+func readBuildState(statePath string) *buildState {
+	content, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		logger.Debugf("Can't read build state (path: %s): %v", statePath, err)
+		return nil
+	}
+
+	var state buildState
+	err = json.Unmarshal(content, &state)
+	if err != nil {
+		logger.Debugf("Can't parse build state (path: %s): %v", statePath, err)
+		return nil
+	}
+	if state.ElasticPackageVersion != elasticPackageVersion() {
+		logger.Debugf("Package was built with a different version of elastic-package")
+		return nil
+	}
+	return &state
+}
+
+func writeBuildState(statePath string, state *buildState) error {
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(statePath), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statePath, content, 0644)
+}
+
+func removeBuildState(statePath string) error {
+	err := os.Remove(statePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing build state failed (path: %s): %w", statePath, err)
+	}
+	return nil
+}
+
+// isPackagedFile checks if the source file is copied into the built package.
+func isPackagedFile(p string) bool {
+	return !slices.Contains(strings.Split(p, "/"), "_dev")
+}
+
+// syncChangedFiles updates the built package with the files changed since the previous build.
+func syncChangedFiles(packageRoot, destinationDir string, previous, current *buildState) error {
+	for p := range previous.Files {
+		if _, found := current.Files[p]; found || !isPackagedFile(p) {
+			continue
+		}
+		logger.Debugf("Remove deleted file from built package: %s", p)
+		err := removeBuiltFile(destinationDir, p)
+		if err != nil {
+			return err
+		}
+	}
+	for p, hash := range current.Files {
+		if previous.Files[p] == hash || !isPackagedFile(p) {
+			continue
+		}
+		logger.Debugf("Copy changed file into built package: %s", p)
+		err := copyBuiltFile(packageRoot, destinationDir, p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetStepTargets copies again the source files of the targets of the step, discarding
+// modifications done by previous builds.
+func resetStepTargets(packageRoot, destinationDir string, step buildStep, current *buildState) error {
+	for p := range current.Files {
+		if !isPackagedFile(p) || !step.targets(p) {
+			continue
+		}
+		err := copyBuiltFile(packageRoot, destinationDir, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Target files not present in the source package are created by the step.
+	return filepath.WalkDir(destinationDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(destinationDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if _, found := current.Files[rel]; found || !step.targets(rel) {
+			return nil
+		}
+		return removeBuiltFile(destinationDir, rel)
+	})
+}
+
+func copyBuiltFile(packageRoot, destinationDir, p string) error {
+	dest := filepath.Join(destinationDir, filepath.FromSlash(p))
+	err := os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return err
+	}
+	return sh.Copy(dest, filepath.Join(packageRoot, filepath.FromSlash(p)))
+}
+
+func removeBuiltFile(destinationDir, p string) error {
+	dest := filepath.Join(destinationDir, filepath.FromSlash(p))
+	err := os.Remove(dest)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// Remove directories left empty, as they are not copied in full builds.
+	for dir := filepath.Dir(dest); dir != destinationDir; dir = filepath.Dir(dir) {
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			break
+		}
+		err = os.Remove(dir)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
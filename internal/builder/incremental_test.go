@@ -0,0 +1,96 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestIncrementalBuildPackageContent(t *testing.T) {
+	packageRoot := t.TempDir()
+	destinationDir := filepath.Join(t.TempDir(), "build", "packages", "test", "1.0.0")
+	writeTestFile(t, filepath.Join(packageRoot, "manifest.yml"), "name: test\n")
+	writeTestFile(t, filepath.Join(packageRoot, "docs", "README.md"), "# Test\n")
+	writeTestFile(t, filepath.Join(packageRoot, "fields", "base.yml"), "- name: foo\n")
+	writeTestFile(t, filepath.Join(packageRoot, "_dev", "build", "build.yml"), "dependencies: {}\n")
+
+	runs := make(map[string]int)
+	steps := []buildStep{
+		{
+			name:    "append to fields",
+			targets: matchPaths("fields/*.yml"),
+			inputs:  matchPaths("_dev/build/build.yml"),
+			run: func(_, destinationDir string) error {
+				runs["append to fields"]++
+				path := filepath.Join(destinationDir, "fields", "base.yml")
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				return os.WriteFile(path, append(content, "- name: bar\n"...), 0644)
+			},
+		},
+		{
+			name:    "append to manifest",
+			targets: matchPaths("manifest.yml"),
+			run: func(_, destinationDir string) error {
+				runs["append to manifest"]++
+				path := filepath.Join(destinationDir, "manifest.yml")
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return err
+				}
+				return os.WriteFile(path, append(content, "built: true\n"...), 0644)
+			},
+		},
+	}
+
+	build := func(previous *buildState) *buildState {
+		state, err := newBuildState(packageRoot, steps)
+		require.NoError(t, err)
+		require.NoError(t, buildPackageContent(packageRoot, destinationDir, steps, previous, state))
+		return state
+	}
+
+	state := build(nil)
+	assert.Equal(t, map[string]int{"append to fields": 1, "append to manifest": 1}, runs)
+	assert.NoFileExists(t, filepath.Join(destinationDir, "_dev", "build", "build.yml"))
+
+	// Nothing changed, no step is executed.
+	next := build(state)
+	assert.False(t, state.contentChanged(next))
+	assert.Equal(t, map[string]int{"append to fields": 1, "append to manifest": 1}, runs)
+
+	// Changes in development files only affect the steps using them as inputs.
+	writeTestFile(t, filepath.Join(packageRoot, "_dev", "build", "build.yml"), "dependencies: {ecs: {}}\n")
+	require.NoError(t, os.Remove(filepath.Join(packageRoot, "docs", "README.md")))
+	state, next = next, build(next)
+	assert.True(t, state.contentChanged(next))
+	assert.Equal(t, map[string]int{"append to fields": 2, "append to manifest": 1}, runs)
+
+	fields, err := os.ReadFile(filepath.Join(destinationDir, "fields", "base.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "- name: foo\n- name: bar\n", string(fields))
+	manifest, err := os.ReadFile(filepath.Join(destinationDir, "manifest.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name: test\nbuilt: true\n", string(manifest))
+	assert.NoDirExists(t, filepath.Join(destinationDir, "docs"))
+}
+
+func TestBuildStatePath(t *testing.T) {
+	statePath := buildStatePath(filepath.Join("build", "packages", "test", "1.0.0"))
+	assert.Equal(t, filepath.Join("build", buildStateFolder, "test-1.0.0.json"), statePath)
+}
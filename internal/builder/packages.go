@@ -31,6 +31,9 @@ type BuildOptions struct {
 	CreateZip      bool
 	SignPackage    bool
 	SkipValidation bool
+
+	// Force can be set to true to build all the package, ignoring previous builds.
+	Force bool
 }
 
 // BuildDirectory function locates the target build directory. If the directory doesn't exist, it will create it.
@@ -142,7 +145,8 @@ func FindBuildPackagesDirectory() (string, bool, error) {
 	return "", false, nil
 }
 
-// BuildPackage function builds the package.
+// BuildPackage function builds the package. Steps whose inputs haven't changed since the
+// previous build of the package are skipped, unless the build is forced.
 func BuildPackage(ctx context.Context, options BuildOptions) (string, error) {
 	destinationDir, err := BuildPackagesDirectory(options.PackageRoot)
 	if err != nil {
@@ -150,49 +154,106 @@ func BuildPackage(ctx context.Context, options BuildOptions) (string, error) {
 	}
 	logger.Debugf("Build directory: %s\n", destinationDir)
 
-	logger.Debugf("Clear target directory (path: %s)", destinationDir)
-	err = files.ClearDir(destinationDir)
+	steps := buildSteps()
+	state, err := newBuildState(options.PackageRoot, steps)
 	if err != nil {
-		return "", fmt.Errorf("clearing package contents failed: %w", err)
+		return "", err
 	}
 
-	logger.Debugf("Copy package content (source: %s)", options.PackageRoot)
-	err = files.CopyWithoutDev(options.PackageRoot, destinationDir)
-	if err != nil {
-		return "", fmt.Errorf("copying package contents failed: %w", err)
+	statePath := buildStatePath(destinationDir)
+	var previous *buildState
+	if options.Force {
+		logger.Debug("Build forced, previous build is ignored")
+	} else if _, err := os.Stat(destinationDir); err == nil {
+		previous = readBuildState(statePath)
 	}
 
-	logger.Debug("Copy license file if needed")
-	err = copyLicenseTextFile(filepath.Join(destinationDir, licenseTextFileName))
-	if err != nil {
-		return "", fmt.Errorf("copying license text file: %w", err)
+	changed := previous == nil || previous.contentChanged(state)
+	if changed {
+		// Invalidate the previous build until this one finishes.
+		err = removeBuildState(statePath)
+		if err != nil {
+			return "", err
+		}
+		err = buildPackageContent(options.PackageRoot, destinationDir, steps, previous, state)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		logger.Debug("Package content hasn't changed since the previous build, skip all build steps")
+		state.Validated = previous.Validated
+		state.Zipped = previous.Zipped
+		state.ZipValidated = previous.ZipValidated
 	}
 
-	logger.Debug("Encode dashboards")
-	err = encodeDashboards(destinationDir)
+	var target string
+	if options.CreateZip {
+		target, err = buildZippedPackage(ctx, options, destinationDir, state)
+	} else {
+		target, err = validateBuiltPackage(options, destinationDir, state)
+	}
 	if err != nil {
-		return "", fmt.Errorf("encoding dashboards failed: %w", err)
+		return "", err
 	}
 
-	logger.Debug("Resolve external fields")
-	err = resolveExternalFields(options.PackageRoot, destinationDir)
+	err = writeBuildState(statePath, state)
 	if err != nil {
-		return "", fmt.Errorf("resolving external fields failed: %w", err)
+		return "", fmt.Errorf("writing build state failed (path: %s): %w", statePath, err)
 	}
+	return target, nil
+}
 
-	err = addDynamicMappings(options.PackageRoot, destinationDir)
-	if err != nil {
-		return "", fmt.Errorf("adding dynamic mappings: %w", err)
+func buildPackageContent(packageRoot, destinationDir string, steps []buildStep, previous, state *buildState) error {
+	if previous == nil {
+		logger.Debugf("Clear target directory (path: %s)", destinationDir)
+		err := files.ClearDir(destinationDir)
+		if err != nil {
+			return fmt.Errorf("clearing package contents failed: %w", err)
+		}
+
+		logger.Debugf("Copy package content (source: %s)", packageRoot)
+		err = files.CopyWithoutDev(packageRoot, destinationDir)
+		if err != nil {
+			return fmt.Errorf("copying package contents failed: %w", err)
+		}
+	} else {
+		logger.Debugf("Copy changed package content (source: %s)", packageRoot)
+		err := syncChangedFiles(packageRoot, destinationDir, previous, state)
+		if err != nil {
+			return fmt.Errorf("copying changed package contents failed: %w", err)
+		}
 	}
 
-	if options.CreateZip {
-		return buildZippedPackage(ctx, options, destinationDir)
+	for _, step := range steps {
+		if previous != nil {
+			if previous.Steps[step.name] == state.Steps[step.name] {
+				logger.Debugf("Skip step %q, its inputs haven't changed", step.name)
+				continue
+			}
+			err := resetStepTargets(packageRoot, destinationDir, step, state)
+			if err != nil {
+				return fmt.Errorf("copying files for step %q failed: %w", step.name, err)
+			}
+		}
+
+		logger.Debugf("Run step %q", step.name)
+		err := step.run(packageRoot, destinationDir)
+		if err != nil {
+			return fmt.Errorf("%s failed: %w", step.name, err)
+		}
 	}
+	return nil
+}
 
+func validateBuiltPackage(options BuildOptions, destinationDir string, state *buildState) (string, error) {
 	if options.SkipValidation {
 		logger.Debug("Skip validation of the built package")
 		return destinationDir, nil
 	}
+	if state.Validated {
+		logger.Debug("Built package has been already validated")
+		return destinationDir, nil
+	}
 
 	logger.Debugf("Validating built package (path: %s)", destinationDir)
 	errs, skipped := validation.ValidateAndFilterFromPath(destinationDir)
@@ -202,23 +263,39 @@ func BuildPackage(ctx context.Context, options BuildOptions) (string, error) {
 	if errs != nil {
 		return "", fmt.Errorf("invalid content found in built package: %w", errs)
 	}
+	state.Validated = true
 	return destinationDir, nil
 }
 
-func buildZippedPackage(ctx context.Context, options BuildOptions, destinationDir string) (string, error) {
-	logger.Debug("Build zipped package")
+func buildZippedPackage(ctx context.Context, options BuildOptions, destinationDir string, state *buildState) (string, error) {
 	zippedPackagePath, err := buildPackagesZipPath(options.PackageRoot)
 	if err != nil {
 		return "", fmt.Errorf("can't evaluate path for the zipped package: %w", err)
 	}
 
-	err = files.Zip(ctx, destinationDir, zippedPackagePath)
+	_, err = os.Stat(zippedPackagePath)
+	if state.Zipped && err == nil {
+		logger.Debugf("Reuse zipped package, its content hasn't changed (path: %s)", zippedPackagePath)
+	} else {
+		logger.Debug("Build zipped package")
+		state.Zipped = false
+		state.ZipValidated = false
+		err = files.Zip(ctx, destinationDir, zippedPackagePath)
+		if err != nil {
+			return "", fmt.Errorf("can't compress the built package (compressed file path: %s): %w", zippedPackagePath, err)
+		}
+		state.Zipped = true
+	}
+
+	err = writeZipManifest(options.PackageRoot, zippedPackagePath)
 	if err != nil {
-		return "", fmt.Errorf("can't compress the built package (compressed file path: %s): %w", zippedPackagePath, err)
+		return "", err
 	}
 
 	if options.SkipValidation {
 		logger.Debug("Skip validation of the built .zip package")
+	} else if state.ZipValidated {
+		logger.Debug("Built .zip package has been already validated")
 	} else {
 		logger.Debugf("Validating built .zip package (path: %s)", zippedPackagePath)
 		errs, skipped := validation.ValidateAndFilterFromZip(zippedPackagePath)
@@ -228,6 +305,7 @@ func buildZippedPackage(ctx context.Context, options BuildOptions, destinationDi
 		if errs != nil {
 			return "", fmt.Errorf("invalid content found in built zip package: %w", errs)
 		}
+		state.ZipValidated = true
 	}
 
 	if options.SignPackage {
@@ -0,0 +1,47 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package builder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages/pipelinesnippets"
+)
+
+func expandPipelineSnippets(packageRoot, destinationDir string) error {
+	pipelineFiles, err := filepath.Glob(filepath.Join(destinationDir, "data_stream", "*", "elasticsearch", "ingest_pipeline", "*"))
+	if err != nil {
+		return fmt.Errorf("listing ingest pipelines failed: %w", err)
+	}
+
+	for _, file := range pipelineFiles {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading ingest pipeline failed (path: %s): %w", file, err)
+		}
+		if !pipelinesnippets.HasIncludes(content) {
+			continue
+		}
+
+		rel, err := filepath.Rel(destinationDir, file)
+		if err != nil {
+			return err
+		}
+		expanded, err := pipelinesnippets.Expand(packageRoot, filepath.Join(packageRoot, rel), content)
+		if err != nil {
+			return fmt.Errorf("expanding pipeline snippets failed: %w", err)
+		}
+
+		logger.Debugf("%s: pipeline snippets have been expanded", rel)
+		err = os.WriteFile(file, expanded.Content, 0644)
+		if err != nil {
+			return fmt.Errorf("writing ingest pipeline failed (path: %s): %w", file, err)
+		}
+	}
+	return nil
+}
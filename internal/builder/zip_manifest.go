@@ -0,0 +1,321 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package builder
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
+	"github.com/elastic/elastic-package/internal/version"
+)
+
+const zipManifestSuffix = ".build-manifest.json"
+
+// ZipManifest describes how a zipped package was built, so the archive can be verified
+// against its source.
+type ZipManifest struct {
+	Package struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"package"`
+
+	ElasticPackage struct {
+		Version string `json:"version,omitempty"`
+		Commit  string `json:"commit"`
+	} `json:"elastic_package"`
+
+	Source struct {
+		// Commit is the git commit of the repository containing the package, if any.
+		Commit string `json:"commit,omitempty"`
+
+		// Dirty is true if the package had uncommitted changes when it was built.
+		Dirty bool `json:"dirty,omitempty"`
+	} `json:"source"`
+
+	// ECSReference is the reference of the ECS schema used to resolve external fields.
+	ECSReference string `json:"ecs_reference,omitempty"`
+
+	Zip struct {
+		Name   string `json:"name"`
+		SHA256 string `json:"sha256"`
+	} `json:"zip"`
+
+	Files []ZipManifestFile `json:"files"`
+}
+
+// ZipManifestFile contains the checksum of a file included in the zipped package.
+type ZipManifestFile struct {
+	Path   string `json:"path"`
+	Size   uint64 `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ZipManifestPath returns the path of the build manifest written along the zipped package.
+func ZipManifestPath(zippedPackagePath string) string {
+	return strings.TrimSuffix(zippedPackagePath, ".zip") + zipManifestSuffix
+}
+
+// ReadZipManifest reads the build manifest of a zipped package. It returns false if the
+// manifest doesn't exist.
+func ReadZipManifest(zippedPackagePath string) (*ZipManifest, bool, error) {
+	manifestPath := ZipManifestPath(zippedPackagePath)
+	content, err := os.ReadFile(manifestPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("reading build manifest failed (path: %s): %w", manifestPath, err)
+	}
+
+	var manifest ZipManifest
+	err = json.Unmarshal(content, &manifest)
+	if err != nil {
+		return nil, true, fmt.Errorf("unmarshalling build manifest failed (path: %s): %w", manifestPath, err)
+	}
+	return &manifest, true, nil
+}
+
+// writeZipManifest writes the build manifest for the zipped package built from the package root.
+func writeZipManifest(packageRoot, zippedPackagePath string) error {
+	manifest, err := newZipManifest(packageRoot, zippedPackagePath)
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling build manifest failed: %w", err)
+	}
+	manifestPath := ZipManifestPath(zippedPackagePath)
+	err = os.WriteFile(manifestPath, content, 0644)
+	if err != nil {
+		return fmt.Errorf("writing build manifest failed (path: %s): %w", manifestPath, err)
+	}
+	logger.Debugf("Build manifest written (path: %s)", manifestPath)
+	return nil
+}
+
+func newZipManifest(packageRoot, zippedPackagePath string) (*ZipManifest, error) {
+	var manifest ZipManifest
+
+	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed (path: %s): %w", packageRoot, err)
+	}
+	manifest.Package.Name = m.Name
+	manifest.Package.Version = m.Version
+
+	manifest.ElasticPackage.Version = version.Tag
+	manifest.ElasticPackage.Commit = version.CommitHash
+
+	manifest.Source.Commit, manifest.Source.Dirty = sourceRevision(packageRoot)
+
+	bm, ok, err := buildmanifest.ReadBuildManifest(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading build manifest failed: %w", err)
+	}
+	if ok {
+		manifest.ECSReference = bm.Dependencies.ECS.Reference
+	}
+
+	manifest.Zip.Name = filepath.Base(zippedPackagePath)
+	manifest.Zip.SHA256, err = fileHash(zippedPackagePath)
+	if err != nil {
+		return nil, fmt.Errorf("calculating checksum of zipped package failed: %w", err)
+	}
+
+	manifest.Files, err = zipFileChecksums(zippedPackagePath)
+	if err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// sourceRevision returns the git commit of the package source, and if it has uncommitted
+// changes. The commit is empty if the package is not in a git repository.
+func sourceRevision(packageRoot string) (string, bool) {
+	output, err := runGit(packageRoot, "rev-parse", "HEAD")
+	if err != nil {
+		logger.Debugf("Can't read git commit of the package: %v", err)
+		return "", false
+	}
+	commit := strings.TrimSpace(string(output))
+
+	output, err = runGit(packageRoot, "status", "--porcelain", "--", ".")
+	if err != nil {
+		logger.Debugf("Can't read git status of the package: %v", err)
+		return commit, false
+	}
+	return commit, len(bytes.TrimSpace(output)) > 0
+}
+
+func runGit(dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+func zipFileChecksums(zippedPackagePath string) ([]ZipManifestFile, error) {
+	zipReader, err := zip.OpenReader(zippedPackagePath)
+	if err != nil {
+		return nil, fmt.Errorf("can't open zipped package (path: %s): %w", zippedPackagePath, err)
+	}
+	defer zipReader.Close()
+
+	var result []ZipManifestFile
+	for _, f := range zipReader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		hash, err := zipEntryHash(f)
+		if err != nil {
+			return nil, fmt.Errorf("calculating checksum of %s failed: %w", f.Name, err)
+		}
+		result = append(result, ZipManifestFile{
+			Path:   f.Name,
+			Size:   f.UncompressedSize64,
+			SHA256: hash,
+		})
+	}
+	slices.SortFunc(result, func(a, b ZipManifestFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return result, nil
+}
+
+func zipEntryHash(f *zip.File) (string, error) {
+	r, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, r)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ZipVerification is the result of comparing a zipped package with the package rebuilt from source.
+type ZipVerification struct {
+	// Reproduced is true if the rebuilt package is byte-identical to the verified one.
+	Reproduced bool
+
+	// Differences contains the files whose content differ between both packages.
+	Differences []string
+
+	// Manifest is the build manifest found along the verified package, if any.
+	Manifest *ZipManifest
+}
+
+// VerifyZippedPackage rebuilds the package from source and compares the result with the
+// given zipped package.
+func VerifyZippedPackage(ctx context.Context, packageRoot, zippedPackagePath string) (*ZipVerification, error) {
+	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed (path: %s): %w", packageRoot, err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "elastic-package-verify-")
+	if err != nil {
+		return nil, fmt.Errorf("can't prepare a temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	destinationDir := filepath.Join(tempDir, m.Name, m.Version)
+	steps := buildSteps()
+	state, err := newBuildState(packageRoot, steps)
+	if err != nil {
+		return nil, err
+	}
+	err = buildPackageContent(packageRoot, destinationDir, steps, nil, state)
+	if err != nil {
+		return nil, fmt.Errorf("rebuilding package failed: %w", err)
+	}
+	rebuiltPackagePath := ZippedBuiltPackagePath(tempDir, *m)
+	err = files.Zip(ctx, destinationDir, rebuiltPackagePath)
+	if err != nil {
+		return nil, fmt.Errorf("can't compress the rebuilt package: %w", err)
+	}
+
+	var verification ZipVerification
+	verification.Manifest, _, err = ReadZipManifest(zippedPackagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	expectedHash, err := fileHash(zippedPackagePath)
+	if err != nil {
+		return nil, fmt.Errorf("calculating checksum of zipped package failed: %w", err)
+	}
+	rebuiltHash, err := fileHash(rebuiltPackagePath)
+	if err != nil {
+		return nil, fmt.Errorf("calculating checksum of rebuilt package failed: %w", err)
+	}
+	if expectedHash == rebuiltHash {
+		verification.Reproduced = true
+		return &verification, nil
+	}
+
+	expected, err := zipFileChecksums(zippedPackagePath)
+	if err != nil {
+		return nil, err
+	}
+	rebuilt, err := zipFileChecksums(rebuiltPackagePath)
+	if err != nil {
+		return nil, err
+	}
+	verification.Differences = compareZipFiles(expected, rebuilt)
+	return &verification, nil
+}
+
+func compareZipFiles(expected, rebuilt []ZipManifestFile) []string {
+	hashes := make(map[string]string, len(rebuilt))
+	for _, f := range rebuilt {
+		hashes[f.Path] = f.SHA256
+	}
+
+	var differences []string
+	for _, f := range expected {
+		hash, found := hashes[f.Path]
+		switch {
+		case !found:
+			differences = append(differences, f.Path+": not found in rebuilt package")
+		case hash != f.SHA256:
+			differences = append(differences, f.Path+": content differs")
+		}
+		delete(hashes, f.Path)
+	}
+	for _, f := range rebuilt {
+		if _, found := hashes[f.Path]; found {
+			differences = append(differences, f.Path+": only found in rebuilt package")
+		}
+	}
+	return differences
+}
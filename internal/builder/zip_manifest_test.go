@@ -0,0 +1,81 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/files"
+)
+
+func TestVerifyZippedPackage(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, "manifest.yml"), "format_version: 3.0.0\nname: test\nversion: 1.0.0\ntype: integration\n")
+	writeTestFile(t, filepath.Join(packageRoot, "docs", "README.md"), "# Test\n")
+	writeTestFile(t, filepath.Join(packageRoot, licenseTextFileName), "License\n")
+
+	verified, err := VerifyZippedPackage(context.Background(), packageRoot, buildTestZip(t, packageRoot))
+	require.NoError(t, err)
+	assert.True(t, verified.Reproduced)
+	assert.Empty(t, verified.Differences)
+
+	zippedPackagePath := buildTestZip(t, packageRoot)
+	writeTestFile(t, filepath.Join(packageRoot, "docs", "README.md"), "# Test package\n")
+	writeTestFile(t, filepath.Join(packageRoot, "docs", "other.md"), "# Other\n")
+
+	verified, err = VerifyZippedPackage(context.Background(), packageRoot, zippedPackagePath)
+	require.NoError(t, err)
+	assert.False(t, verified.Reproduced)
+	assert.Equal(t, []string{
+		"test-1.0.0/docs/README.md: content differs",
+		"test-1.0.0/docs/other.md: only found in rebuilt package",
+	}, verified.Differences)
+}
+
+func TestWriteZipManifest(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, "manifest.yml"), "format_version: 3.0.0\nname: test\nversion: 1.0.0\ntype: integration\n")
+	writeTestFile(t, filepath.Join(packageRoot, "_dev", "build", "build.yml"), "dependencies:\n  ecs:\n    reference: git@v8.11.0\n")
+	zippedPackagePath := buildTestZip(t, packageRoot)
+
+	require.NoError(t, writeZipManifest(packageRoot, zippedPackagePath))
+	manifest, found, err := ReadZipManifest(zippedPackagePath)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	assert.Equal(t, "test", manifest.Package.Name)
+	assert.Equal(t, "1.0.0", manifest.Package.Version)
+	assert.Equal(t, "git@v8.11.0", manifest.ECSReference)
+	assert.Equal(t, "test-1.0.0.zip", manifest.Zip.Name)
+	zipHash, err := fileHash(zippedPackagePath)
+	require.NoError(t, err)
+	assert.Equal(t, zipHash, manifest.Zip.SHA256)
+
+	var paths []string
+	for _, f := range manifest.Files {
+		paths = append(paths, f.Path)
+	}
+	assert.Contains(t, paths, "test-1.0.0/manifest.yml")
+	assert.NotContains(t, paths, "test-1.0.0/_dev/build/build.yml")
+}
+
+// buildTestZip builds the package into a temporary directory and returns the path to the zip file.
+func buildTestZip(t *testing.T, packageRoot string) string {
+	t.Helper()
+	buildDir := t.TempDir()
+	destinationDir := filepath.Join(buildDir, "test", "1.0.0")
+	require.NoError(t, os.MkdirAll(destinationDir, 0755))
+	require.NoError(t, files.CopyWithoutDev(packageRoot, destinationDir))
+
+	zippedPackagePath := filepath.Join(buildDir, "test-1.0.0.zip")
+	require.NoError(t, files.Zip(context.Background(), destinationDir, zippedPackagePath))
+	return zippedPackagePath
+}
@@ -63,5 +63,12 @@ func Build() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("can't remove zipped built package (path: %s): %w", zippedBuildPackagePath, err)
 	}
+
+	zipManifestPath := builder.ZipManifestPath(zippedBuildPackagePath)
+	logger.Debugf("Remove build manifest of zipped package (path: %s)", zipManifestPath)
+	err = os.RemoveAll(zipManifestPath)
+	if err != nil {
+		return "", fmt.Errorf("can't remove build manifest of zipped package (path: %s): %w", zipManifestPath, err)
+	}
 	return destinationDir, nil
 }
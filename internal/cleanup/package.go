@@ -0,0 +1,449 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cleanup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/elasticsearch/ingest"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// PackageOptions contains the options to clean up a package.
+type PackageOptions struct {
+	KibanaClient *kibana.Client
+	ESAPI        *elasticsearch.API
+	PackageName  string
+
+	// DryRun reports the resources that would be deleted, without deleting them.
+	// Assets removed by Fleet together with the package are not reported.
+	DryRun bool
+}
+
+// PackageResource is a resource deleted when cleaning up a package.
+type PackageResource struct {
+	Kind string
+	Name string
+}
+
+func (r PackageResource) String() string {
+	return fmt.Sprintf("%s %s", r.Kind, r.Name)
+}
+
+type packageCleaner struct {
+	PackageOptions
+
+	deleted []PackageResource
+
+	// removedWithPackage contains the assets that Fleet removes when uninstalling the package.
+	// They are only tracked in dry run mode, where the package is not actually removed.
+	removedWithPackage map[PackageResource]struct{}
+}
+
+// Package function uninstalls a package and deletes all the resources related to it: package policies,
+// agent policies that only contain this package, data streams and assets that were not removed
+// when uninstalling the package. It returns the list of deleted resources, even on error.
+func Package(ctx context.Context, options PackageOptions) ([]PackageResource, error) {
+	c := packageCleaner{PackageOptions: options}
+
+	steps := []func(context.Context) error{
+		c.deletePolicies,
+		c.deleteDataStreams,
+		c.removePackage,
+		c.deleteIndexTemplates,
+		c.deleteComponentTemplates,
+		c.deleteIngestPipelines,
+	}
+	for _, step := range steps {
+		err := step(ctx)
+		if err != nil {
+			return c.deleted, err
+		}
+	}
+	return c.deleted, nil
+}
+
+func (c *packageCleaner) delete(kind, name string, deleteFn func() error) error {
+	if _, found := c.removedWithPackage[PackageResource{Kind: kind, Name: name}]; found {
+		logger.Debugf("Not deleting %s %s, it is removed with the package", kind, name)
+		return nil
+	}
+	if !c.DryRun {
+		logger.Debugf("Delete %s %s", kind, name)
+		err := deleteFn()
+		if err != nil {
+			return err
+		}
+	}
+	c.deleted = append(c.deleted, PackageResource{Kind: kind, Name: name})
+	return nil
+}
+
+func (c *packageCleaner) deletePolicies(ctx context.Context) error {
+	packagePolicies, err := c.KibanaClient.ListPackagePolicies(ctx, c.PackageName)
+	if err != nil {
+		return fmt.Errorf("could not list package policies: %w", err)
+	}
+	rawAgentPolicies, err := c.KibanaClient.ListRawPolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("could not list agent policies: %w", err)
+	}
+	agentPolicies, err := selectAgentPolicies(rawAgentPolicies, c.PackageName)
+	if err != nil {
+		return err
+	}
+	agentPolicies, err = c.withoutEnrolledAgents(ctx, agentPolicies)
+	if err != nil {
+		return err
+	}
+
+	for _, packagePolicy := range packagePolicies {
+		err := c.delete("package policy", packagePolicy.Name, func() error {
+			return c.KibanaClient.DeletePackagePolicy(ctx, packagePolicy)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, agentPolicy := range agentPolicies {
+		err := c.delete("agent policy", agentPolicy.Name, func() error {
+			return c.KibanaClient.DeletePolicy(ctx, agentPolicy.ID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type agentPolicy struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	IsManaged       bool   `json:"is_managed"`
+	Agents          *int   `json:"agents"`
+	PackagePolicies []struct {
+		Package struct {
+			Name string `json:"name"`
+		} `json:"package"`
+	} `json:"package_policies"`
+}
+
+// selectAgentPolicies selects the agent policies that only contain package policies of the given
+// package. Managed policies and policies known to have enrolled agents are not selected.
+func selectAgentPolicies(rawPolicies []json.RawMessage, packageName string) ([]agentPolicy, error) {
+	var selected []agentPolicy
+	for _, raw := range rawPolicies {
+		var policy agentPolicy
+		err := json.Unmarshal(raw, &policy)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode agent policy: %w", err)
+		}
+
+		if policy.IsManaged || len(policy.PackagePolicies) == 0 {
+			continue
+		}
+		onlyThisPackage := true
+		for _, packagePolicy := range policy.PackagePolicies {
+			if packagePolicy.Package.Name != packageName {
+				onlyThisPackage = false
+				break
+			}
+		}
+		if !onlyThisPackage {
+			continue
+		}
+		if policy.Agents != nil && *policy.Agents > 0 {
+			logger.Debugf("Agent policy %q is not deleted because it has %d agents enrolled", policy.Name, *policy.Agents)
+			continue
+		}
+		selected = append(selected, policy)
+	}
+	return selected, nil
+}
+
+// withoutEnrolledAgents filters out the agent policies with enrolled agents. Fleet doesn't
+// include the number of agents in all versions, so agents are queried when it is unknown.
+func (c *packageCleaner) withoutEnrolledAgents(ctx context.Context, policies []agentPolicy) ([]agentPolicy, error) {
+	var selected []agentPolicy
+	for _, policy := range policies {
+		if policy.Agents == nil {
+			agents, err := c.KibanaClient.QueryAgents(ctx, fmt.Sprintf("policy_id: %s", policy.ID))
+			if err != nil {
+				return nil, fmt.Errorf("could not query agents of agent policy %q: %w", policy.Name, err)
+			}
+			if len(agents) > 0 {
+				logger.Debugf("Agent policy %q is not deleted because it has %d agents enrolled", policy.Name, len(agents))
+				continue
+			}
+		}
+		selected = append(selected, policy)
+	}
+	return selected, nil
+}
+
+func (c *packageCleaner) deleteDataStreams(ctx context.Context) error {
+	dataStreams, err := ingest.GetDataStreamsForPackage(ctx, c.ESAPI, c.PackageName)
+	if err != nil {
+		return fmt.Errorf("could not get data streams: %w", err)
+	}
+	for _, dataStream := range dataStreams {
+		err := c.delete("data stream", dataStream, func() error {
+			return esRequest(func() (*elasticsearch.Response, error) {
+				return c.ESAPI.Indices.DeleteDataStream([]string{dataStream},
+					c.ESAPI.Indices.DeleteDataStream.WithContext(ctx),
+				)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("could not delete data stream %s: %w", dataStream, err)
+		}
+	}
+	return nil
+}
+
+func (c *packageCleaner) removePackage(ctx context.Context) error {
+	installed, err := c.KibanaClient.GetPackage(ctx, c.PackageName)
+	var notFoundError *kibana.ErrPackageNotFound
+	if errors.As(err, &notFoundError) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not get package: %w", err)
+	}
+	if installed.Status == "not_installed" {
+		return nil
+	}
+	if c.DryRun {
+		c.removedWithPackage = fleetAssetsResources(installed.Assets())
+	}
+
+	return c.delete("package", fmt.Sprintf("%s-%s", installed.Name, installed.Version), func() error {
+		_, err := c.KibanaClient.RemovePackage(ctx, installed.Name, installed.Version)
+		if err != nil {
+			return fmt.Errorf("can't remove the package: %w", err)
+		}
+		return nil
+	})
+}
+
+// fleetAssetKinds maps the types of the Elasticsearch assets installed by Fleet to the kinds
+// of resources deleted when cleaning up packages.
+var fleetAssetKinds = map[packages.AssetType]string{
+	"index_template":     "index template",
+	"component_template": "component template",
+	"ingest_pipeline":    "ingest pipeline",
+}
+
+// fleetAssetsResources returns the resources for the given assets installed by Fleet.
+func fleetAssetsResources(assets []packages.Asset) map[PackageResource]struct{} {
+	resources := make(map[PackageResource]struct{})
+	for _, asset := range assets {
+		kind, found := fleetAssetKinds[asset.Type]
+		if !found {
+			continue
+		}
+		resources[PackageResource{Kind: kind, Name: asset.ID}] = struct{}{}
+	}
+	return resources
+}
+
+func (c *packageCleaner) deleteIndexTemplates(ctx context.Context) error {
+	templates, err := ingest.GetIndexTemplatesForPackage(ctx, c.ESAPI, c.PackageName)
+	if err != nil {
+		return fmt.Errorf("could not get index templates: %w", err)
+	}
+	for _, template := range templates {
+		err := c.delete("index template", template.Name(), func() error {
+			return esRequest(func() (*elasticsearch.Response, error) {
+				return c.ESAPI.Indices.DeleteIndexTemplate(template.Name(),
+					c.ESAPI.Indices.DeleteIndexTemplate.WithContext(ctx),
+				)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("could not delete index template %s: %w", template.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (c *packageCleaner) deleteComponentTemplates(ctx context.Context) error {
+	var names []string
+	err := esRequestJSON(func() (*elasticsearch.Response, error) {
+		return c.ESAPI.Cluster.GetComponentTemplate(
+			c.ESAPI.Cluster.GetComponentTemplate.WithContext(ctx),
+			c.ESAPI.Cluster.GetComponentTemplate.WithName(packageAssetsPattern(c.PackageName)),
+		)
+	}, func(body []byte) error {
+		var err error
+		names, err = componentTemplatesForPackage(body, c.PackageName)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("could not get component templates: %w", err)
+	}
+
+	for _, name := range names {
+		err := c.delete("component template", name, func() error {
+			return esRequest(func() (*elasticsearch.Response, error) {
+				return c.ESAPI.Cluster.DeleteComponentTemplate(name,
+					c.ESAPI.Cluster.DeleteComponentTemplate.WithContext(ctx),
+				)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("could not delete component template %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// componentTemplatesForPackage returns the names of the component templates in a get component
+// templates response that belong to the given package.
+func componentTemplatesForPackage(body []byte, packageName string) ([]string, error) {
+	var response struct {
+		ComponentTemplates []struct {
+			Name              string `json:"name"`
+			ComponentTemplate struct {
+				Meta struct {
+					Package struct {
+						Name string `json:"name"`
+					} `json:"package"`
+				} `json:"_meta"`
+			} `json:"component_template"`
+		} `json:"component_templates"`
+	}
+	err := json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode component templates: %w", err)
+	}
+
+	var names []string
+	for _, template := range response.ComponentTemplates {
+		if template.ComponentTemplate.Meta.Package.Name != packageName {
+			continue
+		}
+		names = append(names, template.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (c *packageCleaner) deleteIngestPipelines(ctx context.Context) error {
+	var names []string
+	err := esRequestJSON(func() (*elasticsearch.Response, error) {
+		return c.ESAPI.Ingest.GetPipeline(
+			c.ESAPI.Ingest.GetPipeline.WithContext(ctx),
+			c.ESAPI.Ingest.GetPipeline.WithPipelineID(packageAssetsPattern(c.PackageName)+","+testPipelinesPattern),
+		)
+	}, func(body []byte) error {
+		var err error
+		names, err = ingestPipelinesForPackage(body, c.PackageName)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("could not get ingest pipelines: %w", err)
+	}
+
+	for _, name := range names {
+		err := c.delete("ingest pipeline", name, func() error {
+			return esRequest(func() (*elasticsearch.Response, error) {
+				return c.ESAPI.Ingest.DeletePipeline(name,
+					c.ESAPI.Ingest.DeletePipeline.WithContext(ctx),
+				)
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("could not delete ingest pipeline %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+const (
+	// testPipelinesInfix is included in the name of ingest pipelines installed by elastic-package for testing.
+	testPipelinesInfix   = "-elastic-package-"
+	testPipelinesPattern = "*" + testPipelinesInfix + "*"
+)
+
+// ingestPipelinesForPackage returns the names of the ingest pipelines in a get pipelines response
+// that belong to the given package. These are the pipelines installed by Fleet for the package, and
+// the test pipelines that include the name of the package. Other pipelines, as the "@custom" ones
+// created by users, are not selected.
+func ingestPipelinesForPackage(body []byte, packageName string) ([]string, error) {
+	var pipelines map[string]struct {
+		Meta struct {
+			Package struct {
+				Name string `json:"name"`
+			} `json:"package"`
+			ManagedBy string `json:"managed_by"`
+		} `json:"_meta"`
+	}
+	err := json.Unmarshal(body, &pipelines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ingest pipelines: %w", err)
+	}
+
+	var names []string
+	for name, pipeline := range pipelines {
+		switch {
+		case pipeline.Meta.Package.Name == packageName && pipeline.Meta.ManagedBy == "fleet":
+		case strings.Contains(name, testPipelinesInfix) && strings.Contains(name, "-"+packageName+"."):
+		default:
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// packageAssetsPattern returns the pattern matching the names of the templates and pipelines
+// installed by Fleet for the data streams of a package, as "logs-package.dataset@package".
+func packageAssetsPattern(packageName string) string {
+	return fmt.Sprintf("*-%s.*", packageName)
+}
+
+// esRequest executes a request, ignoring not found errors.
+func esRequest(request func() (*elasticsearch.Response, error)) error {
+	return esRequestJSON(request, nil)
+}
+
+// esRequestJSON executes a request and passes the body of the response to the handler, ignoring
+// not found errors.
+func esRequestJSON(request func() (*elasticsearch.Response, error), handler func([]byte) error) error {
+	resp, err := request()
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.IsError() {
+		return errors.New(resp.String())
+	}
+	if handler == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	return handler(body)
+}
@@ -0,0 +1,99 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package cleanup
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+func TestSelectAgentPolicies(t *testing.T) {
+	rawPolicies := []json.RawMessage{
+		json.RawMessage(`{"id": "only-nginx", "name": "Only nginx", "agents": 0, "package_policies": [{"package": {"name": "nginx"}}, {"package": {"name": "nginx"}}]}`),
+		json.RawMessage(`{"id": "nginx-and-system", "name": "Nginx and system", "agents": 0, "package_policies": [{"package": {"name": "nginx"}}, {"package": {"name": "system"}}]}`),
+		json.RawMessage(`{"id": "nginx-with-agents", "name": "Nginx with agents", "agents": 1, "package_policies": [{"package": {"name": "nginx"}}]}`),
+		json.RawMessage(`{"id": "managed-nginx", "name": "Managed nginx", "is_managed": true, "package_policies": [{"package": {"name": "nginx"}}]}`),
+		json.RawMessage(`{"id": "empty", "name": "Empty", "package_policies": []}`),
+		json.RawMessage(`{"id": "only-apache", "name": "Only apache", "package_policies": [{"package": {"name": "apache"}}]}`),
+		json.RawMessage(`{"id": "unknown-agents", "name": "Unknown agents", "package_policies": [{"package": {"name": "nginx"}}]}`),
+	}
+
+	selected, err := selectAgentPolicies(rawPolicies, "nginx")
+	require.NoError(t, err)
+
+	var ids []string
+	for _, policy := range selected {
+		ids = append(ids, policy.ID)
+	}
+	// Policies with unknown number of agents are selected, agents are queried for them before deleting.
+	assert.Equal(t, []string{"only-nginx", "unknown-agents"}, ids)
+	assert.Nil(t, selected[1].Agents)
+}
+
+func TestComponentTemplatesForPackage(t *testing.T) {
+	body := `{
+  "component_templates": [
+    {"name": "logs-nginx.access@package", "component_template": {"_meta": {"package": {"name": "nginx"}, "managed_by": "fleet"}}},
+    {"name": "logs-nginx.access@custom", "component_template": {"_meta": {"package": {"name": "nginx"}, "managed_by": "fleet"}}},
+    {"name": "logs-nginx.access@settings", "component_template": {}},
+    {"name": "logs-other-nginx.access@package", "component_template": {"_meta": {"package": {"name": "other"}}}}
+  ]
+}`
+
+	names, err := componentTemplatesForPackage([]byte(body), "nginx")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logs-nginx.access@custom", "logs-nginx.access@package"}, names)
+}
+
+func TestIngestPipelinesForPackage(t *testing.T) {
+	body := `{
+  "logs-nginx.access-1.20.0": {"_meta": {"package": {"name": "nginx"}, "managed_by": "fleet", "managed": true}},
+  "logs-nginx.access-1.20.0-third-party": {"_meta": {"package": {"name": "nginx"}, "managed_by": "fleet", "managed": true}},
+  "logs-nginx.access@custom": {"processors": []},
+  "logs-nginx.access-1.20.0-elastic-package-1700000000": {},
+  "logs-apache.access-1.0.0-elastic-package-1700000000": {},
+  "logs-other.nginx-1.0.0": {"_meta": {"package": {"name": "other"}, "managed_by": "fleet"}}
+}`
+
+	names, err := ingestPipelinesForPackage([]byte(body), "nginx")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"logs-nginx.access-1.20.0",
+		"logs-nginx.access-1.20.0-elastic-package-1700000000",
+		"logs-nginx.access-1.20.0-third-party",
+	}, names)
+}
+
+func TestDeleteSkipsAssetsRemovedWithPackage(t *testing.T) {
+	c := packageCleaner{
+		PackageOptions: PackageOptions{DryRun: true},
+		removedWithPackage: fleetAssetsResources([]packages.Asset{
+			{ID: "logs-nginx.access", Type: "index_template"},
+			{ID: "logs-nginx.access@package", Type: "component_template"},
+			{ID: "logs-nginx.access-1.0.0", Type: "ingest_pipeline"},
+			{ID: "nginx-dashboard", Type: "dashboard"},
+		}),
+	}
+
+	deleteFn := func() error {
+		t.Fatal("resources are not deleted in dry run mode")
+		return nil
+	}
+	require.NoError(t, c.delete("index template", "logs-nginx.access", deleteFn))
+	require.NoError(t, c.delete("component template", "logs-nginx.access@package", deleteFn))
+	require.NoError(t, c.delete("ingest pipeline", "logs-nginx.access-1.0.0", deleteFn))
+	require.NoError(t, c.delete("ingest pipeline", "logs-nginx.access-0.9.0", deleteFn))
+	require.NoError(t, c.delete("component template", "logs-nginx.access@custom", deleteFn))
+
+	assert.Equal(t, []PackageResource{
+		{Kind: "ingest pipeline", Name: "logs-nginx.access-0.9.0"},
+		{Kind: "component template", Name: "logs-nginx.access@custom"},
+	}, c.deleted)
+}
@@ -88,6 +88,9 @@ const (
 	BenchStreamTimestampFieldFlagName        = "timestamp-field"
 	BenchStreamTimestampFieldFlagDescription = "name of the field that's used in the generator config as `@timestamp`"
 
+	BuildForceFlagName        = "force"
+	BuildForceFlagDescription = "build the whole package, without reusing results of previous builds"
+
 	BuildSkipValidationFlagName        = "skip-validation"
 	BuildSkipValidationFlagDescription = "skip validation of the built package, use only if all validation issues have been acknowledged"
 
@@ -112,44 +115,125 @@ const (
 	CheckConditionFlagName        = "check-condition"
 	CheckConditionFlagDescription = "check if the condition is met for the package, but don't install the package (e.g. kibana.version=7.10.0)"
 
+	CheckMappingsFlagName        = "mappings"
+	CheckMappingsFlagDescription = "analyze the size of the mappings of the data streams in the built package"
+
+	CheckVerifyZipFlagName        = "verify-zip"
+	CheckVerifyZipFlagDescription = "rebuild the package and verify that the result is identical to the given zipped package"
+
 	DaemonModeFlagName        = "daemon"
 	DaemonModeFlagDescription = "daemon mode"
 
 	DashboardIDsFlagName        = "id"
 	DashboardIDsFlagDescription = "Kibana dashboard IDs (comma-separated values)"
 
+	DashboardsAllFromPackageFlagName        = "all-from-package"
+	DashboardsAllFromPackageFlagDescription = "export all the dashboards installed with the package"
+
+	DashboardsForceFlagName        = "force"
+	DashboardsForceFlagDescription = "overwrite saved objects modified since the last export or import without asking for confirmation"
+
+	DashboardsTargetKibanaFlagName        = "target-kibana"
+	DashboardsTargetKibanaFlagDescription = "fail if exported objects are not compatible with this version of Kibana"
+
 	DataStreamFlagName        = "data-stream"
 	DataStreamFlagDescription = "use service stack related to the data stream"
 
+	DataStreamSettingsComponentTemplatesFlagName        = "component-template"
+	DataStreamSettingsComponentTemplatesFlagDescription = "names of the @package or @custom component templates to export (comma-separated values)"
+
+	DataStreamSettingsDryRunFlagName        = "dry-run"
+	DataStreamSettingsDryRunFlagDescription = "show the changes without writing them"
+
+	DataStreamSettingsILMPoliciesFlagName        = "ilm-policy"
+	DataStreamSettingsILMPoliciesFlagDescription = "names of the ILM policies to export (comma-separated values)"
+
 	DataStreamsFlagName        = "data-streams"
 	DataStreamsFlagDescription = "comma-separated data streams to test"
 
 	DeferCleanupFlagName        = "defer-cleanup"
 	DeferCleanupFlagDescription = "defer test cleanup for debugging purposes"
 
+	DumpDiffFlagName        = "diff"
+	DumpDiffFlagDescription = "compare installed objects with the package in the current directory instead of dumping them"
+
 	DumpOutputFlagName        = "output"
 	DumpOutputFlagDescription = "path to directory where exported assets will be stored"
 
+	ECSImportReferenceFlagName        = "reference"
+	ECSImportReferenceFlagDescription = "ECS reference (for example git@v8.11.0) the imported schema is used for, defaults to the one in the build manifest of the current package"
+
 	FailOnMissingFlagName        = "fail-on-missing"
 	FailOnMissingFlagDescription = "fail if tests are missing"
 
-	FailFastFlagName                  = "fail-fast"
-	FailFastFlagDescription           = "fail immediately if any file requires updates (do not overwrite)"
+	FailFastFlagName        = "fail-fast"
+	FailFastFlagDescription = "fail immediately if any file requires updates (do not overwrite)"
+
+	FieldsDiffBaseFlagName        = "base"
+	FieldsDiffBaseFlagDescription = "git reference or built package zip with the version to compare with"
+
+	FieldsGenerateDataStreamFlagDescription = "data stream whose fields are generated (required except for input packages)"
+
+	FieldsGenerateDocumentsFlagName        = "documents"
+	FieldsGenerateDocumentsFlagDescription = "comma-separated JSON files with the documents used to generate the fields"
+
+	FieldsGenerateIndexFlagName        = "index"
+	FieldsGenerateIndexFlagDescription = "index or data stream in the running stack with the documents used to generate the fields"
+
+	FieldsGenerateOutputFlagName        = "output"
+	FieldsGenerateOutputFlagDescription = "name of the file in the fields directory where new definitions are written"
+
+	FieldsGenerateSizeFlagName        = "size"
+	FieldsGenerateSizeFlagDescription = "maximum number of documents read from the index"
+
+	FormatCheckFlagName        = "check"
+	FormatCheckFlagDescription = "report the files that require updates and the rules that change them, without overwriting them"
+
+	FormatStrictFlagName        = "strict"
+	FormatStrictFlagDescription = "normalize ingest pipelines and canonicalize Kibana saved objects"
+
 	GenerateTestResultFlagName        = "generate"
 	GenerateTestResultFlagDescription = "generate test result file"
 
 	IngestPipelineIDsFlagName        = "id"
 	IngestPipelineIDsFlagDescription = "Elasticsearch ingest pipeline IDs (comma-separated values)"
 
+	InstallEnrollAgentFlagName        = "enroll-agent"
+	InstallEnrollAgentFlagDescription = "assign the created agent policy to the Elastic Agent of the stack (requires --policy)"
+
+	InstallPolicyFlagName        = "policy"
+	InstallPolicyFlagDescription = "path to a YAML file describing an agent policy and package policies to create after installing the package"
+
+	KibanaAssetIDsFlagName        = "id"
+	KibanaAssetIDsFlagDescription = "Kibana asset IDs (comma-separated values)"
+
+	LintDashboardsFlagName        = "dashboards"
+	LintDashboardsFlagDescription = "analyze dashboards and other saved objects of the package"
+
+	LintFieldsFlagName        = "fields"
+	LintFieldsFlagDescription = "lint field definitions of the package"
+
 	ProfileFlagName        = "profile"
 	ProfileFlagDescription = "select a profile to use for the stack configuration. Can also be set with %s"
 
+	ProfileImportAsFlagName        = "as"
+	ProfileImportAsFlagDescription = "name of the imported profile, defaults to the name of the exported profile"
+
+	ProfileImportOverwriteFlagName        = "overwrite"
+	ProfileImportOverwriteFlagDescription = "replace an existing profile with the same name, it is kept if the import fails"
+
 	ProfileFromFlagName        = "from"
 	ProfileFromFlagDescription = "copy profile from the specified existing profile"
 
+	ProfileExcludeCertsFlagName        = "exclude-certs"
+	ProfileExcludeCertsFlagDescription = "exclude the CA and certificates from the exported profile, they are regenerated on import"
+
 	ProfileFormatFlagName        = "format"
 	ProfileFormatFlagDescription = "format of the profiles list (table | json)"
 
+	ProfileRedactSecretsFlagName        = "redact-secrets"
+	ProfileRedactSecretsFlagDescription = "replace passwords, API keys and tokens in the exported profile"
+
 	ReportFormatFlagName        = "report-format"
 	ReportFormatFlagDescription = "format of test report"
 
@@ -175,6 +259,12 @@ const (
 	TLSSkipVerifyFlagName        = "tls-skip-verify"
 	TLSSkipVerifyFlagDescription = "skip TLS verify"
 
+	StackLockedFlagName        = "locked"
+	StackLockedFlagDescription = "use only the image digests recorded in the images lockfile of the profile"
+
+	StackRelockFlagName        = "relock"
+	StackRelockFlagDescription = "record again the digests of the updated images in the images lockfile of the profile"
+
 	StackProviderFlagName        = "provider"
 	StackProviderFlagDescription = "service provider to start a stack (%s)"
 
@@ -187,6 +277,18 @@ const (
 	StackDumpOutputFlagName        = "output"
 	StackDumpOutputFlagDescription = "output location for the stack dump"
 
+	StackSeedEventsFlagName        = "events"
+	StackSeedEventsFlagDescription = "number of documents to index in each data stream"
+
+	StackSeedNamespaceFlagName        = "namespace"
+	StackSeedNamespaceFlagDescription = "namespace of the data streams where documents are indexed"
+
+	StackSeedTimeWindowFlagName        = "time-window"
+	StackSeedTimeWindowFlagDescription = "period of time until now over which documents are distributed"
+
+	StackStatusWatchFlagName        = "watch"
+	StackStatusWatchFlagDescription = "refresh the status periodically until interrupted"
+
 	StackUserParameterFlagName      = "parameter"
 	StackUserParameterFlagShorthand = "U"
 	StackUserParameterDescription   = "optional parameter for the stack provider, as key=value"
@@ -206,6 +308,15 @@ const (
 	TestCoverageFormatFlagName        = "coverage-format"
 	TestCoverageFormatFlagDescription = "set format for coverage reports: %s"
 
+	TestDeepFlagName        = "deep"
+	TestDeepFlagDescription = "analyze dashboards and other saved objects, checking the fields and data views they use"
+
+	UninstallDryRunFlagName        = "dry-run"
+	UninstallDryRunFlagDescription = "show what would be deleted by --purge without deleting anything"
+
+	UninstallPurgeFlagName        = "purge"
+	UninstallPurgeFlagDescription = "delete also the package policies, data streams and leftover assets of the package"
+
 	VariantFlagName        = "variant"
 	VariantFlagDescription = "service variant"
 
@@ -0,0 +1,128 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const maxDiffValueLength = 80
+
+// DiffValues returns the differences between two versions of a JSON-like document, one per line,
+// with the path of the changed value. Removed values are prefixed with "-", added ones with "+",
+// and modified ones with "~".
+func DiffValues(from, to any) []string {
+	var differences []string
+	diffValues(&differences, "", from, to)
+	return differences
+}
+
+func diffValues(differences *[]string, path string, from, to any) {
+	switch fromValue := from.(type) {
+	case map[string]any:
+		toValue, ok := to.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(fromValue)+len(toValue))
+		for k := range fromValue {
+			keys = append(keys, k)
+		}
+		for k := range toValue {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range slices.Compact(keys) {
+			diffChild(differences, joinPath(path, k), fromValue, toValue, k)
+		}
+		return
+	case []any:
+		toValue, ok := to.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(fromValue), len(toValue)); i++ {
+			elementPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(toValue):
+				*differences = append(*differences, fmt.Sprintf("- %s: %s", elementPath, formatDiffValue(fromValue[i])))
+			case i >= len(fromValue):
+				*differences = append(*differences, fmt.Sprintf("+ %s: %s", elementPath, formatDiffValue(toValue[i])))
+			default:
+				diffValues(differences, elementPath, fromValue[i], toValue[i])
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		*differences = append(*differences, fmt.Sprintf("~ %s: %s => %s", path, formatDiffValue(from), formatDiffValue(to)))
+	}
+}
+
+func diffChild(differences *[]string, path string, from, to map[string]any, key string) {
+	fromValue, inFrom := from[key]
+	toValue, inTo := to[key]
+	switch {
+	case !inTo:
+		*differences = append(*differences, fmt.Sprintf("- %s: %s", path, formatDiffValue(fromValue)))
+	case !inFrom:
+		*differences = append(*differences, fmt.Sprintf("+ %s: %s", path, formatDiffValue(toValue)))
+	default:
+		diffValues(differences, path, fromValue, toValue)
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func formatDiffValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	s := string(b)
+	if len(s) > maxDiffValueLength {
+		s = s[:maxDiffValueLength-3] + "..."
+	}
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// FlattenMap adds the values of the nested map to the result, with their dotted paths as keys,
+// prefixed with the given prefix if not empty.
+func FlattenMap(prefix string, m map[string]any, result map[string]any) {
+	for key, value := range m {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]any); ok {
+			FlattenMap(key, nested, result)
+			continue
+		}
+		result[key] = value
+	}
+}
+
+// PutNested sets the value in the map, creating the nested maps needed for its dotted key.
+func PutNested(m map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		nested, ok := m[part].(map[string]any)
+		if !ok {
+			nested = make(map[string]any)
+			m[part] = nested
+		}
+		m = nested
+	}
+	m[parts[len(parts)-1]] = value
+}
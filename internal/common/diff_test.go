@@ -0,0 +1,64 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffValues(t *testing.T) {
+	from := map[string]any{
+		"attributes": map[string]any{
+			"title":       "Overview",
+			"description": "Old description",
+			"panelsJSON":  []any{map[string]any{"panelIndex": "1"}, map[string]any{"panelIndex": "2"}},
+		},
+		"references": []any{},
+	}
+	to := map[string]any{
+		"attributes": map[string]any{
+			"title":      "Overview",
+			"timeFrom":   "now-15m",
+			"panelsJSON": []any{map[string]any{"panelIndex": "3"}},
+		},
+		"references": []any{map[string]any{"id": "logs-*", "type": "index-pattern"}},
+	}
+
+	expected := []string{
+		`- attributes.description: "Old description"`,
+		`~ attributes.panelsJSON[0].panelIndex: "1" => "3"`,
+		`- attributes.panelsJSON[1]: {"panelIndex":"2"}`,
+		`+ attributes.timeFrom: "now-15m"`,
+		`+ references[0]: {"id":"logs-*","type":"index-pattern"}`,
+	}
+	assert.Equal(t, expected, DiffValues(from, to))
+	assert.Empty(t, DiffValues(from, from))
+}
+
+func TestFlattenMapAndPutNested(t *testing.T) {
+	nested := map[string]any{
+		"index": map[string]any{
+			"number_of_shards": 1,
+			"mapping": map[string]any{
+				"total_fields": map[string]any{"limit": 1000},
+			},
+		},
+	}
+
+	flattened := make(map[string]any)
+	FlattenMap("", nested, flattened)
+	assert.Equal(t, map[string]any{
+		"index.number_of_shards":           1,
+		"index.mapping.total_fields.limit": 1000,
+	}, flattened)
+
+	result := make(map[string]any)
+	for key, value := range flattened {
+		PutNested(result, key, value)
+	}
+	assert.Equal(t, nested, result)
+}
@@ -0,0 +1,552 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dashboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/elastic/package-spec/v3/code/go/pkg/specerrors"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/export"
+	"github.com/elastic/elastic-package/internal/fields"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// Codes of the issues found when analyzing Kibana saved objects. They can be used
+// in the exclude_checks list of the validation.yml file of the package to skip them.
+const (
+	CodeUndefinedField          = "DSH00001"
+	CodeNonPackageDataView      = "DSH00002"
+	CodeDeprecatedVisualization = "DSH00003"
+)
+
+// Types of legacy visualizations deprecated in Kibana.
+var deprecatedVisualizationTypes = []string{"input_control_vis", "region_map", "tile_map", "timelion"}
+
+// Issue is a problem found in a saved object of the package.
+type Issue struct {
+	// File is the path of the saved object, relative to the package root.
+	File    string
+	Code    string
+	Message string
+}
+
+func (i Issue) Error() string {
+	return fmt.Sprintf("file %q is invalid: %s", i.File, i.Message)
+}
+
+type dataView struct {
+	title         string
+	runtimeFields []string
+}
+
+type analyzer struct {
+	// fieldPaths contains the paths of the fields defined in the package, some of them can be wildcard patterns.
+	fieldPaths []string
+
+	// dataViews contains the data views defined in the package, by id.
+	dataViews map[string]dataView
+
+	// indexNames contains an example of index name for each data stream of the package.
+	indexNames []string
+
+	issues []Issue
+}
+
+// location identifies the saved object or panel being analyzed, for reporting.
+type location struct {
+	file string
+
+	// description of the element, such as `panel "Title"`.
+	description string
+
+	// runtimeFields defined in the data views used by the element.
+	runtimeFields []string
+}
+
+// AnalyzePackage analyzes the Kibana saved objects of the package, and reports references to
+// fields not defined in the package, to data views that don't match its data streams, and
+// usages of deprecated visualization types.
+func AnalyzePackage(packageRoot string) ([]Issue, error) {
+	files, err := filepath.Glob(filepath.Join(packageRoot, "kibana", "*", "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("listing saved objects failed: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	a := analyzer{dataViews: make(map[string]dataView)}
+	a.fieldPaths, err = fields.PackageFieldPaths(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading fields of the package failed: %w", err)
+	}
+	a.indexNames, err = dataStreamIndexNames(packageRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make(map[string]common.MapStr, len(files))
+	for _, file := range files {
+		object, err := readSavedObject(file)
+		if err != nil {
+			return nil, err
+		}
+		relPath, err := filepath.Rel(packageRoot, file)
+		if err != nil {
+			return nil, err
+		}
+		relPath = filepath.ToSlash(relPath)
+		objects[relPath] = object
+
+		if stringValue(object, "type") == "index-pattern" {
+			a.dataViews[stringValue(object, "id")] = dataView{
+				title:         stringValue(object, "attributes", "title"),
+				runtimeFields: dataViewRuntimeFields(object["attributes"]),
+			}
+		}
+	}
+
+	for _, relPath := range slices.Sorted(maps.Keys(objects)) {
+		a.analyzeObject(relPath, objects[relPath])
+	}
+	return a.issues, nil
+}
+
+// LintPackage analyzes the Kibana saved objects of the package, and returns the issues found
+// as validation errors.
+func LintPackage(packageRoot string) (specerrors.ValidationErrors, error) {
+	issues, err := AnalyzePackage(packageRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs specerrors.ValidationErrors
+	for _, issue := range issues {
+		errs = append(errs, specerrors.NewStructuredError(issue, issue.Code))
+	}
+	return errs, nil
+}
+
+func readSavedObject(path string) (common.MapStr, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading saved object failed: %w", err)
+	}
+	var object common.MapStr
+	err = json.Unmarshal(content, &object)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling saved object failed (path: %s): %w", path, err)
+	}
+	object, err = export.DecodeSavedObject(object)
+	if err != nil {
+		return nil, fmt.Errorf("decoding saved object failed (path: %s): %w", path, err)
+	}
+	return object, nil
+}
+
+func dataStreamIndexNames(packageRoot string) ([]string, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed: %w", err)
+	}
+	dataStreamManifests, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("listing data streams failed: %w", err)
+	}
+
+	var names []string
+	for _, manifestPath := range dataStreamManifests {
+		dsm, err := packages.ReadDataStreamManifest(manifestPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading data stream manifest failed: %w", err)
+		}
+		names = append(names, dsm.IndexTemplateName(manifest.Name)+"-default")
+	}
+	return names, nil
+}
+
+func (a *analyzer) report(loc location, code string, format string, args ...any) {
+	issue := Issue{
+		File:    loc.file,
+		Code:    code,
+		Message: loc.description + " " + fmt.Sprintf(format, args...),
+	}
+	if !slices.Contains(a.issues, issue) {
+		a.issues = append(a.issues, issue)
+	}
+}
+
+func (a *analyzer) analyzeObject(file string, object common.MapStr) {
+	attributes := object["attributes"]
+	title := stringValue(attributes, "title")
+	references := listValue(object, "references")
+	loc := location{file: file, runtimeFields: a.referencedRuntimeFields(references)}
+
+	switch stringValue(object, "type") {
+	case "dashboard":
+		loc.description = fmt.Sprintf("dashboard %q", title)
+		a.analyzeSearchSource(loc, mapValue(attributes, "kibanaSavedObjectMeta", "searchSourceJSON"))
+		a.analyzeControls(loc, mapValue(attributes, "controlGroupInput", "panelsJSON"))
+		a.analyzePanels(file, listValue(attributes, "panelsJSON"))
+	case "visualization":
+		loc.description = fmt.Sprintf("panel %q", title)
+		a.analyzeVisualization(loc, attributes)
+	case "lens":
+		loc.description = fmt.Sprintf("panel %q", title)
+		a.analyzeLens(loc, attributes)
+	case "search":
+		loc.description = fmt.Sprintf("saved search %q", title)
+		a.analyzeSearch(loc, attributes)
+	default:
+		return
+	}
+	a.analyzeReferences(loc, references)
+}
+
+// analyzePanels analyzes the panels defined by value in a dashboard.
+func (a *analyzer) analyzePanels(file string, panels []any) {
+	for _, panel := range panels {
+		embeddableConfig := mapValue(panel, "embeddableConfig")
+		attributes := mapValue(embeddableConfig, "attributes")
+		if attributes == nil {
+			// Panel defined by reference, it is analyzed in its own file.
+			continue
+		}
+
+		title := stringValue(embeddableConfig, "title")
+		if title == "" {
+			title = stringValue(attributes, "title")
+		}
+		if title == "" {
+			title = stringValue(panel, "panelIndex")
+		}
+		references := listValue(attributes, "references")
+		loc := location{
+			file:          file,
+			description:   fmt.Sprintf("panel %q", title),
+			runtimeFields: a.referencedRuntimeFields(references),
+		}
+
+		switch stringValue(panel, "type") {
+		case "visualization":
+			a.analyzeVisualization(loc, attributes)
+		case "lens":
+			a.analyzeLens(loc, attributes)
+		case "search":
+			a.analyzeSearch(loc, attributes)
+		}
+		a.analyzeReferences(loc, references)
+	}
+}
+
+func (a *analyzer) analyzeControls(loc location, controls any) {
+	m, ok := asMap(controls)
+	if !ok {
+		return
+	}
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		explicitInput := mapValue(m[key], "explicitInput")
+		controlLoc := loc
+		if title := stringValue(explicitInput, "title"); title != "" {
+			controlLoc.description = fmt.Sprintf("control %q", title)
+		}
+		a.checkField(controlLoc, stringValue(explicitInput, "fieldName"))
+		if id := stringValue(explicitInput, "dataViewId"); id != "" {
+			a.checkDataViewID(controlLoc, id)
+		}
+	}
+}
+
+func (a *analyzer) analyzeReferences(loc location, references []any) {
+	for _, reference := range references {
+		if stringValue(reference, "type") != "index-pattern" {
+			continue
+		}
+		a.checkDataViewID(loc, stringValue(reference, "id"))
+	}
+}
+
+// referencedRuntimeFields returns the runtime fields of the package data views in the references.
+func (a *analyzer) referencedRuntimeFields(references []any) []string {
+	var runtimeFields []string
+	for _, reference := range references {
+		if stringValue(reference, "type") != "index-pattern" {
+			continue
+		}
+		if dv, found := a.dataViews[stringValue(reference, "id")]; found {
+			runtimeFields = append(runtimeFields, dv.runtimeFields...)
+		}
+	}
+	return runtimeFields
+}
+
+func (a *analyzer) analyzeVisualization(loc location, attributes any) {
+	a.analyzeSearchSource(loc, mapValue(attributes, "kibanaSavedObjectMeta", "searchSourceJSON"))
+
+	visState := mapValue(attributes, "visState")
+	visType := stringValue(visState, "type")
+	if slices.Contains(deprecatedVisualizationTypes, visType) {
+		a.report(loc, CodeDeprecatedVisualization, "uses deprecated visualization type %q", visType)
+	}
+
+	params := mapValue(visState, "params")
+	switch visType {
+	case "metrics":
+		a.analyzeTSVB(loc, params)
+	case "vega":
+		a.analyzeVega(loc, stringValue(params, "spec"))
+	default:
+		for _, agg := range listValue(visState, "aggs") {
+			a.checkField(loc, stringValue(agg, "params", "field"))
+		}
+	}
+}
+
+// analyzeTSVB analyzes the parameters of Time Series Visual Builder visualizations.
+func (a *analyzer) analyzeTSVB(loc location, params any) {
+	a.checkTSVBIndexPattern(loc, mapValue(params, "index_pattern"))
+	a.checkField(loc, stringValue(params, "time_field"))
+	a.analyzeQuery(loc, mapValue(params, "filter"))
+
+	for _, series := range listValue(params, "series") {
+		metrics := listValue(series, "metrics")
+		for _, metric := range metrics {
+			field := stringValue(metric, "field")
+			if slices.ContainsFunc(metrics, func(m any) bool { return stringValue(m, "id") == field }) {
+				// Pipeline aggregations reference other metrics of the series.
+				continue
+			}
+			a.checkField(loc, field)
+		}
+		switch termsField := mapValue(series, "terms_field").(type) {
+		case string:
+			a.checkField(loc, termsField)
+		case []any:
+			for _, field := range termsField {
+				field, _ := field.(string)
+				a.checkField(loc, field)
+			}
+		}
+		a.analyzeQuery(loc, mapValue(series, "filter"))
+		for _, splitFilter := range listValue(series, "split_filters") {
+			a.analyzeQuery(loc, mapValue(splitFilter, "filter"))
+		}
+		if override, _ := mapValue(series, "override_index_pattern").(float64); override != 0 {
+			a.checkTSVBIndexPattern(loc, mapValue(series, "series_index_pattern"))
+			a.checkField(loc, stringValue(series, "series_time_field"))
+		}
+	}
+
+	for _, annotation := range listValue(params, "annotations") {
+		a.checkTSVBIndexPattern(loc, mapValue(annotation, "index_pattern"))
+		a.checkField(loc, stringValue(annotation, "time_field"))
+		a.analyzeQuery(loc, mapValue(annotation, "query_string"))
+		for _, field := range strings.Split(stringValue(annotation, "fields"), ",") {
+			a.checkField(loc, strings.TrimSpace(field))
+		}
+	}
+}
+
+func (a *analyzer) checkTSVBIndexPattern(loc location, indexPattern any) {
+	switch indexPattern := indexPattern.(type) {
+	case string:
+		a.checkIndexPattern(loc, indexPattern)
+	default:
+		if id := stringValue(indexPattern, "id"); id != "" {
+			a.checkDataViewID(loc, id)
+		}
+	}
+}
+
+func (a *analyzer) analyzeVega(loc location, spec string) {
+	for _, field := range vegaFields(spec) {
+		a.checkField(loc, field)
+	}
+	for _, index := range vegaIndexes(spec) {
+		a.checkIndexPattern(loc, index)
+	}
+}
+
+func (a *analyzer) analyzeLens(loc location, attributes any) {
+	state := mapValue(attributes, "state")
+
+	adHocDataViews, _ := asMap(mapValue(state, "adHocDataViews"))
+	for _, id := range slices.Sorted(maps.Keys(adHocDataViews)) {
+		adHocDataView := adHocDataViews[id]
+		loc.runtimeFields = append(loc.runtimeFields, dataViewRuntimeFields(adHocDataView)...)
+		a.checkIndexPattern(loc, stringValue(adHocDataView, "title"))
+	}
+
+	for _, datasource := range []string{"formBased", "indexpattern"} {
+		layers, _ := asMap(mapValue(state, "datasourceStates", datasource, "layers"))
+		for _, layerID := range slices.Sorted(maps.Keys(layers)) {
+			columns, _ := asMap(mapValue(layers[layerID], "columns"))
+			for _, columnID := range slices.Sorted(maps.Keys(columns)) {
+				field := stringValue(columns[columnID], "sourceField")
+				if field == "___records___" {
+					continue
+				}
+				a.checkField(loc, field)
+			}
+		}
+	}
+
+	a.analyzeQuery(loc, mapValue(state, "query"))
+	a.analyzeFilters(loc, listValue(state, "filters"))
+}
+
+func (a *analyzer) analyzeSearch(loc location, attributes any) {
+	for _, column := range listValue(attributes, "columns") {
+		column, _ := column.(string)
+		a.checkField(loc, column)
+	}
+	for _, sort := range listValue(attributes, "sort") {
+		if sort, ok := sort.([]any); ok && len(sort) > 0 {
+			field, _ := sort[0].(string)
+			a.checkField(loc, field)
+		}
+	}
+	a.analyzeSearchSource(loc, mapValue(attributes, "kibanaSavedObjectMeta", "searchSourceJSON"))
+}
+
+func (a *analyzer) analyzeSearchSource(loc location, searchSource any) {
+	a.analyzeQuery(loc, mapValue(searchSource, "query"))
+	a.analyzeFilters(loc, listValue(searchSource, "filter"))
+}
+
+func (a *analyzer) analyzeFilters(loc location, filters []any) {
+	for _, filter := range filters {
+		a.checkField(loc, stringValue(filter, "meta", "key"))
+		a.analyzeQuery(loc, mapValue(filter, "query"))
+	}
+}
+
+func (a *analyzer) analyzeQuery(loc location, query any) {
+	language := stringValue(query, "language")
+	if language != "kuery" && language != "lucene" {
+		return
+	}
+	for _, field := range kqlFields(stringValue(query, "query")) {
+		a.checkField(loc, field)
+	}
+}
+
+func (a *analyzer) checkField(loc location, field string) {
+	if field == "" || strings.Contains(field, "*") || strings.HasPrefix(field, "_") {
+		return
+	}
+	if slices.Contains(loc.runtimeFields, field) {
+		return
+	}
+	i, found := slices.BinarySearch(a.fieldPaths, field)
+	if found {
+		return
+	}
+	if i < len(a.fieldPaths) && strings.HasPrefix(a.fieldPaths[i], field+".") {
+		// Parent of defined fields, it can be used in exists queries.
+		return
+	}
+	for _, p := range a.fieldPaths {
+		if !strings.Contains(p, "*") {
+			continue
+		}
+		if matched, _ := path.Match(p, field); matched {
+			return
+		}
+	}
+	a.report(loc, CodeUndefinedField, "references undefined field %q", field)
+}
+
+func (a *analyzer) checkDataViewID(loc location, id string) {
+	if id == "" {
+		return
+	}
+	pattern := id
+	if dv, found := a.dataViews[id]; found {
+		pattern = dv.title
+	}
+	a.checkIndexPattern(loc, pattern)
+}
+
+// checkIndexPattern checks that the index pattern matches some data stream of the package.
+func (a *analyzer) checkIndexPattern(loc location, pattern string) {
+	if pattern == "" || len(a.indexNames) == 0 {
+		// Packages without data streams, such as input packages, can be used with any data view.
+		return
+	}
+	for _, part := range strings.Split(pattern, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || strings.HasPrefix(part, "-") || strings.Contains(part, ":") {
+			continue
+		}
+		for _, index := range a.indexNames {
+			if matched, _ := path.Match(part, index); matched {
+				return
+			}
+		}
+	}
+	a.report(loc, CodeNonPackageDataView, "uses data view %q that doesn't match any data stream of the package", pattern)
+}
+
+func dataViewRuntimeFields(dataView any) []string {
+	runtimeFieldMap, _ := asMap(mapValue(dataView, "runtimeFieldMap"))
+	if runtimeFieldMap == nil {
+		// Data views in saved objects have their runtime fields encoded.
+		if encoded := stringValue(dataView, "runtimeFieldMap"); encoded != "" {
+			_ = json.Unmarshal([]byte(encoded), &runtimeFieldMap)
+		}
+	}
+	return slices.Sorted(maps.Keys(runtimeFieldMap))
+}
+
+func asMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case common.MapStr:
+		return m, true
+	}
+	return nil, false
+}
+
+// mapValue returns the value found in the given path of nested maps, or nil if not found.
+func mapValue(v any, keys ...string) any {
+	for _, key := range keys {
+		m, ok := asMap(v)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func stringValue(v any, keys ...string) string {
+	s, _ := mapValue(v, keys...).(string)
+	return s
+}
+
+func listValue(v any, keys ...string) []any {
+	switch list := mapValue(v, keys...).(type) {
+	case []any:
+		return list
+	case []map[string]any:
+		result := make([]any, len(list))
+		for i, item := range list {
+			result[i] = item
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dashboards
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzePackage(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, "manifest.yml"), `
+format_version: 3.0.0
+name: example
+title: Example
+version: 1.0.0
+type: integration
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "data_stream", "access", "manifest.yml"), `
+title: Access logs
+type: logs
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "data_stream", "access", "fields", "fields.yml"), `
+- name: '@timestamp'
+  type: date
+- name: example.access
+  type: group
+  fields:
+    - name: status
+      type: long
+    - name: url
+      type: keyword
+      multi_fields:
+        - name: text
+          type: match_only_text
+    - name: labels
+      type: object
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "kibana", "index_pattern", "example-logs.json"), `{
+  "id": "example-logs",
+  "type": "index-pattern",
+  "attributes": {
+    "title": "logs-example.*",
+    "runtimeFieldMap": "{\"example.access.slow\":{\"type\":\"boolean\"}}"
+  }
+}`)
+	writeTestFile(t, filepath.Join(packageRoot, "kibana", "visualization", "example-status.json"), `{
+  "id": "example-status",
+  "type": "visualization",
+  "attributes": {
+    "title": "Status codes",
+    "visState": "{\"type\":\"pie\",\"aggs\":[{\"params\":{\"field\":\"example.access.status\"}},{\"params\":{\"field\":\"example.access.method\"}}]}",
+    "kibanaSavedObjectMeta": {
+      "searchSourceJSON": "{\"query\":{\"language\":\"kuery\",\"query\":\"example.access.url.text : \\\"login\\\" and not example.access.slow: true and data_stream.dataset: example.access\"},\"filter\":[{\"meta\":{\"key\":\"example.access.labels.env\"}}]}"
+    }
+  },
+  "references": [
+    {"id": "example-logs", "name": "kibanaSavedObjectMeta.searchSourceJSON.index", "type": "index-pattern"}
+  ]
+}`)
+	writeTestFile(t, filepath.Join(packageRoot, "kibana", "visualization", "example-map.json"), `{
+  "id": "example-map",
+  "type": "visualization",
+  "attributes": {
+    "title": "Map",
+    "visState": "{\"type\":\"tile_map\",\"aggs\":[]}"
+  },
+  "references": [
+    {"id": "metrics-*", "name": "kibanaSavedObjectMeta.searchSourceJSON.index", "type": "index-pattern"}
+  ]
+}`)
+	writeTestFile(t, filepath.Join(packageRoot, "kibana", "dashboard", "example-overview.json"), `{
+  "id": "example-overview",
+  "type": "dashboard",
+  "attributes": {
+    "title": "Overview",
+    "panelsJSON": "[{\"panelIndex\":\"1\",\"type\":\"lens\",\"embeddableConfig\":{\"attributes\":{\"title\":\"Requests\",\"state\":{\"datasourceStates\":{\"formBased\":{\"layers\":{\"layer1\":{\"columns\":{\"col1\":{\"sourceField\":\"___records___\"},\"col2\":{\"sourceField\":\"example.access.bytes\"}}}}}},\"query\":{\"language\":\"kuery\",\"query\":\"\"},\"filters\":[]},\"references\":[{\"id\":\"logs-*\",\"name\":\"indexpattern-datasource-layer-layer1\",\"type\":\"index-pattern\"}]}}},{\"panelIndex\":\"2\",\"type\":\"visualization\",\"embeddableConfig\":{}}]"
+  },
+  "references": []
+}`)
+
+	issues, err := AnalyzePackage(packageRoot)
+	require.NoError(t, err)
+
+	expected := []Issue{
+		{
+			File:    "kibana/dashboard/example-overview.json",
+			Code:    CodeUndefinedField,
+			Message: `panel "Requests" references undefined field "example.access.bytes"`,
+		},
+		{
+			File:    "kibana/visualization/example-map.json",
+			Code:    CodeDeprecatedVisualization,
+			Message: `panel "Map" uses deprecated visualization type "tile_map"`,
+		},
+		{
+			File:    "kibana/visualization/example-map.json",
+			Code:    CodeNonPackageDataView,
+			Message: `panel "Map" uses data view "metrics-*" that doesn't match any data stream of the package`,
+		},
+		{
+			File:    "kibana/visualization/example-status.json",
+			Code:    CodeUndefinedField,
+			Message: `panel "Status codes" references undefined field "data_stream.dataset"`,
+		},
+		{
+			File:    "kibana/visualization/example-status.json",
+			Code:    CodeUndefinedField,
+			Message: `panel "Status codes" references undefined field "example.access.method"`,
+		},
+	}
+	assert.Equal(t, expected, issues)
+}
+
+func TestKQLFields(t *testing.T) {
+	cases := []struct {
+		query    string
+		expected []string
+	}{
+		{query: "", expected: nil},
+		{query: "error", expected: nil},
+		{query: `host.name: "a:b" and (http.response.status_code >= 400 or not event.outcome : failure)`, expected: []string{"host.name", "http.response.status_code", "event.outcome"}},
+		{query: `user:{ first: "Alice" }`, expected: []string{"user"}},
+		{query: `_exists_:apache.status and @timestamp<now`, expected: []string{"_exists_", "@timestamp"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			assert.Equal(t, c.expected, kqlFields(c.query))
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
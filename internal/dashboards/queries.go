@@ -0,0 +1,64 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dashboards
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	queryQuotedString = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+	queryNestedQuery  = regexp.MustCompile(`\{[^{}]*\}`)
+	queryField        = regexp.MustCompile(`(?:^|[\s(])([\w@][\w@.\-]*)\s*(?::|<=|>=|<|>)`)
+
+	// Fields used in aggregations of Vega specs. Other fields in the specs refer to the
+	// results of the queries, so they are not checked.
+	vegaAggregationField = regexp.MustCompile(`["']?(?:terms|date_histogram|histogram|avg|sum|min|max|cardinality|value_count|percentiles|stats|extended_stats|significant_terms|top_metrics|geotile_grid|geohash_grid)["']?\s*:\s*\{\s*["']?field["']?\s*:\s*["']([^"']+)["']`)
+	vegaTimeField        = regexp.MustCompile(`["']?%timefield%["']?\s*:\s*["']([^"']+)["']`)
+	vegaIndex            = regexp.MustCompile(`["']?index["']?\s*:\s*["']([^"']+)["']`)
+)
+
+// kqlFields returns the fields used in a KQL or Lucene query.
+func kqlFields(query string) []string {
+	query = queryQuotedString.ReplaceAllString(query, `""`)
+	query = queryNestedQuery.ReplaceAllString(query, `""`)
+
+	var fields []string
+	for _, match := range queryField.FindAllStringSubmatch(query, -1) {
+		field := match[1]
+		if slices.Contains(fields, field) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// vegaFields returns the fields used in the queries of a Vega spec.
+func vegaFields(spec string) []string {
+	var fields []string
+	for _, re := range []*regexp.Regexp{vegaTimeField, vegaAggregationField} {
+		for _, match := range re.FindAllStringSubmatch(spec, -1) {
+			if !slices.Contains(fields, match[1]) {
+				fields = append(fields, match[1])
+			}
+		}
+	}
+	return fields
+}
+
+// vegaIndexes returns the index patterns queried in a Vega spec.
+func vegaIndexes(spec string) []string {
+	var indexes []string
+	for _, match := range vegaIndex.FindAllStringSubmatch(spec, -1) {
+		index := strings.TrimSpace(match[1])
+		if !slices.Contains(indexes, index) {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
@@ -10,9 +10,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dustin/go-humanize"
+
 	"github.com/elastic/elastic-package/internal/logger"
 )
 
@@ -190,3 +193,209 @@ func Copy(containerName, containerPath, localPath string) error {
 	}
 	return nil
 }
+
+// Restart function restarts the selected Docker containers.
+func Restart(containerIDs ...string) error {
+	args := append([]string{"restart"}, containerIDs...)
+	cmd := exec.Command("docker", args...)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("run command: %s", cmd)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not restart containers (stderr=%q): %w", errOutput.String(), err)
+	}
+	return nil
+}
+
+// ContainerStats contains resource usage statistics of a container.
+type ContainerStats struct {
+	ID               string
+	Name             string
+	CPUPercentage    float64
+	MemoryUsage      uint64
+	MemoryLimit      uint64
+	MemoryPercentage float64
+	BlockRead        uint64
+	BlockWrite       uint64
+}
+
+// rawContainerStats is the output of docker stats in JSON format.
+type rawContainerStats struct {
+	ID       string `json:"ID"`
+	Name     string `json:"Name"`
+	CPUPerc  string `json:"CPUPerc"`
+	MemUsage string `json:"MemUsage"`
+	MemPerc  string `json:"MemPerc"`
+	BlockIO  string `json:"BlockIO"`
+}
+
+// Stats function returns a sample of the resource usage statistics of the selected containers.
+func Stats(containerIDs ...string) ([]ContainerStats, error) {
+	args := append([]string{"stats", "--no-stream", "--no-trunc", "--format", "{{json .}}"}, containerIDs...)
+	cmd := exec.Command("docker", args...)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("output command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get containers stats (stderr=%q): %w", errOutput.String(), err)
+	}
+
+	var stats []ContainerStats
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line == "" {
+			continue
+		}
+		var raw rawContainerStats
+		err := json.Unmarshal([]byte(line), &raw)
+		if err != nil {
+			return nil, fmt.Errorf("can't unmarshal container stats: %w", err)
+		}
+		s, err := parseContainerStats(raw)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse stats of container %s: %w", raw.Name, err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+func parseContainerStats(raw rawContainerStats) (ContainerStats, error) {
+	stats := ContainerStats{
+		ID:   raw.ID,
+		Name: raw.Name,
+	}
+
+	var err error
+	stats.CPUPercentage, err = parsePercentage(raw.CPUPerc)
+	if err != nil {
+		return stats, fmt.Errorf("invalid CPU percentage: %w", err)
+	}
+	stats.MemoryPercentage, err = parsePercentage(raw.MemPerc)
+	if err != nil {
+		return stats, fmt.Errorf("invalid memory percentage: %w", err)
+	}
+	stats.MemoryUsage, stats.MemoryLimit, err = parseBytesPair(raw.MemUsage)
+	if err != nil {
+		return stats, fmt.Errorf("invalid memory usage: %w", err)
+	}
+	stats.BlockRead, stats.BlockWrite, err = parseBytesPair(raw.BlockIO)
+	if err != nil {
+		return stats, fmt.Errorf("invalid block I/O: %w", err)
+	}
+	return stats, nil
+}
+
+func parsePercentage(s string) (float64, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if s == "" || s == "--" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseBytesPair parses pairs of sizes as reported by docker stats, e.g. "1.2GiB / 7.6GiB".
+// Values not available are reported as "--".
+func parseBytesPair(s string) (uint64, uint64, error) {
+	if strings.TrimSpace(s) == "--" {
+		return 0, 0, nil
+	}
+	first, second, found := strings.Cut(s, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("unexpected format %q", s)
+	}
+	a, err := parseBytes(first)
+	if err != nil {
+		return 0, 0, err
+	}
+	b, err := parseBytes(second)
+	if err != nil {
+		return 0, 0, err
+	}
+	return a, b, nil
+}
+
+func parseBytes(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	if s == "--" {
+		return 0, nil
+	}
+	return humanize.ParseBytes(s)
+}
+
+// ContainerSizes function returns the size in bytes of the writable layer of the selected containers, by ID.
+func ContainerSizes(containerIDs ...string) (map[string]uint64, error) {
+	args := []string{"ps", "-a", "--size", "--no-trunc", "--format", "{{.ID}}\t{{.Size}}"}
+	for _, id := range containerIDs {
+		args = append(args, "--filter", "id="+id)
+	}
+	cmd := exec.Command("docker", args...)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("output command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not get containers sizes (stderr=%q): %w", errOutput.String(), err)
+	}
+
+	sizes := make(map[string]uint64)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		id, size, found := strings.Cut(line, "\t")
+		if !found {
+			continue
+		}
+		// Size is reported as "<size> (virtual <size>)".
+		size, _, _ = strings.Cut(size, "(")
+		sizeInBytes, err := humanize.ParseBytes(strings.TrimSpace(size))
+		if err != nil {
+			return nil, fmt.Errorf("can't parse size of container %s: %w", id, err)
+		}
+		sizes[id] = sizeInBytes
+	}
+	return sizes, nil
+}
+
+// ImageDigestRef returns the reference by digest (e.g. "name@sha256:...") of a local image,
+// as obtained from the registry it was pulled from.
+func ImageDigestRef(image string) (string, error) {
+	cmd := exec.Command("docker", "image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	errOutput := new(bytes.Buffer)
+	cmd.Stderr = errOutput
+
+	logger.Debugf("output command: %s", cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not inspect image %q (stderr=%q): %w", image, errOutput.String(), err)
+	}
+
+	var repoDigests []string
+	err = json.Unmarshal(output, &repoDigests)
+	if err != nil {
+		return "", fmt.Errorf("can't unmarshal repository digests of image %q: %w", image, err)
+	}
+	return selectRepoDigest(image, repoDigests)
+}
+
+func selectRepoDigest(image string, repoDigests []string) (string, error) {
+	name := imageName(image)
+	for _, repoDigest := range repoDigests {
+		repository, _, found := strings.Cut(repoDigest, "@")
+		if found && repository == name {
+			return repoDigest, nil
+		}
+	}
+	return "", fmt.Errorf("no digest found for image %q, it may have been built locally", image)
+}
+
+// imageName returns the name of an image reference, without tag or digest.
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	// Tags come after the last colon, if it is not part of the registry host with port.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
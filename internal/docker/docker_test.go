@@ -0,0 +1,79 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContainerStats(t *testing.T) {
+	raw := rawContainerStats{
+		ID:       "abcd",
+		Name:     "elastic-package-stack-elasticsearch-1",
+		CPUPerc:  "12.50%",
+		MemUsage: "1.5GiB / 7.5GiB",
+		MemPerc:  "20.00%",
+		BlockIO:  "10MB / 2.5kB",
+	}
+
+	stats, err := parseContainerStats(raw)
+	require.NoError(t, err)
+
+	expected := ContainerStats{
+		ID:               "abcd",
+		Name:             "elastic-package-stack-elasticsearch-1",
+		CPUPercentage:    12.5,
+		MemoryUsage:      1610612736,
+		MemoryLimit:      8053063680,
+		MemoryPercentage: 20,
+		BlockRead:        10000000,
+		BlockWrite:       2500,
+	}
+	assert.Equal(t, expected, stats)
+}
+
+func TestParseContainerStatsNotAvailable(t *testing.T) {
+	raw := rawContainerStats{
+		ID:       "abcd",
+		CPUPerc:  "--",
+		MemUsage: "-- / --",
+		MemPerc:  "--",
+		BlockIO:  "--",
+	}
+
+	stats, err := parseContainerStats(raw)
+	require.NoError(t, err)
+	assert.Equal(t, ContainerStats{ID: "abcd"}, stats)
+}
+
+func TestSelectRepoDigest(t *testing.T) {
+	repoDigests := []string{
+		"localhost:5000/elasticsearch/elasticsearch@sha256:1111",
+		"docker.elastic.co/elasticsearch/elasticsearch@sha256:2222",
+	}
+
+	cases := []struct {
+		image    string
+		expected string
+	}{
+		{"docker.elastic.co/elasticsearch/elasticsearch:8.15.0-SNAPSHOT", "docker.elastic.co/elasticsearch/elasticsearch@sha256:2222"},
+		{"docker.elastic.co/elasticsearch/elasticsearch@sha256:2222", "docker.elastic.co/elasticsearch/elasticsearch@sha256:2222"},
+		{"localhost:5000/elasticsearch/elasticsearch:8.15.0", "localhost:5000/elasticsearch/elasticsearch@sha256:1111"},
+		{"localhost:5000/elasticsearch/elasticsearch", "localhost:5000/elasticsearch/elasticsearch@sha256:1111"},
+	}
+	for _, c := range cases {
+		t.Run(c.image, func(t *testing.T) {
+			digest, err := selectRepoDigest(c.image, repoDigests)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, digest)
+		})
+	}
+
+	_, err := selectRepoDigest("docker.elastic.co/kibana/kibana:8.15.0", repoDigests)
+	assert.Error(t, err)
+}
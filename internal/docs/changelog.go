@@ -0,0 +1,39 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/packages/changelog"
+)
+
+const defaultChangelogRevisions = 3
+
+func renderChangelog(packageRoot string, revisions int) (string, error) {
+	entries, err := changelog.ReadChangelogFromPackageRoot(packageRoot)
+	if err != nil {
+		return "", fmt.Errorf("reading changelog failed: %w", err)
+	}
+	if revisions > 0 && len(entries) > revisions {
+		entries = entries[:revisions]
+	}
+
+	var builder strings.Builder
+	for _, revision := range entries {
+		builder.WriteString(fmt.Sprintf("**%s**\n\n", revision.Version))
+		for _, change := range revision.Changes {
+			description := strings.TrimSpace(strings.ReplaceAll(change.Description, "\n", " "))
+			line := fmt.Sprintf("- %s: %s", change.Type, description)
+			if change.Link != "" {
+				line += fmt.Sprintf(" ([link](%s))", change.Link)
+			}
+			builder.WriteString(line + "\n")
+		}
+		builder.WriteString("\n")
+	}
+	return strings.TrimSuffix(builder.String(), "\n\n"), nil
+}
@@ -0,0 +1,43 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+func renderCompatibility(packageRoot string) (string, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return "", fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	conditions := manifest.Conditions
+	subscription := conditions.Elastic.Subscription
+	if subscription == "" {
+		subscription = "basic"
+	}
+
+	var builder strings.Builder
+	builder.WriteString("| Requirement | Value |\n")
+	builder.WriteString("|---|---|\n")
+	if conditions.Kibana.Version != "" {
+		builder.WriteString(fmt.Sprintf("| Kibana version | %s |\n", escapeTableCell(conditions.Kibana.Version)))
+	}
+	builder.WriteString(fmt.Sprintf("| Elastic subscription | %s |\n", subscription))
+	if len(conditions.Elastic.Capabilities) > 0 {
+		builder.WriteString(fmt.Sprintf("| Capabilities | %s |\n", strings.Join(conditions.Elastic.Capabilities, ", ")))
+	}
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
+
+// escapeTableCell escapes characters with special meaning in markdown tables, as the "||"
+// used in version constraints.
+func escapeTableCell(value string) string {
+	return strings.ReplaceAll(value, "|", "\\|")
+}
@@ -0,0 +1,63 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+func renderDataStreams(packageRoot string) (string, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return "", fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	manifestPaths, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return "", fmt.Errorf("listing data streams failed: %w", err)
+	}
+	if len(manifestPaths) == 0 {
+		return "(no data streams available)", nil
+	}
+	sort.Strings(manifestPaths)
+
+	var builder strings.Builder
+	builder.WriteString("| Data stream | Title | Type | ILM policy | Index mode |\n")
+	builder.WriteString("|---|---|---|---|---|\n")
+	for _, manifestPath := range manifestPaths {
+		dsManifest, err := packages.ReadDataStreamManifest(manifestPath)
+		if err != nil {
+			return "", fmt.Errorf("reading data stream manifest failed: %w", err)
+		}
+
+		dataset := dsManifest.Dataset
+		if dataset == "" {
+			dataset = fmt.Sprintf("%s.%s", manifest.Name, filepath.Base(filepath.Dir(manifestPath)))
+		}
+
+		ilmPolicy := dsManifest.ILMPolicy
+		if ilmPolicy == "" {
+			ilmPolicy = "default"
+		}
+
+		indexMode := "standard"
+		if dsManifest.Elasticsearch != nil && dsManifest.Elasticsearch.IndexMode != "" {
+			indexMode = dsManifest.Elasticsearch.IndexMode
+		}
+
+		builder.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+			escaper.Replace(fmt.Sprintf("%s-%s-*", dsManifest.Type, dataset)),
+			escaper.Replace(dsManifest.Title),
+			dsManifest.Type,
+			ilmPolicy,
+			indexMode))
+	}
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
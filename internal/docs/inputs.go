@@ -0,0 +1,126 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+func renderInputs(packageRoot, policyTemplateName string) (string, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return "", fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	var builder strings.Builder
+	if policyTemplateName == "" && len(manifest.Vars) > 0 {
+		builder.WriteString("**Package variables**\n\n")
+		renderVariablesTable(&builder, manifest.Vars)
+		builder.WriteString("\n")
+	}
+
+	found := false
+	for _, policyTemplate := range manifest.PolicyTemplates {
+		if policyTemplateName != "" && policyTemplate.Name != policyTemplateName {
+			continue
+		}
+		found = true
+		renderPolicyTemplate(&builder, policyTemplate)
+	}
+	if policyTemplateName != "" && !found {
+		return "", fmt.Errorf("policy template %q not found", policyTemplateName)
+	}
+	if builder.Len() == 0 {
+		return "(no inputs available)", nil
+	}
+
+	return strings.TrimRight(builder.String(), "\n"), nil
+}
+
+func renderPolicyTemplate(builder *strings.Builder, policyTemplate packages.PolicyTemplate) {
+	builder.WriteString(fmt.Sprintf("**%s**\n\n", titleOrName(policyTemplate.Title, policyTemplate.Name)))
+	if description := strings.TrimSpace(policyTemplate.Description); description != "" {
+		builder.WriteString(description + "\n\n")
+	}
+
+	// Input packages define the input in the policy template.
+	if policyTemplate.Input != "" {
+		builder.WriteString(fmt.Sprintf("Input: `%s`\n\n", policyTemplate.Input))
+		renderVariablesTable(builder, policyTemplate.Vars)
+		builder.WriteString("\n")
+		return
+	}
+
+	for _, input := range policyTemplate.Inputs {
+		builder.WriteString(fmt.Sprintf("_%s_ (`%s`)\n\n", titleOrName(input.Title, input.Type), input.Type))
+		if description := strings.TrimSpace(input.Description); description != "" {
+			builder.WriteString(description + "\n\n")
+		}
+		renderVariablesTable(builder, input.Vars)
+		builder.WriteString("\n")
+	}
+}
+
+func renderVariablesTable(builder *strings.Builder, vars []packages.Variable) {
+	if len(vars) == 0 {
+		builder.WriteString("(no variables available)\n")
+		return
+	}
+
+	builder.WriteString("| Variable | Title | Type | Default | Required | Secret |\n")
+	builder.WriteString("|---|---|---|---|---|---|\n")
+	for _, v := range vars {
+		varType := v.Type
+		if v.Multi {
+			varType += " (multi)"
+		}
+		builder.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n",
+			escaper.Replace(v.Name),
+			escaper.Replace(v.Title),
+			varType,
+			formatDefaultValue(v),
+			yesNo(v.Required),
+			yesNo(v.Secret)))
+	}
+}
+
+func formatDefaultValue(v packages.Variable) string {
+	// Don't leak default values of secrets in documentation.
+	if v.Secret {
+		return ""
+	}
+	d, err := json.Marshal(v.Default)
+	if err != nil || string(d) == "null" {
+		return ""
+	}
+	value := string(d)
+	var s string
+	if err := json.Unmarshal(d, &s); err == nil {
+		value = s
+	}
+	value = strings.TrimSpace(strings.ReplaceAll(value, "\n", " "))
+	if value == "" {
+		return ""
+	}
+	return fmt.Sprintf("`%s`", strings.ReplaceAll(value, "|", "\\|"))
+}
+
+func titleOrName(title, name string) string {
+	if title != "" {
+		return title
+	}
+	return name
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,91 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+type kibanaAssetRecord struct {
+	title       string
+	description string
+	assetType   string
+}
+
+var documentedKibanaAssetTypes = map[packages.AssetType]string{
+	"dashboard": "Dashboard",
+	"search":    "Saved search",
+}
+
+func renderDashboards(packageRoot string) (string, error) {
+	assets, err := packages.LoadPackageAssets(packageRoot)
+	if err != nil {
+		return "", fmt.Errorf("loading package assets failed: %w", err)
+	}
+
+	var records []kibanaAssetRecord
+	for _, asset := range assets {
+		assetType, found := documentedKibanaAssetTypes[asset.Type]
+		if !found {
+			continue
+		}
+		title, description, err := readKibanaAssetTitle(asset.SourcePath)
+		if err != nil {
+			return "", fmt.Errorf("reading asset %s failed: %w", asset.ID, err)
+		}
+		records = append(records, kibanaAssetRecord{
+			title:       titleOrName(title, asset.ID),
+			description: description,
+			assetType:   assetType,
+		})
+	}
+
+	if len(records) == 0 {
+		return "(no dashboards available)", nil
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].assetType != records[j].assetType {
+			return records[i].assetType < records[j].assetType
+		}
+		return records[i].title < records[j].title
+	})
+
+	var builder strings.Builder
+	builder.WriteString("| Title | Type | Description |\n")
+	builder.WriteString("|---|---|---|\n")
+	for _, r := range records {
+		description := strings.TrimSpace(strings.ReplaceAll(r.description, "\n", " "))
+		builder.WriteString(fmt.Sprintf("| %s | %s | %s |\n",
+			escaper.Replace(r.title),
+			r.assetType,
+			escaper.Replace(escapeTableCell(description))))
+	}
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
+
+func readKibanaAssetTitle(path string) (string, string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	var asset struct {
+		Attributes struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+		} `json:"attributes"`
+	}
+	err = json.Unmarshal(content, &asset)
+	if err != nil {
+		return "", "", fmt.Errorf("can't unmarshal asset: %w", err)
+	}
+	return asset.Attributes.Title, asset.Attributes.Description, nil
+}
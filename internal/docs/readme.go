@@ -204,6 +204,27 @@ func renderReadme(fileName, packageRoot, templatePath string, linksMap linkMap)
 			}
 			return linksMap.RenderLink(args[0], options)
 		},
+		"inputs": func(args ...string) (string, error) {
+			if len(args) > 0 {
+				return renderInputs(packageRoot, args[0])
+			}
+			return renderInputs(packageRoot, "")
+		},
+		"compatibility": func() (string, error) {
+			return renderCompatibility(packageRoot)
+		},
+		"dashboards": func() (string, error) {
+			return renderDashboards(packageRoot)
+		},
+		"dataStreams": func() (string, error) {
+			return renderDataStreams(packageRoot)
+		},
+		"changelog": func(args ...int) (string, error) {
+			if len(args) > 0 {
+				return renderChangelog(packageRoot, args[0])
+			}
+			return renderChangelog(packageRoot, defaultChangelogRevisions)
+		},
 	}).ParseFiles(templatePath)
 	if err != nil {
 		return nil, fmt.Errorf("parsing README template failed (path: %s): %w", templatePath, err)
@@ -263,6 +263,145 @@ Introduction to the package
 	}
 }
 
+func TestRenderReadmeWithPackageInfo(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, packages.PackageManifestFile), `
+format_version: 3.0.0
+name: example
+title: Example
+version: 1.2.0
+type: integration
+conditions:
+  kibana:
+    version: "^8.13.0 || ^9.0.0"
+  elastic:
+    subscription: basic
+vars:
+  - name: api_key
+    type: password
+    title: API Key
+    required: true
+    secret: true
+    default: changeme
+policy_templates:
+  - name: example
+    title: Example logs
+    description: Collect example logs.
+    inputs:
+      - type: logfile
+        title: Collect logs from files
+        vars:
+          - name: paths
+            type: text
+            title: Paths
+            multi: true
+            required: true
+            default:
+              - /var/log/example.log
+          - name: preserve_original_event
+            type: bool
+            title: Preserve original event
+            default: false
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "data_stream", "log", packages.DataStreamManifestFile), `
+title: Example logs
+type: logs
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "data_stream", "metrics", packages.DataStreamManifestFile), `
+title: Example metrics
+type: metrics
+dataset: example.stats
+ilm_policy: metrics-example.stats-default_policy
+elasticsearch:
+  index_mode: time_series
+`)
+	writeTestFile(t, filepath.Join(packageRoot, "kibana", "dashboard", "example-overview.json"), `{"id":"example-overview","attributes":{"title":"[Logs Example] Overview","description":"Overview of example logs."}}`)
+	writeTestFile(t, filepath.Join(packageRoot, "kibana", "search", "example-errors.json"), `{"id":"example-errors","attributes":{"title":"Example errors"}}`)
+	writeTestFile(t, filepath.Join(packageRoot, "changelog.yml"), `
+- version: "1.2.0"
+  changes:
+    - description: Add metrics data stream.
+      type: enhancement
+      link: https://github.com/elastic/integrations/pull/2
+- version: "1.1.0"
+  changes:
+    - description: Fix parsing of dates.
+      type: bugfix
+      link: https://github.com/elastic/integrations/pull/1
+`)
+
+	cases := []struct {
+		title    string
+		template string
+		expected string
+	}{
+		{
+			title:    "inputs",
+			template: `{{ inputs }}`,
+			expected: "**Package variables**\n\n" +
+				"| Variable | Title | Type | Default | Required | Secret |\n" +
+				"|---|---|---|---|---|---|\n" +
+				"| api_key | API Key | password |  | yes | yes |\n\n" +
+				"**Example logs**\n\n" +
+				"Collect example logs.\n\n" +
+				"_Collect logs from files_ (`logfile`)\n\n" +
+				"| Variable | Title | Type | Default | Required | Secret |\n" +
+				"|---|---|---|---|---|---|\n" +
+				"| paths | Paths | text (multi) | `[\"/var/log/example.log\"]` | yes | no |\n" +
+				"| preserve_original_event | Preserve original event | bool | `false` | no | no |",
+		},
+		{
+			title:    "compatibility",
+			template: `{{ compatibility }}`,
+			expected: "| Requirement | Value |\n" +
+				"|---|---|\n" +
+				"| Kibana version | ^8.13.0 \\|\\| ^9.0.0 |\n" +
+				"| Elastic subscription | basic |",
+		},
+		{
+			title:    "dashboards",
+			template: `{{ dashboards }}`,
+			expected: "| Title | Type | Description |\n" +
+				"|---|---|---|\n" +
+				"| [Logs Example] Overview | Dashboard | Overview of example logs. |\n" +
+				"| Example errors | Saved search |  |",
+		},
+		{
+			title:    "data streams",
+			template: `{{ dataStreams }}`,
+			expected: "| Data stream | Title | Type | ILM policy | Index mode |\n" +
+				"|---|---|---|---|---|\n" +
+				"| logs-example.log-\\* | Example logs | logs | default | standard |\n" +
+				"| metrics-example.stats-\\* | Example metrics | metrics | metrics-example.stats-default_policy | time_series |",
+		},
+		{
+			title:    "changelog",
+			template: `{{ changelog 1 }}`,
+			expected: "**1.2.0**\n\n" +
+				"- enhancement: Add metrics data stream. ([link](https://github.com/elastic/integrations/pull/2))",
+		},
+	}
+
+	linksMap := newLinkMap()
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			err := createReadmeFile(packageRoot, c.template)
+			require.NoError(t, err)
+
+			templatePath := filepath.Join(packageRoot, "_dev", "build", "docs", "README.md")
+			rendered, err := renderReadme("README.md", packageRoot, templatePath, linksMap)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, string(rendered))
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, path, contents string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+}
+
 func createReadmeFile(packageRoot, contents string) error {
 	docsFolder, err := createDocsFolder(packageRoot)
 	if err != nil {
@@ -0,0 +1,318 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dump
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/elasticsearch/ingest"
+)
+
+const (
+	DataStreamsDumpDir = "data_streams"
+
+	dataStreamMappingsFile       = "mappings.json"
+	dataStreamSettingsFile       = "settings.json"
+	dataStreamILMExplainFile     = "ilm_explain.json"
+	dataStreamIgnoredFieldsFile  = "ignored_fields.json"
+	dataStreamFieldConflictsFile = "field_caps_conflicts.json"
+	dataStreamDocCountsFile      = "doc_counts.json"
+	dataStreamSampleDocsFile     = "sample_docs.json"
+
+	// Number of sample documents dumped for each data stream.
+	dataStreamSampleDocsSize = 5
+
+	// Maximum number of ignored fields reported for each data stream.
+	dataStreamIgnoredFieldsSize = 100
+)
+
+// dataStreamSearchBody is used to get, with a single query, the documents count by namespace, statistics
+// about ignored fields and the most recent documents of a data stream.
+var dataStreamSearchBody = fmt.Sprintf(`{
+  "size": %d,
+  "track_total_hits": true,
+  "sort": [{"@timestamp": {"order": "desc", "unmapped_type": "date"}}],
+  "runtime_mappings": {
+    "ignored_field": {
+      "type": "keyword",
+      "script": {
+        "source": "for (def v : params['_fields']._ignored.values) { emit(v); }"
+      }
+    }
+  },
+  "aggs": {
+    "namespaces": {
+      "terms": {"field": "data_stream.namespace", "size": 100, "missing": ""}
+    },
+    "ignored": {
+      "filter": {"exists": {"field": "_ignored"}},
+      "aggs": {
+        "fields": {
+          "terms": {"field": "ignored_field", "size": %d}
+        }
+      }
+    }
+  }
+}`, dataStreamSampleDocsSize, dataStreamIgnoredFieldsSize)
+
+// DataStreamsDumper dumps diagnostic information about the data ingested in the data streams of a package.
+type DataStreamsDumper struct {
+	packageName string
+	client      *elasticsearch.Client
+}
+
+// NewDataStreamsDumper creates a DataStreamsDumper for a given package.
+func NewDataStreamsDumper(client *elasticsearch.Client, packageName string) *DataStreamsDumper {
+	return &DataStreamsDumper{
+		packageName: packageName,
+		client:      client,
+	}
+}
+
+// DumpAll dumps the information of all the data streams of the package in the given directory, in a
+// subdirectory for each data stream. It returns the number of data streams dumped.
+func (d *DataStreamsDumper) DumpAll(ctx context.Context, dir string) (count int, err error) {
+	dataStreams, err := ingest.GetDataStreamsForPackage(ctx, d.client.API, d.packageName)
+	if err != nil {
+		return 0, err
+	}
+
+	dir = filepath.Join(dir, DataStreamsDumpDir)
+	for i, dataStream := range dataStreams {
+		err := d.dumpDataStream(ctx, filepath.Join(dir, dataStream), dataStream)
+		if err != nil {
+			return i, fmt.Errorf("failed to dump data stream %s: %w", dataStream, err)
+		}
+	}
+	return len(dataStreams), nil
+}
+
+func (d *DataStreamsDumper) dumpDataStream(ctx context.Context, dir, dataStream string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+
+	mappings, err := d.client.DataStreamMappings(ctx, dataStream)
+	if err != nil {
+		return err
+	}
+	err = dumpJSONFile(filepath.Join(dir, dataStreamMappingsFile), mappings)
+	if err != nil {
+		return fmt.Errorf("failed to dump mappings: %w", err)
+	}
+
+	settings, err := getRawResponse("settings", func() (*elasticsearch.Response, error) {
+		return d.client.Indices.GetSettings(
+			d.client.Indices.GetSettings.WithContext(ctx),
+			d.client.Indices.GetSettings.WithIndex(dataStream),
+		)
+	})
+	if err != nil {
+		return err
+	}
+	err = dumpRawJSONFile(filepath.Join(dir, dataStreamSettingsFile), settings)
+	if err != nil {
+		return fmt.Errorf("failed to dump settings: %w", err)
+	}
+
+	explain, err := getRawResponse("ILM explain", func() (*elasticsearch.Response, error) {
+		return d.client.ILM.ExplainLifecycle(dataStream,
+			d.client.ILM.ExplainLifecycle.WithContext(ctx),
+		)
+	})
+	if err != nil {
+		return err
+	}
+	err = dumpRawJSONFile(filepath.Join(dir, dataStreamILMExplainFile), explain)
+	if err != nil {
+		return fmt.Errorf("failed to dump ILM explain: %w", err)
+	}
+
+	fieldCaps, err := getRawResponse("field capabilities", func() (*elasticsearch.Response, error) {
+		return d.client.FieldCaps(
+			d.client.FieldCaps.WithContext(ctx),
+			d.client.FieldCaps.WithIndex(dataStream),
+			d.client.FieldCaps.WithFields("*"),
+		)
+	})
+	if err != nil {
+		return err
+	}
+	conflicts, err := fieldCapsConflicts(fieldCaps)
+	if err != nil {
+		return err
+	}
+	err = dumpJSONFile(filepath.Join(dir, dataStreamFieldConflictsFile), conflicts)
+	if err != nil {
+		return fmt.Errorf("failed to dump field conflicts: %w", err)
+	}
+
+	search, err := getRawResponse("documents", func() (*elasticsearch.Response, error) {
+		return d.client.Search(
+			d.client.Search.WithContext(ctx),
+			d.client.Search.WithIndex(dataStream),
+			d.client.Search.WithBody(strings.NewReader(dataStreamSearchBody)),
+		)
+	})
+	if err != nil {
+		return err
+	}
+	stats, err := parseDataStreamSearch(search)
+	if err != nil {
+		return err
+	}
+	err = dumpJSONFile(filepath.Join(dir, dataStreamDocCountsFile), stats.DocCounts)
+	if err != nil {
+		return fmt.Errorf("failed to dump document counts: %w", err)
+	}
+	err = dumpJSONFile(filepath.Join(dir, dataStreamIgnoredFieldsFile), stats.Ignored)
+	if err != nil {
+		return fmt.Errorf("failed to dump ignored fields: %w", err)
+	}
+	err = dumpJSONFile(filepath.Join(dir, dataStreamSampleDocsFile), stats.SampleDocs)
+	if err != nil {
+		return fmt.Errorf("failed to dump sample documents: %w", err)
+	}
+
+	return nil
+}
+
+func getRawResponse(what string, request func() (*elasticsearch.Response, error)) ([]byte, error) {
+	resp, err := request()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", what, err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return nil, fmt.Errorf("failed to get %s: %s", what, resp.String())
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", what, err)
+	}
+	return body, nil
+}
+
+// DocCounts contains the number of documents in a data stream.
+type DocCounts struct {
+	Total      int            `json:"total"`
+	Namespaces map[string]int `json:"namespaces"`
+}
+
+// IgnoredFields contains statistics about the fields ignored on ingestion.
+type IgnoredFields struct {
+	// DocCount is the number of documents with some ignored field.
+	DocCount int `json:"doc_count"`
+
+	// Fields is the number of documents where each field has been ignored.
+	Fields map[string]int `json:"fields"`
+}
+
+type dataStreamStats struct {
+	DocCounts  DocCounts
+	Ignored    IgnoredFields
+	SampleDocs []json.RawMessage
+}
+
+func parseDataStreamSearch(body []byte) (*dataStreamStats, error) {
+	type bucket struct {
+		Key      string `json:"key"`
+		DocCount int    `json:"doc_count"`
+	}
+	var response struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []json.RawMessage `json:"hits"`
+		} `json:"hits"`
+		Aggregations struct {
+			Namespaces struct {
+				Buckets []bucket `json:"buckets"`
+			} `json:"namespaces"`
+			Ignored struct {
+				DocCount int `json:"doc_count"`
+				Fields   struct {
+					Buckets []bucket `json:"buckets"`
+				} `json:"fields"`
+			} `json:"ignored"`
+		} `json:"aggregations"`
+	}
+	err := json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode search response: %w", err)
+	}
+
+	stats := dataStreamStats{
+		DocCounts: DocCounts{
+			Total:      response.Hits.Total.Value,
+			Namespaces: make(map[string]int),
+		},
+		Ignored: IgnoredFields{
+			DocCount: response.Aggregations.Ignored.DocCount,
+			Fields:   make(map[string]int),
+		},
+		SampleDocs: response.Hits.Hits,
+	}
+	for _, b := range response.Aggregations.Namespaces.Buckets {
+		stats.DocCounts.Namespaces[b.Key] = b.DocCount
+	}
+	for _, b := range response.Aggregations.Ignored.Fields.Buckets {
+		stats.Ignored.Fields[b.Key] = b.DocCount
+	}
+	if stats.SampleDocs == nil {
+		stats.SampleDocs = []json.RawMessage{}
+	}
+	return &stats, nil
+}
+
+// fieldCapsConflicts returns the fields that have different types in the backing indices of a data stream,
+// with the indices that have each type.
+func fieldCapsConflicts(body []byte) (map[string]map[string][]string, error) {
+	var response struct {
+		Fields map[string]map[string]struct {
+			Indices []string `json:"indices"`
+		} `json:"fields"`
+	}
+	err := json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode field capabilities: %w", err)
+	}
+
+	conflicts := make(map[string]map[string][]string)
+	for field, types := range response.Fields {
+		if len(types) < 2 {
+			continue
+		}
+		conflicts[field] = make(map[string][]string)
+		for fieldType, caps := range types {
+			conflicts[field][fieldType] = caps.Indices
+		}
+	}
+	return conflicts, nil
+}
+
+func dumpJSONFile(path string, object any) error {
+	d, err := json.MarshalIndent(object, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON object: %w", err)
+	}
+	return os.WriteFile(path, d, 0644)
+}
+
+func dumpRawJSONFile(path string, raw []byte) error {
+	formatted, err := formatJSON(raw)
+	if err != nil {
+		return fmt.Errorf("failed to format JSON object: %w", err)
+	}
+	return os.WriteFile(path, formatted, 0644)
+}
@@ -0,0 +1,88 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dump
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDataStreamSearch(t *testing.T) {
+	body := `{
+  "hits": {
+    "total": {"value": 42, "relation": "eq"},
+    "hits": [
+      {"_index": ".ds-logs-apache.access-default-2024.01.01-000001", "_id": "1", "_source": {"message": "GET /"}}
+    ]
+  },
+  "aggregations": {
+    "namespaces": {
+      "buckets": [
+        {"key": "default", "doc_count": 40},
+        {"key": "ep", "doc_count": 2}
+      ]
+    },
+    "ignored": {
+      "doc_count": 3,
+      "fields": {
+        "buckets": [
+          {"key": "event.original", "doc_count": 3}
+        ]
+      }
+    }
+  }
+}`
+
+	stats, err := parseDataStreamSearch([]byte(body))
+	require.NoError(t, err)
+	assert.Equal(t, DocCounts{
+		Total:      42,
+		Namespaces: map[string]int{"default": 40, "ep": 2},
+	}, stats.DocCounts)
+	assert.Equal(t, IgnoredFields{
+		DocCount: 3,
+		Fields:   map[string]int{"event.original": 3},
+	}, stats.Ignored)
+	require.Len(t, stats.SampleDocs, 1)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(stats.SampleDocs[0], &doc))
+	assert.Equal(t, map[string]any{"message": "GET /"}, doc["_source"])
+}
+
+func TestParseDataStreamSearchEmpty(t *testing.T) {
+	stats, err := parseDataStreamSearch([]byte(`{"hits": {"total": {"value": 0}, "hits": []}}`))
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.DocCounts.Total)
+	assert.Empty(t, stats.Ignored.Fields)
+	assert.NotNil(t, stats.SampleDocs)
+}
+
+func TestFieldCapsConflicts(t *testing.T) {
+	body := `{
+  "indices": [".ds-logs-apache.access-default-2024.01.01-000001", ".ds-logs-apache.access-default-2024.01.02-000002"],
+  "fields": {
+    "message": {
+      "match_only_text": {"type": "match_only_text", "searchable": true, "aggregatable": false}
+    },
+    "http.response.status_code": {
+      "long": {"type": "long", "searchable": true, "aggregatable": true, "indices": [".ds-logs-apache.access-default-2024.01.01-000001"]},
+      "keyword": {"type": "keyword", "searchable": true, "aggregatable": true, "indices": [".ds-logs-apache.access-default-2024.01.02-000002"]}
+    }
+  }
+}`
+
+	conflicts, err := fieldCapsConflicts([]byte(body))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string][]string{
+		"http.response.status_code": {
+			"long":    {".ds-logs-apache.access-default-2024.01.01-000001"},
+			"keyword": {".ds-logs-apache.access-default-2024.01.02-000002"},
+		},
+	}, conflicts)
+}
@@ -0,0 +1,570 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dump
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/elasticsearch/ingest"
+	"github.com/elastic/elastic-package/internal/export"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// ObjectStatus is the status of an installed object when compared with the package.
+type ObjectStatus string
+
+const (
+	// ObjectMissing is the status of objects defined in the package that are not installed.
+	ObjectMissing ObjectStatus = "missing"
+
+	// ObjectExtra is the status of objects installed for the package that are not defined in it.
+	ObjectExtra ObjectStatus = "extra"
+
+	// ObjectModified is the status of installed objects that differ from their definition in the package.
+	ObjectModified ObjectStatus = "modified"
+)
+
+const (
+	kindIndexTemplate     = "index template"
+	kindComponentTemplate = "component template"
+	kindILMPolicy         = "ILM policy"
+	kindIngestPipeline    = "ingest pipeline"
+	kindMLModel           = "ML model"
+)
+
+// ObjectDiff describes an installed object that doesn't match the package.
+type ObjectDiff struct {
+	Kind   string
+	Name   string
+	Status ObjectStatus
+
+	// Differences are the changes needed to update the installed object to its definition in
+	// the package, only for modified objects.
+	Differences []string
+}
+
+func (d ObjectDiff) String() string {
+	return fmt.Sprintf("%s %s %s", d.Status, d.Kind, d.Name)
+}
+
+// expectedObject is an object that should be installed for the package. Only the values present in
+// its content are compared, if there is no content, only its presence is checked.
+type expectedObject struct {
+	name    string
+	content map[string]any
+}
+
+// installedObject is an object installed in the stack, decoded from its JSON representation.
+type installedObject struct {
+	name    string
+	content map[string]any
+}
+
+// Diff method compares the objects installed in Elasticsearch for the package with the ones expected from
+// the package in the given root directory. Objects are compared as they would be installed by Fleet for the
+// given version of the package.
+func (e *InstalledObjectsDumper) Diff(ctx context.Context, packageRoot, packageVersion string) ([]ObjectDiff, error) {
+	expected, err := e.expectedObjects(packageRoot, packageVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []ObjectDiff
+
+	indexTemplates, err := e.getIndexTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	installed, err := decodeInstalledObjects(indexTemplates)
+	if err != nil {
+		return nil, err
+	}
+	diffs = append(diffs, compareObjects(kindIndexTemplate, expected[kindIndexTemplate], installed, "index_template._meta.package.name", e.packageName)...)
+
+	componentTemplates, err := e.getComponentTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	installed, err = decodeInstalledObjects(componentTemplates)
+	if err != nil {
+		return nil, err
+	}
+	installed, err = mergeLegacyComponentTemplates(installed)
+	if err != nil {
+		return nil, err
+	}
+	diffs = append(diffs, compareObjects(kindComponentTemplate, expected[kindComponentTemplate], installed, "component_template._meta.package.name", e.packageName)...)
+
+	ilmPolicies, err := e.getILMPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, policy := range missingObjects(expected[kindILMPolicy], ilmPolicies) {
+		found, err := getILMPolicyByName(ctx, e.client, policy)
+		if errors.Is(err, errILMPolicyNotFound) {
+			// Not installed, it is reported as missing.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ilmPolicies = append(ilmPolicies, found...)
+	}
+	installed, err = decodeInstalledObjects(ilmPolicies)
+	if err != nil {
+		return nil, err
+	}
+	diffs = append(diffs, compareObjects(kindILMPolicy, expected[kindILMPolicy], installed, "policy._meta.package.name", e.packageName)...)
+
+	ingestPipelines, err := e.getIngestPipelines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, pipeline := range missingObjects(expected[kindIngestPipeline], ingestPipelines) {
+		found, err := ingest.GetRemotePipelines(ctx, e.client, pipeline)
+		if err != nil {
+			return nil, err
+		}
+		ingestPipelines = append(ingestPipelines, found...)
+	}
+	installed, err = decodeInstalledObjects(ingestPipelines)
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range installed {
+		removeFleetPipelineProcessors(object.content)
+	}
+	diffs = append(diffs, compareObjects(kindIngestPipeline, expected[kindIngestPipeline], installed, "_meta.package.name", e.packageName)...)
+
+	mlModels, err := e.getMLModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	installed, err = decodeInstalledObjects(mlModels)
+	if err != nil {
+		return nil, err
+	}
+	// Models are found by the package prefix, so all of them belong to the package.
+	diffs = append(diffs, compareObjects(kindMLModel, expected[kindMLModel], installed, "", "")...)
+
+	return diffs, nil
+}
+
+// expectedObjects returns the Elasticsearch objects that Fleet would install for the package, by kind.
+func (e *InstalledObjectsDumper) expectedObjects(packageRoot, packageVersion string) (map[string][]expectedObject, error) {
+	expected := make(map[string][]expectedObject)
+
+	manifestPaths, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("listing data streams failed: %w", err)
+	}
+	for _, manifestPath := range manifestPaths {
+		dataStreamPath := filepath.Dir(manifestPath)
+		manifest, err := packages.ReadDataStreamManifest(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		templateName := manifest.IndexTemplateName(e.packageName)
+
+		expected[kindIndexTemplate] = append(expected[kindIndexTemplate], expectedObject{
+			name: templateName,
+			content: map[string]any{
+				"index_template": map[string]any{
+					"index_patterns": []any{templateName + "-*"},
+				},
+			},
+		})
+
+		template, err := expectedComponentTemplate(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		expected[kindComponentTemplate] = append(expected[kindComponentTemplate], expectedObject{
+			name:    templateName + "@package",
+			content: template,
+		})
+
+		policies, err := expectedILMPolicies(dataStreamPath, strings.TrimPrefix(templateName, "."))
+		if err != nil {
+			return nil, err
+		}
+		expected[kindILMPolicy] = append(expected[kindILMPolicy], policies...)
+
+		pipelines, err := ingest.LoadFleetDataStreamPipelines(dataStreamPath, e.packageName, packageVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, pipeline := range pipelines {
+			content, err := pipelineContent(pipeline)
+			if err != nil {
+				return nil, err
+			}
+			expected[kindIngestPipeline] = append(expected[kindIngestPipeline], expectedObject{
+				name:    pipeline.Name,
+				content: content,
+			})
+		}
+	}
+
+	modelPaths, err := filepath.Glob(filepath.Join(packageRoot, "elasticsearch", "ml_model", "*"))
+	if err != nil {
+		return nil, fmt.Errorf("listing ML models failed: %w", err)
+	}
+	for _, path := range modelPaths {
+		name := filepath.Base(path)
+		expected[kindMLModel] = append(expected[kindMLModel], expectedObject{
+			name: strings.TrimSuffix(name, filepath.Ext(name)),
+		})
+	}
+
+	return expected, nil
+}
+
+// expectedComponentTemplate returns the settings and mapping options defined in the data stream manifest, in
+// the format used in component templates.
+func expectedComponentTemplate(manifestPath string) (map[string]any, error) {
+	d, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading data stream manifest failed: %w", err)
+	}
+	var manifest struct {
+		Elasticsearch struct {
+			IndexMode     string `yaml:"index_mode"`
+			IndexTemplate struct {
+				Settings map[string]any `yaml:"settings"`
+				Mappings map[string]any `yaml:"mappings"`
+			} `yaml:"index_template"`
+		} `yaml:"elasticsearch"`
+	}
+	err = yaml.Unmarshal(d, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling data stream manifest failed (path: %s): %w", manifestPath, err)
+	}
+
+	// Elasticsearch returns settings as strings, under the "index" object.
+	flattened := make(map[string]any)
+	common.FlattenMap("", manifest.Elasticsearch.IndexTemplate.Settings, flattened)
+	if manifest.Elasticsearch.IndexMode != "" {
+		flattened["index.mode"] = manifest.Elasticsearch.IndexMode
+	}
+	settings := make(map[string]any)
+	for key, value := range flattened {
+		if !strings.HasPrefix(key, "index.") {
+			key = "index." + key
+		}
+		common.PutNested(settings, key, settingString(value))
+	}
+
+	mappings := make(map[string]any)
+	for key, value := range manifest.Elasticsearch.IndexTemplate.Mappings {
+		// Dynamic templates are merged with the ones generated from field definitions.
+		if key == "dynamic_templates" {
+			continue
+		}
+		mappings[key] = value
+	}
+
+	template := make(map[string]any)
+	if len(settings) > 0 {
+		template["settings"] = settings
+	}
+	if len(mappings) > 0 {
+		template["mappings"] = mappings
+	}
+	content, err := jsonRoundTrip(map[string]any{
+		"component_template": map[string]any{
+			"template": template,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid index template in data stream manifest (path: %s): %w", manifestPath, err)
+	}
+	return content, nil
+}
+
+func expectedILMPolicies(dataStreamPath, prefix string) ([]expectedObject, error) {
+	paths, err := filepath.Glob(filepath.Join(dataStreamPath, "elasticsearch", "ilm", "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("listing ILM policies failed: %w", err)
+	}
+	var policies []expectedObject
+	for _, path := range paths {
+		d, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading ILM policy failed: %w", err)
+		}
+		var content map[string]any
+		err = json.Unmarshal(d, &content)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling ILM policy failed (path: %s): %w", path, err)
+		}
+		name := filepath.Base(path)
+		policies = append(policies, expectedObject{
+			name:    prefix + "-" + strings.TrimSuffix(name, filepath.Ext(name)),
+			content: content,
+		})
+	}
+	return policies, nil
+}
+
+func pipelineContent(pipeline ingest.Pipeline) (map[string]any, error) {
+	var content map[string]any
+	err := yaml.Unmarshal(pipeline.Content, &content)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling ingest pipeline failed (path: %s): %w", pipeline.Path, err)
+	}
+
+	// Only processors are compared, other properties can be modified by Fleet.
+	compared := make(map[string]any)
+	for _, key := range []string{"processors", "on_failure"} {
+		if value, found := content[key]; found {
+			compared[key] = value
+		}
+	}
+	return jsonRoundTrip(compared)
+}
+
+// removeFleetPipelineProcessors removes the processors that Fleet adds to ingest pipelines to call
+// the custom pipelines.
+func removeFleetPipelineProcessors(pipeline map[string]any) {
+	processors, ok := pipeline["processors"].([]any)
+	if !ok {
+		return
+	}
+	pipeline["processors"] = slices.DeleteFunc(processors, func(processor any) bool {
+		options, err := common.MapStr(toMap(processor)).GetValue("pipeline")
+		if err != nil {
+			return false
+		}
+		name, _ := toMap(options)["name"].(string)
+		ignoreMissing, _ := toMap(options)["ignore_missing_pipeline"].(bool)
+		return ignoreMissing && strings.HasSuffix(name, "@custom")
+	})
+}
+
+// mergeLegacyComponentTemplates merges the @settings and @mappings component templates, installed by older
+// versions of Fleet, into a single @package component template.
+func mergeLegacyComponentTemplates(installed []installedObject) ([]installedObject, error) {
+	var result []installedObject
+	legacy := make(map[string]common.MapStr)
+	var legacyNames []string
+	for _, object := range installed {
+		base, found := strings.CutSuffix(object.name, "@settings")
+		if !found {
+			base, found = strings.CutSuffix(object.name, "@mappings")
+		}
+		if !found {
+			result = append(result, object)
+			continue
+		}
+		merged, exists := legacy[base]
+		if !exists {
+			merged = common.MapStr{}
+			legacy[base] = merged
+			legacyNames = append(legacyNames, base)
+		}
+		merged.DeepUpdate(object.content)
+	}
+	for _, base := range legacyNames {
+		// Round trip to get plain maps, as DeepUpdate converts nested objects to MapStr.
+		content, err := jsonRoundTrip(legacy[base])
+		if err != nil {
+			return nil, fmt.Errorf("merging component templates for %s failed: %w", base, err)
+		}
+		result = append(result, installedObject{name: base + "@package", content: content})
+	}
+	return result, nil
+}
+
+// compareObjects compares the installed objects of a kind with the expected ones. Installed objects that are
+// not expected are only reported if the value in the owner key matches the package.
+func compareObjects(kind string, expected []expectedObject, installed []installedObject, ownerKey, packageName string) []ObjectDiff {
+	var diffs []ObjectDiff
+	for _, object := range expected {
+		idx := slices.IndexFunc(installed, func(o installedObject) bool { return o.name == object.name })
+		if idx < 0 {
+			diffs = append(diffs, ObjectDiff{Kind: kind, Name: object.name, Status: ObjectMissing})
+			continue
+		}
+		if object.content == nil {
+			continue
+		}
+		found := selectValues(installed[idx].content, object.content)
+		differences := common.DiffValues(found, object.content)
+		if len(differences) > 0 {
+			diffs = append(diffs, ObjectDiff{Kind: kind, Name: object.name, Status: ObjectModified, Differences: differences})
+		}
+	}
+
+	for _, object := range installed {
+		if slices.ContainsFunc(expected, func(o expectedObject) bool { return o.name == object.name }) {
+			continue
+		}
+		if ownerKey != "" {
+			owner, _ := common.MapStr(object.content).GetValue(ownerKey)
+			if owner != packageName {
+				continue
+			}
+		}
+		diffs = append(diffs, ObjectDiff{Kind: kind, Name: object.name, Status: ObjectExtra})
+	}
+	return diffs
+}
+
+// selectValues returns the values of the found map whose keys are present in the expected one, recursively.
+func selectValues(found, expected map[string]any) map[string]any {
+	result := make(map[string]any)
+	for key, expectedValue := range expected {
+		foundValue, ok := found[key]
+		if !ok {
+			continue
+		}
+		expectedMap, isMap := expectedValue.(map[string]any)
+		foundMap, isFoundMap := foundValue.(map[string]any)
+		if isMap && isFoundMap {
+			result[key] = selectValues(foundMap, expectedMap)
+			continue
+		}
+		result[key] = foundValue
+	}
+	return result
+}
+
+type namedJSONObject interface {
+	Name() string
+	JSON() []byte
+}
+
+func decodeInstalledObjects[T namedJSONObject](objects []T) ([]installedObject, error) {
+	result := make([]installedObject, 0, len(objects))
+	for _, object := range objects {
+		var content map[string]any
+		err := json.Unmarshal(object.JSON(), &content)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s failed: %w", object.Name(), err)
+		}
+		result = append(result, installedObject{name: object.Name(), content: content})
+	}
+	return result, nil
+}
+
+func missingObjects[T namedJSONObject](expected []expectedObject, installed []T) []string {
+	var missing []string
+	for _, object := range expected {
+		if !slices.ContainsFunc(installed, func(o T) bool { return o.Name() == object.name }) {
+			missing = append(missing, object.name)
+		}
+	}
+	return missing
+}
+
+// DiffSavedObjects compares the saved objects defined in the package with the ones in Kibana. Installed Kibana
+// assets of the package are used to find extra objects.
+func DiffSavedObjects(ctx context.Context, kibanaClient *kibana.Client, packageRoot, packageName string, installedAssets []packages.Asset) ([]ObjectDiff, error) {
+	paths, err := filepath.Glob(filepath.Join(packageRoot, "kibana", "*", "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("listing saved objects failed: %w", err)
+	}
+
+	var diffs []ObjectDiff
+	var local []string
+	for _, path := range paths {
+		d, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading saved object failed: %w", err)
+		}
+		var object common.MapStr
+		err = json.Unmarshal(d, &object)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling saved object failed (path: %s): %w", path, err)
+		}
+		aType, _ := object["type"].(string)
+		id, _ := object["id"].(string)
+		local = append(local, aType+"/"+id)
+
+		kibanaObject, err := kibanaClient.GetSavedObject(ctx, aType, id)
+		var notFoundErr *kibana.ErrSavedObjectNotFound
+		if errors.As(err, &notFoundErr) {
+			diffs = append(diffs, ObjectDiff{Kind: aType, Name: id, Status: ObjectMissing})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		differences, err := export.SavedObjectDifferences(packageName, object, kibanaObject)
+		if err != nil {
+			return nil, fmt.Errorf("comparing saved object failed (path: %s): %w", path, err)
+		}
+		if len(differences) > 0 {
+			diffs = append(diffs, ObjectDiff{Kind: aType, Name: id, Status: ObjectModified, Differences: differences})
+		}
+	}
+
+	for _, asset := range installedAssets {
+		aType := string(asset.Type)
+		if slices.Contains(local, aType+"/"+asset.ID) || isFleetManagedAsset(aType, asset.ID) {
+			continue
+		}
+		diffs = append(diffs, ObjectDiff{Kind: aType, Name: asset.ID, Status: ObjectExtra})
+	}
+	return diffs, nil
+}
+
+// isFleetManagedAsset returns true for the Kibana assets created by Fleet on installation, that are not
+// defined in packages.
+func isFleetManagedAsset(aType, id string) bool {
+	switch aType {
+	case "index-pattern":
+		return true
+	case "tag":
+		return strings.Contains(id, "fleet-pkg-") || strings.Contains(id, "fleet-managed-")
+	}
+	return false
+}
+
+func toMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func jsonRoundTrip(v any) (map[string]any, error) {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]any
+	err = json.Unmarshal(d, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func settingString(value any) any {
+	switch value := value.(type) {
+	case []any:
+		values := make([]any, len(value))
+		for i, v := range value {
+			values[i] = settingString(v)
+		}
+		return values
+	case map[string]any:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
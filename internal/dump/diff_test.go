@@ -0,0 +1,149 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package dump
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareObjects(t *testing.T) {
+	expected := []expectedObject{
+		{
+			name: "logs-apache.access@package",
+			content: map[string]any{
+				"component_template": map[string]any{
+					"template": map[string]any{
+						"settings": map[string]any{"index": map[string]any{"codec": "best_compression"}},
+					},
+				},
+			},
+		},
+		{
+			name: "logs-apache.error@package",
+		},
+	}
+	installed := []installedObject{
+		{
+			name: "logs-apache.access@package",
+			content: map[string]any{
+				"component_template": map[string]any{
+					"template": map[string]any{
+						"settings": map[string]any{"index": map[string]any{"codec": "default", "hidden": "true"}},
+					},
+					"_meta": map[string]any{"package": map[string]any{"name": "apache"}},
+				},
+			},
+		},
+		{
+			name: "logs-apache.status@package",
+			content: map[string]any{
+				"component_template": map[string]any{
+					"_meta": map[string]any{"package": map[string]any{"name": "apache"}},
+				},
+			},
+		},
+		{
+			name: "logs-nginx.access@package",
+			content: map[string]any{
+				"component_template": map[string]any{
+					"_meta": map[string]any{"package": map[string]any{"name": "nginx"}},
+				},
+			},
+		},
+	}
+
+	diffs := compareObjects(kindComponentTemplate, expected, installed, "component_template._meta.package.name", "apache")
+	assert.Equal(t, []ObjectDiff{
+		{
+			Kind:        kindComponentTemplate,
+			Name:        "logs-apache.access@package",
+			Status:      ObjectModified,
+			Differences: []string{`~ component_template.template.settings.index.codec: "default" => "best_compression"`},
+		},
+		{Kind: kindComponentTemplate, Name: "logs-apache.error@package", Status: ObjectMissing},
+		{Kind: kindComponentTemplate, Name: "logs-apache.status@package", Status: ObjectExtra},
+	}, diffs)
+}
+
+func TestMergeLegacyComponentTemplates(t *testing.T) {
+	installed := []installedObject{
+		{name: "logs-apache.access@settings", content: map[string]any{"component_template": map[string]any{"template": map[string]any{"settings": map[string]any{}}}}},
+		{name: "logs-apache.access@mappings", content: map[string]any{"component_template": map[string]any{"template": map[string]any{"mappings": map[string]any{}}}}},
+		{name: "logs-apache.access@custom", content: map[string]any{}},
+	}
+
+	merged, err := mergeLegacyComponentTemplates(installed)
+	require.NoError(t, err)
+	require.Len(t, merged, 2)
+	assert.Equal(t, "logs-apache.access@custom", merged[0].name)
+	assert.Equal(t, "logs-apache.access@package", merged[1].name)
+	assert.Equal(t, map[string]any{
+		"settings": map[string]any{},
+		"mappings": map[string]any{},
+	}, merged[1].content["component_template"].(map[string]any)["template"])
+}
+
+func TestRemoveFleetPipelineProcessors(t *testing.T) {
+	pipeline := map[string]any{
+		"processors": []any{
+			map[string]any{"set": map[string]any{"field": "event.kind", "value": "event"}},
+			map[string]any{"pipeline": map[string]any{"name": "logs-apache.access-1.0.0-third-party"}},
+			map[string]any{"pipeline": map[string]any{"name": "logs@custom", "ignore_missing_pipeline": true}},
+			map[string]any{"pipeline": map[string]any{"name": "logs-apache.access@custom", "ignore_missing_pipeline": true}},
+		},
+	}
+
+	removeFleetPipelineProcessors(pipeline)
+	assert.Equal(t, []any{
+		map[string]any{"set": map[string]any{"field": "event.kind", "value": "event"}},
+		map[string]any{"pipeline": map[string]any{"name": "logs-apache.access-1.0.0-third-party"}},
+	}, pipeline["processors"])
+}
+
+func TestExpectedComponentTemplate(t *testing.T) {
+	manifest := `title: "Access logs"
+type: logs
+elasticsearch:
+  index_template:
+    settings:
+      number_of_shards: 2
+      index:
+        codec: best_compression
+        sort.field: ["@timestamp"]
+    mappings:
+      date_detection: false
+      dynamic_templates:
+        - strings:
+            match_mapping_type: string
+`
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(manifest), 0644))
+
+	template, err := expectedComponentTemplate(manifestPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"component_template": map[string]any{
+			"template": map[string]any{
+				"settings": map[string]any{
+					"index": map[string]any{
+						"number_of_shards": "2",
+						"codec":            "best_compression",
+						"sort": map[string]any{
+							"field": []any{"@timestamp"},
+						},
+					},
+				},
+				"mappings": map[string]any{
+					"date_detection": false,
+				},
+			},
+		},
+	}, template)
+}
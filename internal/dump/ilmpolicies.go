@@ -7,8 +7,10 @@ package dump
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 
 	"github.com/elastic/elastic-package/internal/elasticsearch"
 )
@@ -45,6 +47,9 @@ func getILMPolicies(ctx context.Context, api *elasticsearch.API, policies ...str
 	return ilmPolicies, nil
 }
 
+// errILMPolicyNotFound is returned when a requested ILM policy is not installed.
+var errILMPolicyNotFound = errors.New("ILM policy not found")
+
 type getILMLifecycleResponse map[string]json.RawMessage
 
 func getILMPolicyByName(ctx context.Context, api *elasticsearch.API, policy string) ([]ILMPolicy, error) {
@@ -57,6 +62,9 @@ func getILMPolicyByName(ctx context.Context, api *elasticsearch.API, policy stri
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("failed to get policy %s: %w: %s", policy, errILMPolicyNotFound, resp.String())
+	}
 	if resp.IsError() {
 		return nil, fmt.Errorf("failed to get policy %s: %s", policy, resp.String())
 	}
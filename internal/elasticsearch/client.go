@@ -23,6 +23,9 @@ import (
 // API contains the elasticsearch APIs
 type API = esapi.API
 
+// Response is the response of an API request.
+type Response = esapi.Response
+
 // IngestSimulateRequest configures the Ingest Simulate API request.
 type IngestSimulateRequest = esapi.IngestSimulateRequest
 
@@ -32,6 +35,9 @@ type IngestGetPipelineRequest = esapi.IngestGetPipelineRequest
 // ClusterStateRequest configures the Cluster State API request.
 type ClusterStateRequest = esapi.ClusterStateRequest
 
+// BulkRequest configures the Bulk API request.
+type BulkRequest = esapi.BulkRequest
+
 // clientOptions are used to configure a client.
 type clientOptions struct {
 	address  string
@@ -20,6 +20,7 @@ import (
 
 	"github.com/elastic/elastic-package/internal/elasticsearch"
 	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/pipelinesnippets"
 )
 
 var (
@@ -55,7 +56,9 @@ func InstallDataStreamPipelines(ctx context.Context, api *elasticsearch.API, dat
 	nonce := time.Now().UnixNano()
 
 	mainPipeline := getPipelineNameWithNonce(dataStreamManifest.GetPipelineNameOrDefault(), nonce)
-	pipelines, err := loadIngestPipelineFiles(dataStreamPath, nonce)
+	pipelines, err := loadIngestPipelineFiles(dataStreamPath, func(name string) string {
+		return getPipelineNameWithNonce(name, nonce)
+	})
 	if err != nil {
 		return "", nil, fmt.Errorf("loading ingest pipeline files failed: %w", err)
 	}
@@ -67,7 +70,29 @@ func InstallDataStreamPipelines(ctx context.Context, api *elasticsearch.API, dat
 	return mainPipeline, pipelines, nil
 }
 
-func loadIngestPipelineFiles(dataStreamPath string, nonce int64) ([]Pipeline, error) {
+// LoadFleetDataStreamPipelines loads the ingest pipelines of the data stream with the names and references
+// to other pipelines that Fleet uses when installing the given version of the package.
+func LoadFleetDataStreamPipelines(dataStreamPath, packageName, packageVersion string) ([]Pipeline, error) {
+	dataStreamManifest, err := packages.ReadDataStreamManifest(filepath.Join(dataStreamPath, packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading data stream manifest failed: %w", err)
+	}
+
+	mainPipeline := dataStreamManifest.GetPipelineNameOrDefault()
+	prefix := fmt.Sprintf("%s-%s", strings.TrimPrefix(dataStreamManifest.IndexTemplateName(packageName), "."), packageVersion)
+	pipelines, err := loadIngestPipelineFiles(dataStreamPath, func(name string) string {
+		if name == mainPipeline {
+			return prefix
+		}
+		return prefix + "-" + name
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading ingest pipeline files failed: %w", err)
+	}
+	return pipelines, nil
+}
+
+func loadIngestPipelineFiles(dataStreamPath string, pipelineName func(string) string) ([]Pipeline, error) {
 	elasticsearchPath := filepath.Join(dataStreamPath, "elasticsearch", "ingest_pipeline")
 
 	var pipelineFiles []string
@@ -79,6 +104,8 @@ func loadIngestPipelineFiles(dataStreamPath string, nonce int64) ([]Pipeline, er
 		pipelineFiles = append(pipelineFiles, files...)
 	}
 
+	packageRoot := filepath.Dir(filepath.Dir(dataStreamPath))
+
 	var pipelines []Pipeline
 	for _, path := range pipelineFiles {
 		c, err := os.ReadFile(path)
@@ -86,6 +113,16 @@ func loadIngestPipelineFiles(dataStreamPath string, nonce int64) ([]Pipeline, er
 			return nil, fmt.Errorf("reading ingest pipeline failed (path: %s): %w", path, err)
 		}
 
+		var sourceLines []pipelinesnippets.Line
+		if pipelinesnippets.HasIncludes(c) {
+			expanded, err := pipelinesnippets.Expand(packageRoot, path, c)
+			if err != nil {
+				return nil, fmt.Errorf("expanding pipeline snippets failed: %w", err)
+			}
+			c = expanded.Content
+			sourceLines = expanded.Lines
+		}
+
 		c = ingestPipelineTag.ReplaceAllFunc(c, func(found []byte) []byte {
 			s := strings.Split(string(found), `"`)
 			if len(s) != 3 {
@@ -93,7 +130,7 @@ func loadIngestPipelineFiles(dataStreamPath string, nonce int64) ([]Pipeline, er
 				return nil
 			}
 			pipelineTag := s[1]
-			return []byte(getPipelineNameWithNonce(pipelineTag, nonce))
+			return []byte(pipelineName(pipelineTag))
 		})
 		if err != nil {
 			return nil, err
@@ -107,10 +144,11 @@ func loadIngestPipelineFiles(dataStreamPath string, nonce int64) ([]Pipeline, er
 		name := filepath.Base(path)
 		pipelines = append(pipelines, Pipeline{
 			Path:            path,
-			Name:            getPipelineNameWithNonce(name[:strings.Index(name, ".")], nonce),
+			Name:            pipelineName(name[:strings.Index(name, ".")]),
 			Format:          filepath.Ext(path)[1:],
 			Content:         cWithRerouteProcessors,
 			ContentOriginal: c,
+			SourceLines:     sourceLines,
 		})
 	}
 	return pipelines, nil
@@ -11,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"slices"
+	"sort"
 
 	"github.com/elastic/elastic-package/internal/elasticsearch"
 )
@@ -119,6 +120,56 @@ func GetIndexTemplatesForPackage(ctx context.Context, api *elasticsearch.API, pa
 	return indexTemplates, nil
 }
 
+// GetDataStreamsForPackage returns the names of the data streams created from the index templates of a package.
+func GetDataStreamsForPackage(ctx context.Context, api *elasticsearch.API, packageName string) ([]string, error) {
+	indexTemplates, err := GetIndexTemplatesForPackage(ctx, api, packageName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index templates: %w", err)
+	}
+	if len(indexTemplates) == 0 {
+		return nil, nil
+	}
+
+	resp, err := api.Indices.GetDataStream(
+		api.Indices.GetDataStream.WithContext(ctx),
+		api.Indices.GetDataStream.WithName(fmt.Sprintf("*-%s.*", packageName)),
+		api.Indices.GetDataStream.WithExpandWildcards("all"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data streams: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("failed to get data streams: %s", resp.String())
+	}
+
+	var dataStreamsResponse struct {
+		DataStreams []struct {
+			Name     string `json:"name"`
+			Template string `json:"template"`
+		} `json:"data_streams"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&dataStreamsResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data streams: %w", err)
+	}
+
+	var dataStreams []string
+	for _, dataStream := range dataStreamsResponse.DataStreams {
+		isPackageTemplate := slices.ContainsFunc(indexTemplates, func(t IndexTemplate) bool {
+			return t.Name() == dataStream.Template
+		})
+		if isPackageTemplate {
+			dataStreams = append(dataStreams, dataStream.Name)
+		}
+	}
+	sort.Strings(dataStreams)
+	return dataStreams, nil
+}
+
 func managedByFleet(managedBy string) bool {
 	var managers = []string{"ingest-manager", "fleet"}
 	return slices.Contains(managers, managedBy)
@@ -18,6 +18,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/packages/pipelinesnippets"
 )
 
 type simulatePipelineRequest struct {
@@ -43,7 +44,20 @@ type Pipeline struct {
 	Name            string // Name of the pipeline.
 	Format          string // Format (extension) of the pipeline.
 	Content         []byte // Content is the pipeline file contents with reroute processors if any.
-	ContentOriginal []byte // Content is the original file contents.
+	ContentOriginal []byte // Content is the original file contents, with pipeline snippets expanded.
+
+	// SourceLines contains the source of each line of ContentOriginal when pipeline snippets are included.
+	SourceLines []pipelinesnippets.Line
+}
+
+// LineSource returns the path of the file and the line number where the given line of the
+// original contents is defined, that can be the pipeline file or a pipeline snippet.
+func (p *Pipeline) LineSource(line int) (string, int) {
+	if line < 1 || line > len(p.SourceLines) {
+		return p.Path, line
+	}
+	source := p.SourceLines[line-1]
+	return source.Path, source.Number
 }
 
 // Filename returns the original filename associated with the pipeline.
@@ -0,0 +1,250 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// AssetType describes a type of Kibana asset that can be exported into packages, other than dashboards
+// and their references.
+type AssetType struct {
+	// Name is the name used to select the asset type, e.g. in commands.
+	Name string
+
+	// Description is a human-readable description of the asset type.
+	Description string
+
+	// SavedObjectType is the type of the saved object in Kibana.
+	SavedObjectType string
+
+	// Folder is the folder of the package, under the kibana directory, where assets are stored.
+	Folder string
+
+	// PrefixID indicates if the IDs of the assets must be prefixed with the package name.
+	PrefixID bool
+
+	// export obtains the assets with the given IDs from Kibana, in the format of saved objects.
+	// The Saved Objects API is used when it is not set.
+	export func(ctx context.Context, kibanaClient *kibana.Client, ids []string) ([]common.MapStr, error)
+}
+
+// exportObjects obtains the assets of this type with the given IDs from Kibana.
+func (t AssetType) exportObjects(ctx context.Context, kibanaClient *kibana.Client, ids []string) ([]common.MapStr, error) {
+	if t.export != nil {
+		return t.export(ctx, kibanaClient, ids)
+	}
+
+	request := kibana.ExportSavedObjectsRequest{
+		ExcludeExportDetails:  true,
+		IncludeReferencesDeep: false,
+	}
+	for _, id := range ids {
+		request.Objects = append(request.Objects, kibana.ExportSavedObjectsRequestObject{
+			ID:   id,
+			Type: t.SavedObjectType,
+		})
+	}
+	exported, err := kibanaClient.ExportSavedObjects(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]common.MapStr, len(exported))
+	for i, object := range exported {
+		objects[i] = object
+	}
+	return objects, nil
+}
+
+// exportSecurityRules exports detection rules by their rule IDs, and wraps them in saved objects
+// of type security-rule, as they are stored in packages.
+func exportSecurityRules(ctx context.Context, kibanaClient *kibana.Client, ruleIDs []string) ([]common.MapStr, error) {
+	rules, err := kibanaClient.ExportDetectionRules(ctx, ruleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]common.MapStr, len(rules))
+	for i, rule := range rules {
+		attributes := common.MapStr(rule)
+		for _, key := range []string{"id", "created_at", "created_by", "updated_at", "updated_by", "execution_summary", "revision"} {
+			delete(attributes, key)
+		}
+		ruleID, _ := attributes["rule_id"].(string)
+		if ruleID == "" {
+			return nil, fmt.Errorf("detection rule without rule_id found")
+		}
+		version, found := attributes["version"].(float64)
+		if !found {
+			return nil, fmt.Errorf("detection rule %s without version found", ruleID)
+		}
+		objects[i] = common.MapStr{
+			"id":         fmt.Sprintf("%s_%d", ruleID, int64(version)),
+			"type":       "security-rule",
+			"attributes": map[string]any(attributes),
+		}
+	}
+	return objects, nil
+}
+
+// AssetTypes contains the types of Kibana assets that can be exported, in addition to dashboards.
+var AssetTypes = []AssetType{
+	{
+		Name:            "security-rules",
+		Description:     "security detection rules",
+		SavedObjectType: "security-rule",
+		Folder:          "security_rule",
+		export:          exportSecurityRules,
+	},
+	{
+		Name:            "osquery-packs",
+		Description:     "Osquery packs",
+		SavedObjectType: "osquery-pack-asset",
+		Folder:          "osquery_pack_asset",
+		PrefixID:        true,
+	},
+	{
+		Name:            "osquery-saved-queries",
+		Description:     "Osquery saved queries",
+		SavedObjectType: "osquery-saved-query",
+		Folder:          "osquery_saved_query",
+		PrefixID:        true,
+	},
+}
+
+// kibanaFolder returns the folder of the package where saved objects of the given type are stored.
+func kibanaFolder(savedObjectType string) string {
+	for _, assetType := range AssetTypes {
+		if assetType.SavedObjectType == savedObjectType {
+			return assetType.Folder
+		}
+	}
+	return savedObjectType
+}
+
+// AssetsOptions contains the options to export Kibana assets.
+type AssetsOptions struct {
+	Type AssetType
+	IDs  []string
+
+	// Resolve is called before overwriting local files modified since the last export or import,
+	// a nil resolver overwrites them.
+	Resolve ConflictResolver
+}
+
+// Assets method exports the selected Kibana assets of the given type, without their references. Assets are
+// saved to local files in the folder corresponding to their type.
+func Assets(ctx context.Context, kibanaClient *kibana.Client, opts AssetsOptions) error {
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return fmt.Errorf("locating package root failed: %w", err)
+	}
+	logger.Debugf("Package root found: %s", packageRoot)
+
+	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return fmt.Errorf("reading package manifest failed (path: %s): %w", packageRoot, err)
+	}
+
+	objects, err := opts.Type.exportObjects(ctx, kibanaClient, opts.IDs)
+	if err != nil {
+		return fmt.Errorf("exporting %s using Kibana client failed: %w", opts.Type.Description, err)
+	}
+
+	transformContext := &transformationContext{
+		packageName: m.Name,
+	}
+
+	objects, err = applyAssetTransformations(transformContext, opts.Type, objects)
+	if err != nil {
+		return fmt.Errorf("can't transform Kibana objects: %w", err)
+	}
+
+	state, err := readSyncState(m.Name)
+	if err != nil {
+		return err
+	}
+
+	err = saveObjectsToFiles(packageRoot, objects, state, opts.Resolve)
+	if err != nil {
+		return fmt.Errorf("can't save Kibana objects: %w", err)
+	}
+
+	err = state.write()
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func applyAssetTransformations(ctx *transformationContext, assetType AssetType, objects []common.MapStr) ([]common.MapStr, error) {
+	transforms := []func(*transformationContext, common.MapStr) (common.MapStr, error){
+		filterAssetType(assetType),
+		stripObjectProperties,
+		stripAssetProperties,
+		removeFleetManagedTags,
+	}
+	if assetType.PrefixID {
+		transforms = append(transforms, standardizeAssetID)
+	}
+	return newObjectTransformer().
+		withContext(ctx).
+		withTransforms(transforms...).
+		transform(objects)
+}
+
+// filterAssetType filters out the objects that are not of the exported type.
+func filterAssetType(assetType AssetType) func(*transformationContext, common.MapStr) (common.MapStr, error) {
+	return func(ctx *transformationContext, object common.MapStr) (common.MapStr, error) {
+		aType, _ := object.GetValue("type")
+		if aType != assetType.SavedObjectType {
+			id, _ := object.GetValue("id")
+			logger.Debugf("Skipping Kibana object %v of type %v, not %s", id, aType, assetType.Description)
+			return nil, nil
+		}
+		return object, nil
+	}
+}
+
+// stripAssetProperties removes the properties set by Kibana when the assets are created or updated.
+func stripAssetProperties(ctx *transformationContext, object common.MapStr) (common.MapStr, error) {
+	for _, key := range []string{"created_at", "created_by", "updated_by"} {
+		err := object.Delete(key)
+		if err != nil && err != common.ErrKeyNotFound {
+			return nil, fmt.Errorf("removing field %q failed: %w", key, err)
+		}
+	}
+	return object, nil
+}
+
+// standardizeAssetID prefixes the ID of the asset, and of the objects it references, with the package name.
+func standardizeAssetID(ctx *transformationContext, object common.MapStr) (common.MapStr, error) {
+	id, _ := object.GetValue("id")
+	_, err := object.Put("id", adjustObjectID(ctx, id.(string)))
+	if err != nil {
+		return nil, fmt.Errorf("can't update object ID: %w", err)
+	}
+
+	references, ok := object["references"].([]interface{})
+	if !ok {
+		return object, nil
+	}
+	newReferences, err := adjustObjectReferences(ctx, references)
+	if err != nil {
+		return nil, fmt.Errorf("can't adjust object references (ID: %s): %w", id, err)
+	}
+	_, err = object.Put("references", newReferences)
+	if err != nil {
+		return nil, fmt.Errorf("can't update references: %w", err)
+	}
+	return object, nil
+}
@@ -0,0 +1,221 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/kibana"
+)
+
+func assetType(t *testing.T, name string) AssetType {
+	t.Helper()
+	for _, assetType := range AssetTypes {
+		if assetType.Name == name {
+			return assetType
+		}
+	}
+	require.Failf(t, "asset type not found", "name: %s", name)
+	return AssetType{}
+}
+
+func TestApplyAssetTransformations(t *testing.T) {
+	ctx := &transformationContext{packageName: "apache"}
+
+	cases := []struct {
+		title     string
+		assetType string
+		objects   []common.MapStr
+		expected  []common.MapStr
+	}{
+		{
+			title:     "osquery pack with package prefix",
+			assetType: "osquery-packs",
+			objects: []common.MapStr{
+				{
+					"id":         "hardware-monitoring",
+					"type":       "osquery-pack-asset",
+					"attributes": map[string]any{"name": "hardware-monitoring"},
+					"references": []any{
+						map[string]any{"id": "fleet-pkg-apache-default", "type": "tag", "name": "tag-ref"},
+					},
+					"namespaces": []any{"default"},
+					"updated_at": "2024-01-01T00:00:00.000Z",
+					"updated_by": "u_elastic",
+					"created_at": "2024-01-01T00:00:00.000Z",
+					"created_by": "u_elastic",
+					"version":    "WzEsMV0=",
+					"managed":    true,
+				},
+			},
+			expected: []common.MapStr{
+				{
+					"id":         "apache-hardware-monitoring",
+					"type":       "osquery-pack-asset",
+					"attributes": map[string]any{"name": "hardware-monitoring"},
+					"references": []any{},
+				},
+			},
+		},
+		{
+			title:     "security rule keeps its ID",
+			assetType: "security-rules",
+			objects: []common.MapStr{
+				{
+					"id":         "000047bb-b27a-47ec-8b62-ef1a5d2c9e10_100",
+					"type":       "security-rule",
+					"attributes": map[string]any{"name": "Attempt to Modify an Okta Policy Rule"},
+					"updated_at": "2024-01-01T00:00:00.000Z",
+				},
+			},
+			expected: []common.MapStr{
+				{
+					"id":         "000047bb-b27a-47ec-8b62-ef1a5d2c9e10_100",
+					"type":       "security-rule",
+					"attributes": map[string]any{"name": "Attempt to Modify an Okta Policy Rule"},
+				},
+			},
+		},
+		{
+			title:     "objects of other types are filtered out",
+			assetType: "osquery-saved-queries",
+			objects: []common.MapStr{
+				{
+					"id":   "apache-overview",
+					"type": "dashboard",
+				},
+			},
+			expected: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			objects, err := applyAssetTransformations(ctx, assetType(t, c.assetType), c.objects)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, objects)
+		})
+	}
+}
+
+func TestKibanaFolder(t *testing.T) {
+	assert.Equal(t, "dashboard", kibanaFolder("dashboard"))
+	assert.Equal(t, "security_rule", kibanaFolder("security-rule"))
+	assert.Equal(t, "osquery_saved_query", kibanaFolder("osquery-saved-query"))
+}
+
+func TestAssetTypesExportObjects(t *testing.T) {
+	cases := []struct {
+		assetType    string
+		ids          []string
+		path         string
+		expectedBody string
+		response     string
+		expected     []common.MapStr
+	}{
+		{
+			assetType:    "security-rules",
+			ids:          []string{"000047bb-b27a-47ec-8b62-ef1a5d2c9e10"},
+			path:         "/api/detection_engine/rules/_export",
+			expectedBody: `{"objects":[{"rule_id":"000047bb-b27a-47ec-8b62-ef1a5d2c9e10"}]}`,
+			response: `{"id":"6a3c5e1a-2b3f-4d1a-8e0d-1c2b3a4d5e6f","rule_id":"000047bb-b27a-47ec-8b62-ef1a5d2c9e10","version":100,"name":"Attempt to Modify an Okta Policy Rule","revision":2,"created_at":"2024-01-01T00:00:00.000Z","created_by":"elastic","updated_at":"2024-01-01T00:00:00.000Z","updated_by":"elastic"}
+{"list_id":"endpoint_list","item_id":"item-1","type":"simple"}
+{"exported_count":2,"exported_rules_count":1,"missing_rules":[],"missing_rules_count":0}
+`,
+			expected: []common.MapStr{
+				{
+					"id":   "000047bb-b27a-47ec-8b62-ef1a5d2c9e10_100",
+					"type": "security-rule",
+					"attributes": map[string]any{
+						"rule_id": "000047bb-b27a-47ec-8b62-ef1a5d2c9e10",
+						"version": float64(100),
+						"name":    "Attempt to Modify an Okta Policy Rule",
+					},
+				},
+			},
+		},
+		{
+			assetType:    "osquery-packs",
+			ids:          []string{"hardware-monitoring"},
+			path:         "/api/saved_objects/_export",
+			expectedBody: `{"excludeExportDetails":true,"includeReferencesDeep":false,"objects":[{"id":"hardware-monitoring","type":"osquery-pack-asset"}]}`,
+			response:     `{"id":"hardware-monitoring","type":"osquery-pack-asset","attributes":{"name":"hardware-monitoring"}}` + "\n",
+			expected: []common.MapStr{
+				{
+					"id":         "hardware-monitoring",
+					"type":       "osquery-pack-asset",
+					"attributes": map[string]any{"name": "hardware-monitoring"},
+				},
+			},
+		},
+		{
+			assetType:    "osquery-saved-queries",
+			ids:          []string{"uptime"},
+			path:         "/api/saved_objects/_export",
+			expectedBody: `{"excludeExportDetails":true,"includeReferencesDeep":false,"objects":[{"id":"uptime","type":"osquery-saved-query"}]}`,
+			response:     `{"id":"uptime","type":"osquery-saved-query","attributes":{"id":"uptime","query":"select * from uptime;"}}` + "\n",
+			expected: []common.MapStr{
+				{
+					"id":         "uptime",
+					"type":       "osquery-saved-query",
+					"attributes": map[string]any{"id": "uptime", "query": "select * from uptime;"},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.assetType, func(t *testing.T) {
+			client := newMockKibanaClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, c.path, r.URL.Path)
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, c.expectedBody, string(body))
+				fmt.Fprint(w, c.response)
+			})
+
+			objects, err := assetType(t, c.assetType).exportObjects(context.Background(), client, c.ids)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, objects)
+		})
+	}
+}
+
+func TestExportSecurityRulesMissing(t *testing.T) {
+	client := newMockKibanaClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"exported_count":0,"exported_rules_count":0,"missing_rules":[{"rule_id":"not-found"}],"missing_rules_count":1}`)
+	})
+
+	_, err := assetType(t, "security-rules").exportObjects(context.Background(), client, []string{"not-found"})
+	assert.ErrorContains(t, err, "detection rules not found: not-found")
+}
+
+// newMockKibanaClient returns a Kibana client for a server that handles the status API, and
+// uses the given handler for any other request.
+func newMockKibanaClient(t *testing.T, handler http.HandlerFunc) *kibana.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == kibana.StatusAPI {
+			fmt.Fprintln(w, `{"version":{"number":"8.15.0"}}`)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := kibana.NewClient(kibana.Address(server.URL))
+	require.NoError(t, err)
+	return client
+}
@@ -7,9 +7,11 @@ package export
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 
@@ -19,9 +21,23 @@ import (
 	"github.com/elastic/elastic-package/internal/packages"
 )
 
+// DashboardsOptions contains the options to export dashboards.
+type DashboardsOptions struct {
+	DashboardIDs []string
+
+	// Resolve is called before overwriting local files modified since the last export or import,
+	// a nil resolver overwrites them.
+	Resolve ConflictResolver
+
+	// TargetKibanaVersion is the version of Kibana the exported objects must be compatible with, the
+	// export fails if some object is not compatible. If it is not set, objects are checked against
+	// the minimum version of Kibana supported by the package, and only warnings are reported.
+	TargetKibanaVersion *semver.Version
+}
+
 // Dashboards method exports selected dashboards with references objects. All Kibana objects are saved to local files
 // in appropriate directories.
-func Dashboards(ctx context.Context, kibanaClient *kibana.Client, dashboardsIDs []string) error {
+func Dashboards(ctx context.Context, kibanaClient *kibana.Client, opts DashboardsOptions) error {
 	packageRoot, err := packages.MustFindPackageRoot()
 	if err != nil {
 		return fmt.Errorf("locating package root failed: %w", err)
@@ -41,7 +57,7 @@ func Dashboards(ctx context.Context, kibanaClient *kibana.Client, dashboardsIDs
 		return fmt.Errorf("cannot import from this Kibana version: %w", err)
 	}
 
-	objects, err := kibanaClient.Export(ctx, dashboardsIDs)
+	objects, err := kibanaClient.Export(ctx, opts.DashboardIDs)
 	if err != nil {
 		return fmt.Errorf("exporting dashboards using Kibana client failed: %w", err)
 	}
@@ -55,10 +71,25 @@ func Dashboards(ctx context.Context, kibanaClient *kibana.Client, dashboardsIDs
 		return fmt.Errorf("can't transform Kibana objects: %w", err)
 	}
 
-	err = saveObjectsToFiles(packageRoot, objects)
+	err = checkObjectsCompatibility(*m, objects, opts.TargetKibanaVersion)
+	if err != nil {
+		return err
+	}
+
+	state, err := readSyncState(m.Name)
+	if err != nil {
+		return err
+	}
+
+	err = saveObjectsToFiles(packageRoot, objects, state, opts.Resolve)
 	if err != nil {
 		return fmt.Errorf("can't save Kibana objects: %w", err)
 	}
+
+	err = state.write()
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -79,6 +110,45 @@ func checkKibanaVersion(info kibana.VersionInfo) error {
 	return nil
 }
 
+// checkObjectsCompatibility checks that the objects can be installed in the target version of Kibana. If there is no
+// target version, the minimum version supported by the package is used, and incompatibilities are reported as warnings.
+func checkObjectsCompatibility(manifest packages.PackageManifest, objects []common.MapStr, target *semver.Version) error {
+	strict := target != nil
+	if !strict {
+		var err error
+		target, err = packages.MinimumKibanaVersion(manifest)
+		if err != nil {
+			return fmt.Errorf("cannot determine minimum Kibana version supported by the package: %w", err)
+		}
+		if target == nil {
+			logger.Debug("Skipping migration versions check, the package doesn't define a minimum Kibana version")
+			return nil
+		}
+	}
+
+	var incompatible []MigrationIssue
+	for _, issue := range checkMigrationVersions(objects, target) {
+		switch {
+		case !issue.Incompatible(target):
+			logger.Warnf("Exported %s, compatibility with Kibana %s cannot be verified", issue, target)
+		case !strict:
+			logger.Warnf("Exported %s, but the package supports Kibana %s", issue, target)
+		default:
+			incompatible = append(incompatible, issue)
+		}
+	}
+	if len(incompatible) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d saved objects are not compatible with Kibana %s:", len(incompatible), target)
+	for _, issue := range incompatible {
+		fmt.Fprintf(&sb, "\n- %s", issue)
+	}
+	return errors.New(sb.String())
+}
+
 func applyTransformations(ctx *transformationContext, objects []common.MapStr) ([]common.MapStr, error) {
 	return newObjectTransformer().
 		withContext(ctx).
@@ -91,12 +161,28 @@ func applyTransformations(ctx *transformationContext, objects []common.MapStr) (
 		transform(objects)
 }
 
-func saveObjectsToFiles(packageRoot string, objects []common.MapStr) error {
+func saveObjectsToFiles(packageRoot string, objects []common.MapStr, state *syncState, resolve ConflictResolver) error {
 	logger.Debug("Save Kibana objects to files")
 
 	for _, object := range objects {
 		id, _ := object.GetValue("id")
 		aType, _ := object.GetValue("type")
+		key := objectKey(aType.(string), id.(string))
+		targetDir := filepath.Join(packageRoot, "kibana", kibanaFolder(aType.(string)))
+		objectPath := filepath.Join(targetDir, id.(string)+".json")
+
+		content, err := semanticContent(object)
+		if err != nil {
+			return fmt.Errorf("reading content of Kibana object failed (ID: %s): %w", id, err)
+		}
+		overwrite, err := confirmLocalOverwrite(objectPath, aType.(string), id.(string), content, state, resolve)
+		if err != nil {
+			return err
+		}
+		if !overwrite {
+			logger.Debugf("Skipping Kibana object %s, modified since the last export", key)
+			continue
+		}
 
 		// Marshal object to byte content
 		b, err := json.MarshalIndent(&object, "", "    ")
@@ -105,18 +191,61 @@ func saveObjectsToFiles(packageRoot string, objects []common.MapStr) error {
 		}
 
 		// Create target directory
-		targetDir := filepath.Join(packageRoot, "kibana", aType.(string))
 		err = os.MkdirAll(targetDir, 0755)
 		if err != nil {
 			return fmt.Errorf("creating target directory failed (path: %s): %w", targetDir, err)
 		}
 
 		// Save object to file
-		objectPath := filepath.Join(targetDir, id.(string)+".json")
 		err = os.WriteFile(objectPath, b, 0644)
 		if err != nil {
 			return fmt.Errorf("writing to file failed: %w", err)
 		}
+		state.Objects[key] = contentHash(content)
 	}
 	return nil
 }
+
+// confirmLocalOverwrite checks if the local copy of the object can be overwritten with the given content.
+// Local files modified since the last export or import need confirmation.
+func confirmLocalOverwrite(objectPath, aType, id string, content map[string]any, state *syncState, resolve ConflictResolver) (bool, error) {
+	lastHash, found := state.Objects[objectKey(aType, id)]
+	if resolve == nil || !found {
+		return true, nil
+	}
+	local, err := readObjectFile(objectPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	localContent, err := semanticContent(local)
+	if err != nil {
+		return false, fmt.Errorf("reading content of local object failed (path: %s): %w", objectPath, err)
+	}
+	localHash := contentHash(localContent)
+	if localHash == lastHash || localHash == contentHash(content) {
+		return true, nil
+	}
+
+	return resolve(Conflict{
+		Type:        aType,
+		ID:          id,
+		Path:        objectPath,
+		Differences: common.DiffValues(localContent, content),
+	})
+}
+
+func readObjectFile(path string) (common.MapStr, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var object common.MapStr
+	err = json.Unmarshal(b, &object)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling saved object failed (path: %s): %w", path, err)
+	}
+	return object, nil
+}
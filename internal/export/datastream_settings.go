@@ -0,0 +1,488 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/formatter"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+const (
+	packageComponentTemplateSuffix = "@package"
+	customComponentTemplateSuffix  = "@custom"
+)
+
+// managedIndexSettings are the settings of the component templates that are set by Fleet on installation,
+// and cannot be defined in the data stream manifests.
+var managedIndexSettings = []string{
+	"index.default_pipeline",
+	"index.final_pipeline",
+	"index.hidden",
+	"index.lifecycle",
+	"index.mapping.ignore_malformed",
+	"index.mapping.source",
+	"index.mode",
+	"index.query",
+	"index.routing_path",
+}
+
+// topLevelIndexSettings are the index settings that are defined out of the "index" object in the data
+// stream manifests.
+var topLevelIndexSettings = []string{
+	"analysis",
+	"number_of_shards",
+}
+
+// DataStreamSettingsOptions contains the options to export data stream settings.
+type DataStreamSettingsOptions struct {
+	// ComponentTemplates are the names of the @package or @custom component templates to export.
+	ComponentTemplates []string
+
+	// ILMPolicies are the names of the ILM policies to export.
+	ILMPolicies []string
+
+	// DryRun only reports the changes, without writing them.
+	DryRun bool
+}
+
+// DataStreamSettingsChange describes the changes applied to a file of the package.
+type DataStreamSettingsChange struct {
+	// Source is the name of the component template or ILM policy the changes come from.
+	Source string
+
+	// Path is the path of the modified file.
+	Path string
+
+	Differences []string
+}
+
+type dataStreamTarget struct {
+	manifestPath string
+	manifest     *packages.DataStreamManifest
+	templateName string
+}
+
+// DataStreamSettings method exports the settings and mappings of component templates into the manifests of the
+// corresponding data streams, and ILM policies into their definitions in the package. Settings and mappings found
+// in the component templates are merged into the ones defined in the manifests. Settings managed by Fleet and
+// field mappings are not exported.
+func DataStreamSettings(ctx context.Context, api *elasticsearch.API, opts DataStreamSettingsOptions) ([]DataStreamSettingsChange, error) {
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return nil, fmt.Errorf("locating package root failed: %w", err)
+	}
+	logger.Debugf("Package root found: %s", packageRoot)
+
+	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed (path: %s): %w", packageRoot, err)
+	}
+
+	targets, err := readDataStreamTargets(packageRoot, m.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []DataStreamSettingsChange
+	for _, name := range opts.ComponentTemplates {
+		change, err := exportComponentTemplate(ctx, api, targets, name, opts.DryRun)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+	for _, name := range opts.ILMPolicies {
+		change, err := exportILMPolicy(ctx, api, targets, name, opts.DryRun)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+	return changes, nil
+}
+
+func readDataStreamTargets(packageRoot, packageName string) ([]dataStreamTarget, error) {
+	manifestPaths, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("listing data streams failed: %w", err)
+	}
+
+	var targets []dataStreamTarget
+	for _, manifestPath := range manifestPaths {
+		manifest, err := packages.ReadDataStreamManifest(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, dataStreamTarget{
+			manifestPath: manifestPath,
+			manifest:     manifest,
+			templateName: manifest.IndexTemplateName(packageName),
+		})
+	}
+	return targets, nil
+}
+
+func exportComponentTemplate(ctx context.Context, api *elasticsearch.API, targets []dataStreamTarget, name string, dryRun bool) (*DataStreamSettingsChange, error) {
+	idx := slices.IndexFunc(targets, func(target dataStreamTarget) bool {
+		return name == target.templateName+packageComponentTemplateSuffix || name == target.templateName+customComponentTemplateSuffix
+	})
+	if idx < 0 {
+		return nil, fmt.Errorf("component template %q doesn't belong to any data stream of the package", name)
+	}
+	target := targets[idx]
+
+	template, err := getComponentTemplate(ctx, api, name)
+	if err != nil {
+		return nil, err
+	}
+
+	indexTemplate := map[string]any{}
+	settings := manifestSettings(template.Settings)
+	if len(settings) > 0 {
+		indexTemplate["settings"] = settings
+	}
+	mappings := manifestMappings(template.Mappings, strings.HasSuffix(name, packageComponentTemplateSuffix))
+	if len(mappings) > 0 {
+		indexTemplate["mappings"] = mappings
+	}
+
+	content, err := os.ReadFile(target.manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading data stream manifest failed: %w", err)
+	}
+	updated, differences, err := mergeIndexTemplate(content, indexTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("updating data stream manifest failed (path: %s): %w", target.manifestPath, err)
+	}
+
+	if !dryRun && len(differences) > 0 {
+		err = os.WriteFile(target.manifestPath, updated, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("writing data stream manifest failed: %w", err)
+		}
+	}
+
+	return &DataStreamSettingsChange{
+		Source:      name,
+		Path:        target.manifestPath,
+		Differences: differences,
+	}, nil
+}
+
+func exportILMPolicy(ctx context.Context, api *elasticsearch.API, targets []dataStreamTarget, name string, dryRun bool) (*DataStreamSettingsChange, error) {
+	var policyPath string
+	for _, target := range targets {
+		paths, err := filepath.Glob(filepath.Join(filepath.Dir(target.manifestPath), "elasticsearch", "ilm", "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("listing ILM policies failed: %w", err)
+		}
+		for _, path := range paths {
+			policyName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			if name == strings.TrimPrefix(target.templateName, ".")+"-"+policyName {
+				policyPath = path
+			}
+		}
+	}
+	if policyPath == "" {
+		return nil, fmt.Errorf("ILM policy %q is not defined in any data stream of the package", name)
+	}
+
+	policy, err := getILMPolicy(ctx, api, name)
+	if err != nil {
+		return nil, err
+	}
+	// Metadata is added by Fleet on installation.
+	delete(policy, "_meta")
+
+	var current map[string]any
+	content, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading ILM policy failed: %w", err)
+	}
+	err = json.Unmarshal(content, &current)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling ILM policy failed (path: %s): %w", policyPath, err)
+	}
+
+	updated := map[string]any{"policy": policy}
+	differences := common.DiffValues(current, updated)
+	if !dryRun && len(differences) > 0 {
+		b, err := json.MarshalIndent(updated, "", "    ")
+		if err != nil {
+			return nil, fmt.Errorf("marshalling ILM policy failed: %w", err)
+		}
+		err = os.WriteFile(policyPath, b, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("writing ILM policy failed: %w", err)
+		}
+	}
+
+	return &DataStreamSettingsChange{
+		Source:      name,
+		Path:        policyPath,
+		Differences: differences,
+	}, nil
+}
+
+// manifestSettings converts the index settings of a component template to the format used in the data stream
+// manifests, removing the settings managed by Fleet.
+func manifestSettings(settings map[string]any) map[string]any {
+	flattened := make(map[string]any)
+	common.FlattenMap("", settings, flattened)
+
+	result := make(map[string]any)
+	for key, value := range flattened {
+		if !strings.HasPrefix(key, "index.") {
+			key = "index." + key
+		}
+		if slices.ContainsFunc(managedIndexSettings, func(managed string) bool {
+			return key == managed || strings.HasPrefix(key, managed+".")
+		}) {
+			logger.Debugf("Ignoring setting %q managed by Fleet", key)
+			continue
+		}
+		for _, topLevel := range topLevelIndexSettings {
+			if key == "index."+topLevel || strings.HasPrefix(key, "index."+topLevel+".") {
+				key = strings.TrimPrefix(key, "index.")
+				break
+			}
+		}
+		common.PutNested(result, key, settingValue(value))
+	}
+	return result
+}
+
+// manifestMappings returns the mapping options of a component template that can be defined in the data stream
+// manifests. Field mappings are generated from the field definitions, so they are not included. Dynamic templates
+// in @package templates are also generated from field definitions.
+func manifestMappings(mappings map[string]any, fromPackage bool) map[string]any {
+	result := make(map[string]any)
+	for key, value := range mappings {
+		switch key {
+		case "properties", "_meta":
+			continue
+		case "dynamic_templates":
+			if fromPackage {
+				continue
+			}
+		}
+		result[key] = value
+	}
+	return result
+}
+
+// settingValue converts the values of settings, that Elasticsearch returns as strings, to their types.
+func settingValue(value any) any {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+		return b
+	}
+	return s
+}
+
+// mergeIndexTemplate merges the given index template into the elasticsearch.index_template section of the
+// data stream manifest, and returns the updated manifest with the differences.
+func mergeIndexTemplate(content []byte, indexTemplate map[string]any) ([]byte, []string, error) {
+	var node yaml.Node
+	err := yaml.Unmarshal(content, &node)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, errors.New("unexpected manifest content: not a map")
+	}
+
+	var before map[string]any
+	err = node.Content[0].Decode(&before)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	templateNode := yamlMapping(yamlMapping(node.Content[0], "elasticsearch"), "index_template")
+	err = mergeYAMLMapping(templateNode, indexTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var after map[string]any
+	err = node.Content[0].Decode(&after)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode updated manifest: %w", err)
+	}
+	differences := common.DiffValues(before, after)
+	if len(differences) == 0 {
+		return content, nil, nil
+	}
+
+	d, err := yaml.Marshal(&node)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	d, _, err = formatter.NewYAMLFormatter(formatter.KeysWithDotActionNone).Format(d)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to format manifest: %w", err)
+	}
+	return d, differences, nil
+}
+
+// yamlMapping returns the mapping node for the given key of a mapping node, it is created if it doesn't exist.
+func yamlMapping(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key && node.Content[i+1].Kind == yaml.MappingNode {
+			return node.Content[i+1]
+		}
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value,
+	)
+	return value
+}
+
+// mergeYAMLMapping sets the values of the given map into a mapping node, keeping the values not present in the map.
+func mergeYAMLMapping(node *yaml.Node, values map[string]any) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if nested, ok := values[key].(map[string]any); ok {
+			err := mergeYAMLMapping(yamlMapping(node, key), nested)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		var value yaml.Node
+		err := value.Encode(values[key])
+		if err != nil {
+			return fmt.Errorf("failed to encode value of %q: %w", key, err)
+		}
+		found := false
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				node.Content[i+1] = &value
+				found = true
+				break
+			}
+		}
+		if !found {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+				&value,
+			)
+		}
+	}
+	return nil
+}
+
+type componentTemplate struct {
+	Settings map[string]any
+	Mappings map[string]any
+}
+
+func getComponentTemplate(ctx context.Context, api *elasticsearch.API, name string) (*componentTemplate, error) {
+	resp, err := api.Cluster.GetComponentTemplate(
+		api.Cluster.GetComponentTemplate.WithContext(ctx),
+		api.Cluster.GetComponentTemplate.WithName(name),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get component template %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("failed to get component template %s: %s", name, resp.String())
+	}
+
+	d, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var templateResponse struct {
+		ComponentTemplates []struct {
+			Name              string `json:"name"`
+			ComponentTemplate struct {
+				Template struct {
+					Settings map[string]any `json:"settings"`
+					Mappings map[string]any `json:"mappings"`
+				} `json:"template"`
+			} `json:"component_template"`
+		} `json:"component_templates"`
+	}
+	err = json.Unmarshal(d, &templateResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(templateResponse.ComponentTemplates) != 1 {
+		return nil, fmt.Errorf("expected one component template with name %s, found %d", name, len(templateResponse.ComponentTemplates))
+	}
+
+	template := templateResponse.ComponentTemplates[0].ComponentTemplate.Template
+	return &componentTemplate{
+		Settings: template.Settings,
+		Mappings: template.Mappings,
+	}, nil
+}
+
+func getILMPolicy(ctx context.Context, api *elasticsearch.API, name string) (map[string]any, error) {
+	resp, err := api.ILM.GetLifecycle(
+		api.ILM.GetLifecycle.WithContext(ctx),
+		api.ILM.GetLifecycle.WithPolicy(name),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return nil, fmt.Errorf("failed to get policy %s: %s", name, resp.String())
+	}
+
+	d, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var policiesResponse map[string]struct {
+		Policy map[string]any `json:"policy"`
+	}
+	err = json.Unmarshal(d, &policiesResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	policy, found := policiesResponse[name]
+	if !found {
+		return nil, fmt.Errorf("policy %s not found", name)
+	}
+	return policy.Policy, nil
+}
@@ -0,0 +1,114 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestSettings(t *testing.T) {
+	settings := map[string]any{
+		"index": map[string]any{
+			"codec":            "best_compression",
+			"number_of_shards": "2",
+			"default_pipeline": "logs-apache.access-1.0.0",
+			"lifecycle": map[string]any{
+				"name": "logs",
+			},
+			"mapping": map[string]any{
+				"total_fields": map[string]any{
+					"limit": "5000",
+				},
+			},
+			"sort": map[string]any{
+				"field": []any{"@timestamp"},
+			},
+		},
+	}
+
+	expected := map[string]any{
+		"number_of_shards": int64(2),
+		"index": map[string]any{
+			"codec": "best_compression",
+			"mapping": map[string]any{
+				"total_fields": map[string]any{
+					"limit": int64(5000),
+				},
+			},
+			"sort": map[string]any{
+				"field": []any{"@timestamp"},
+			},
+		},
+	}
+	assert.Equal(t, expected, manifestSettings(settings))
+}
+
+func TestManifestMappings(t *testing.T) {
+	mappings := map[string]any{
+		"_meta":             map[string]any{"package": map[string]any{"name": "apache"}},
+		"properties":        map[string]any{"message": map[string]any{"type": "text"}},
+		"dynamic_templates": []any{map[string]any{"strings": map[string]any{"match_mapping_type": "string"}}},
+		"date_detection":    false,
+	}
+
+	assert.Equal(t, map[string]any{"date_detection": false}, manifestMappings(mappings, true))
+	assert.Equal(t, map[string]any{
+		"date_detection":    false,
+		"dynamic_templates": []any{map[string]any{"strings": map[string]any{"match_mapping_type": "string"}}},
+	}, manifestMappings(mappings, false))
+}
+
+func TestMergeIndexTemplate(t *testing.T) {
+	manifest := `title: "Access logs"
+type: logs
+elasticsearch:
+  index_template:
+    mappings:
+      subobjects: false
+    settings:
+      index:
+        codec: best_compression
+`
+	indexTemplate := map[string]any{
+		"settings": map[string]any{
+			"number_of_shards": int64(2),
+			"index": map[string]any{
+				"codec": "best_compression",
+			},
+		},
+		"mappings": map[string]any{
+			"date_detection": false,
+		},
+	}
+
+	updated, differences, err := mergeIndexTemplate([]byte(manifest), indexTemplate)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"+ elasticsearch.index_template.mappings.date_detection: false",
+		"+ elasticsearch.index_template.settings.number_of_shards: 2",
+	}, differences)
+
+	expected := `title: "Access logs"
+type: logs
+elasticsearch:
+  index_template:
+    mappings:
+      subobjects: false
+      date_detection: false
+    settings:
+      index:
+        codec: best_compression
+      number_of_shards: 2
+`
+	assert.Equal(t, expected, string(updated))
+
+	// Merging again doesn't produce changes.
+	_, differences, err = mergeIndexTemplate(updated, indexTemplate)
+	require.NoError(t, err)
+	assert.Empty(t, differences)
+}
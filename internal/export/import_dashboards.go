@@ -0,0 +1,226 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/multierror"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// ImportSummary contains the keys of the saved objects processed on import, in the form of <type>/<id>.
+type ImportSummary struct {
+	Imported []string
+	UpToDate []string
+	Skipped  []string
+}
+
+type importedObject struct {
+	object common.MapStr
+	hash   string
+}
+
+// ImportDashboards method imports the saved objects of the package into Kibana, overwriting the existing
+// ones. Objects modified in Kibana since the last export or import are only overwritten if the resolver
+// confirms it, a nil resolver overwrites them.
+func ImportDashboards(ctx context.Context, kibanaClient *kibana.Client, resolve ConflictResolver) (*ImportSummary, error) {
+	packageRoot, err := packages.MustFindPackageRoot()
+	if err != nil {
+		return nil, fmt.Errorf("locating package root failed: %w", err)
+	}
+	logger.Debugf("Package root found: %s", packageRoot)
+
+	m, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed (path: %s): %w", packageRoot, err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(packageRoot, "kibana", "*", "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("listing saved objects failed: %w", err)
+	}
+
+	state, err := readSyncState(m.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	transformContext := &transformationContext{
+		packageName: m.Name,
+	}
+
+	var summary ImportSummary
+	toImport := make(map[string]importedObject)
+	for _, path := range paths {
+		object, err := readObjectFile(path)
+		if err != nil {
+			return nil, err
+		}
+		aType, _ := object["type"].(string)
+		id, _ := object["id"].(string)
+		key := objectKey(aType, id)
+
+		content, err := semanticContent(object)
+		if err != nil {
+			return nil, fmt.Errorf("reading content of local object failed (path: %s): %w", path, err)
+		}
+		hash := contentHash(content)
+
+		overwrite, err := confirmKibanaOverwrite(ctx, kibanaClient, transformContext, path, aType, id, content, state, resolve)
+		if err != nil {
+			return nil, err
+		}
+		switch overwrite {
+		case overwriteUpToDate:
+			state.Objects[key] = hash
+			summary.UpToDate = append(summary.UpToDate, key)
+			continue
+		case overwriteRejected:
+			summary.Skipped = append(summary.Skipped, key)
+			continue
+		}
+
+		object, err = encodeObject(transformContext, object)
+		if err != nil {
+			return nil, fmt.Errorf("encoding saved object failed (path: %s): %w", path, err)
+		}
+		toImport[key] = importedObject{object: object, hash: hash}
+	}
+
+	if len(toImport) > 0 {
+		imported, err := importObjects(ctx, kibanaClient, toImport)
+		for _, key := range imported {
+			state.Objects[key] = toImport[key].hash
+			summary.Imported = append(summary.Imported, key)
+		}
+		if err != nil {
+			return nil, errors.Join(err, state.write())
+		}
+	}
+
+	err = state.write()
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+type overwriteDecision int
+
+const (
+	overwriteAccepted overwriteDecision = iota
+	overwriteRejected
+	overwriteUpToDate
+)
+
+// confirmKibanaOverwrite checks if the object in Kibana can be overwritten with the given content.
+// Objects modified in Kibana since the last export or import need confirmation.
+func confirmKibanaOverwrite(ctx context.Context, kibanaClient *kibana.Client, transformContext *transformationContext, path, aType, id string, content map[string]any, state *syncState, resolve ConflictResolver) (overwriteDecision, error) {
+	kibanaObject, err := kibanaClient.GetSavedObject(ctx, aType, id)
+	var notFoundErr *kibana.ErrSavedObjectNotFound
+	if errors.As(err, &notFoundErr) {
+		return overwriteAccepted, nil
+	}
+	if err != nil {
+		return overwriteRejected, err
+	}
+
+	kibanaContent, err := normalizeKibanaObject(transformContext, kibanaObject)
+	if err != nil {
+		return overwriteRejected, fmt.Errorf("reading content of Kibana object failed (%s %s): %w", aType, id, err)
+	}
+	kibanaHash := contentHash(kibanaContent)
+	if kibanaHash == contentHash(content) {
+		return overwriteUpToDate, nil
+	}
+	if resolve == nil || state.Objects[objectKey(aType, id)] == kibanaHash {
+		return overwriteAccepted, nil
+	}
+
+	confirmed, err := resolve(Conflict{
+		Type:        aType,
+		ID:          id,
+		Path:        path,
+		Differences: common.DiffValues(kibanaContent, content),
+	})
+	if err != nil {
+		return overwriteRejected, err
+	}
+	if !confirmed {
+		return overwriteRejected, nil
+	}
+	return overwriteAccepted, nil
+}
+
+// normalizeKibanaObject applies to the object the same transformations applied on export, so
+// its content can be compared with the local copy.
+func normalizeKibanaObject(ctx *transformationContext, object map[string]any) (map[string]any, error) {
+	objects, err := newObjectTransformer().
+		withContext(ctx).
+		withTransforms(decodeObject,
+			stripObjectProperties,
+			standardizeObjectProperties,
+			removeFleetManagedTags,
+			standardizeObjectID).
+		transform([]common.MapStr{object})
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, errors.New("object filtered out on export")
+	}
+	return semanticContent(objects[0])
+}
+
+// SavedObjectDifferences returns the changes needed to update a saved object in Kibana to its version in the
+// package, after applying to the Kibana object the same transformations applied on export.
+func SavedObjectDifferences(packageName string, local, kibanaObject common.MapStr) ([]string, error) {
+	transformContext := &transformationContext{
+		packageName: packageName,
+	}
+	kibanaContent, err := normalizeKibanaObject(transformContext, kibanaObject)
+	if err != nil {
+		return nil, fmt.Errorf("reading content of Kibana object failed: %w", err)
+	}
+	localContent, err := semanticContent(local)
+	if err != nil {
+		return nil, fmt.Errorf("reading content of local object failed: %w", err)
+	}
+	return common.DiffValues(kibanaContent, localContent), nil
+}
+
+// importObjects imports the objects into Kibana, and returns the keys of the ones imported successfully.
+func importObjects(ctx context.Context, kibanaClient *kibana.Client, objects map[string]importedObject) ([]string, error) {
+	request := kibana.ImportSavedObjectsRequest{Overwrite: true}
+	for _, key := range slices.Sorted(maps.Keys(objects)) {
+		request.Objects = append(request.Objects, objects[key].object)
+	}
+	response, err := kibanaClient.ImportSavedObjects(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("importing saved objects failed: %w", err)
+	}
+
+	var imported []string
+	for _, result := range response.Results {
+		imported = append(imported, objectKey(result.Type, result.ID))
+	}
+	var multiErr multierror.Error
+	for _, result := range response.Errors {
+		multiErr = append(multiErr, fmt.Errorf("%s %s: %v", result.Type, result.ID, result.Error["type"]))
+	}
+	if len(multiErr) > 0 {
+		return imported, fmt.Errorf("some saved objects couldn't be imported: %w", multiErr.Unique())
+	}
+	return imported, nil
+}
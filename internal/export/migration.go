@@ -0,0 +1,189 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/elastic/elastic-package/internal/common"
+)
+
+var (
+	// Type migration versions since this one are model versions, and not Kibana versions.
+	// Model versions were introduced in Kibana 8.10.0.
+	firstModelVersion         = semver.MustParse("10.0.0")
+	firstKibanaModelVersioned = semver.MustParse("8.10.0")
+)
+
+// MigrationIssue describes an exported saved object that has been migrated to a version of Kibana
+// newer than the target one, so it may not be installed there.
+type MigrationIssue struct {
+	Type  string
+	ID    string
+	Title string
+
+	// RequiredVersion is the minimum version of Kibana that can install the object.
+	RequiredVersion *semver.Version
+
+	// ModelVersion is the model version of the object, when it determines the required version.
+	// Model versions cannot be mapped to Kibana versions, so RequiredVersion is then a lower bound,
+	// and the object may require a newer version of Kibana.
+	ModelVersion string
+
+	// Panels contains the descriptions of the panels of dashboards created with Kibana versions newer than
+	// the target one.
+	Panels []string
+}
+
+func (i MigrationIssue) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s", i.Type, i.ID)
+	if i.Title != "" {
+		fmt.Fprintf(&sb, " (%s)", i.Title)
+	}
+	fmt.Fprintf(&sb, " requires Kibana %s", i.RequiredVersion)
+	if i.LowerBound() {
+		fmt.Fprintf(&sb, " or newer (model version %s)", i.ModelVersion)
+	}
+	if len(i.Panels) > 0 {
+		fmt.Fprintf(&sb, ", panels to re-author: %s", strings.Join(i.Panels, ", "))
+	}
+	return sb.String()
+}
+
+// LowerBound returns true if the object may require a version of Kibana newer than RequiredVersion.
+func (i MigrationIssue) LowerBound() bool {
+	return i.ModelVersion != ""
+}
+
+// Incompatible returns true if the object cannot be installed in the target version of Kibana. Otherwise
+// its compatibility cannot be verified.
+func (i MigrationIssue) Incompatible(target *semver.Version) bool {
+	return i.RequiredVersion.GreaterThan(target)
+}
+
+// checkMigrationVersions returns the objects whose migration versions are newer than the target Kibana version,
+// and the objects using model versions, whose compatibility cannot be verified. Objects are expected to be decoded.
+func checkMigrationVersions(objects []common.MapStr, target *semver.Version) []MigrationIssue {
+	var issues []MigrationIssue
+	for _, object := range objects {
+		required, modelVersion := requiredKibanaVersion(object)
+		if required == nil || (modelVersion == "" && !required.GreaterThan(target)) {
+			continue
+		}
+
+		aType, _ := object["type"].(string)
+		id, _ := object["id"].(string)
+		title, _ := object.GetValue("attributes.title")
+		titleStr, _ := title.(string)
+		issues = append(issues, MigrationIssue{
+			Type:            aType,
+			ID:              id,
+			Title:           titleStr,
+			RequiredVersion: required,
+			ModelVersion:    modelVersion,
+			Panels:          newerPanels(object, target),
+		})
+	}
+	return issues
+}
+
+// requiredKibanaVersion returns the minimum version of Kibana that supports the migration versions of the object.
+// If this version is determined by a model version, it is also returned, as the required version is only a lower
+// bound then.
+func requiredKibanaVersion(object common.MapStr) (*semver.Version, string) {
+	var versions []string
+	if v, ok := object["coreMigrationVersion"].(string); ok {
+		versions = append(versions, v)
+	}
+	if v, ok := object["typeMigrationVersion"].(string); ok {
+		versions = append(versions, v)
+	}
+	// Objects exported before Kibana 8.8 contain the migration versions of each type.
+	if migrationVersion, ok := object["migrationVersion"].(map[string]any); ok {
+		for _, v := range migrationVersion {
+			if v, ok := v.(string); ok {
+				versions = append(versions, v)
+			}
+		}
+	}
+
+	var required *semver.Version
+	var modelVersion string
+	for _, v := range versions {
+		version, err := semver.NewVersion(v)
+		if err != nil {
+			continue
+		}
+		isModelVersion := !version.LessThan(firstModelVersion)
+		if isModelVersion {
+			version = firstKibanaModelVersioned
+		}
+		switch {
+		case required == nil || version.GreaterThan(required):
+			required = version
+			modelVersion = ""
+			if isModelVersion {
+				modelVersion = v
+			}
+		case isModelVersion && version.Equal(required):
+			modelVersion = v
+		}
+	}
+	return required, modelVersion
+}
+
+// newerPanels returns the descriptions of the panels of a dashboard that were created with a version of
+// Kibana newer than the target one.
+func newerPanels(object common.MapStr, target *semver.Version) []string {
+	panelsValue, err := object.GetValue(panelsAttribute)
+	if err != nil {
+		return nil
+	}
+	var panels []map[string]any
+	switch value := panelsValue.(type) {
+	case []map[string]any:
+		panels = value
+	case []any:
+		for _, panel := range value {
+			if panel, ok := panel.(map[string]any); ok {
+				panels = append(panels, panel)
+			}
+		}
+	}
+
+	var descriptions []string
+	for _, panel := range panels {
+		v, _ := panel["version"].(string)
+		version, err := semver.NewVersion(v)
+		if err != nil || !version.GreaterThan(target) {
+			continue
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%q (Kibana %s)", panelTitle(panel), version))
+	}
+	slices.Sort(descriptions)
+	return descriptions
+}
+
+func panelTitle(panel map[string]any) string {
+	embeddableConfig, _ := panel[embeddableConfigAttribute].(map[string]any)
+	if title, ok := embeddableConfig["title"].(string); ok && title != "" {
+		return title
+	}
+	if attributes, ok := embeddableConfig["attributes"].(map[string]any); ok {
+		if title, ok := attributes["title"].(string); ok && title != "" {
+			return title
+		}
+	}
+	if title, ok := panel["title"].(string); ok && title != "" {
+		return title
+	}
+	panelIndex, _ := panel["panelIndex"].(string)
+	return panelIndex
+}
@@ -0,0 +1,120 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+func migrationTestObjects() []common.MapStr {
+	return []common.MapStr{
+		{
+			"id":                   "example-dashboard",
+			"type":                 "dashboard",
+			"coreMigrationVersion": "8.8.0",
+			"typeMigrationVersion": "10.2.0",
+			"attributes": map[string]any{
+				"title": "Overview",
+				"panelsJSON": []any{
+					map[string]any{"panelIndex": "1", "version": "7.14.0", "embeddableConfig": map[string]any{}},
+					map[string]any{"panelIndex": "2", "version": "8.11.0", "embeddableConfig": map[string]any{"title": "Requests"}},
+					map[string]any{"panelIndex": "3", "version": "8.9.0", "embeddableConfig": map[string]any{}},
+				},
+			},
+		},
+		{
+			"id":               "example-visualization",
+			"type":             "visualization",
+			"migrationVersion": map[string]any{"visualization": "7.14.0"},
+			"attributes":       map[string]any{"title": "Status codes"},
+		},
+	}
+}
+
+func TestCheckMigrationVersions(t *testing.T) {
+	issues := checkMigrationVersions(migrationTestObjects(), semver.MustParse("8.0.0"))
+	require.Len(t, issues, 1)
+	assert.Equal(t, "dashboard", issues[0].Type)
+	assert.Equal(t, "8.10.0", issues[0].RequiredVersion.String())
+	assert.Equal(t, []string{`"3" (Kibana 8.9.0)`, `"Requests" (Kibana 8.11.0)`}, issues[0].Panels)
+	assert.True(t, issues[0].LowerBound())
+	assert.True(t, issues[0].Incompatible(semver.MustParse("8.0.0")))
+	assert.Equal(t, `dashboard example-dashboard (Overview) requires Kibana 8.10.0 or newer (model version 10.2.0), panels to re-author: "3" (Kibana 8.9.0), "Requests" (Kibana 8.11.0)`, issues[0].String())
+
+	issues = checkMigrationVersions(migrationTestObjects(), semver.MustParse("7.10.0"))
+	require.Len(t, issues, 2)
+	assert.Equal(t, "7.14.0", issues[1].RequiredVersion.String())
+	assert.Empty(t, issues[1].Panels)
+
+	// Objects using model versions are reported, as they may require newer versions of Kibana.
+	issues = checkMigrationVersions(migrationTestObjects(), semver.MustParse("8.12.0"))
+	require.Len(t, issues, 1)
+	assert.Equal(t, "dashboard", issues[0].Type)
+	assert.True(t, issues[0].LowerBound())
+	assert.False(t, issues[0].Incompatible(semver.MustParse("8.12.0")))
+	assert.Empty(t, issues[0].Panels)
+}
+
+func TestRequiredKibanaVersion(t *testing.T) {
+	cases := []struct {
+		title        string
+		object       common.MapStr
+		required     string
+		modelVersion string
+	}{
+		{
+			title:    "migration versions",
+			object:   common.MapStr{"migrationVersion": map[string]any{"visualization": "7.14.0", "search": "7.9.3"}},
+			required: "7.14.0",
+		},
+		{
+			title:        "model version",
+			object:       common.MapStr{"coreMigrationVersion": "8.8.0", "typeMigrationVersion": "10.2.0"},
+			required:     "8.10.0",
+			modelVersion: "10.2.0",
+		},
+		{
+			title:    "core migration version newer than model versions",
+			object:   common.MapStr{"coreMigrationVersion": "8.12.0", "typeMigrationVersion": "10.2.0"},
+			required: "8.12.0",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			required, modelVersion := requiredKibanaVersion(c.object)
+			require.NotNil(t, required)
+			assert.Equal(t, c.required, required.String())
+			assert.Equal(t, c.modelVersion, modelVersion)
+		})
+	}
+}
+
+func TestCheckObjectsCompatibility(t *testing.T) {
+	manifest := packages.PackageManifest{
+		Conditions: packages.Conditions{
+			Kibana: packages.KibanaConditions{Version: "^8.0.0"},
+		},
+	}
+
+	// Only warnings are reported when checking against the package constraints.
+	err := checkObjectsCompatibility(manifest, migrationTestObjects(), nil)
+	assert.NoError(t, err)
+
+	// Objects using model versions are only reported as warnings if the lower bound is compatible.
+	err = checkObjectsCompatibility(manifest, migrationTestObjects(), semver.MustParse("8.10.0"))
+	assert.NoError(t, err)
+
+	err = checkObjectsCompatibility(manifest, migrationTestObjects(), semver.MustParse("8.5.0"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 saved objects are not compatible with Kibana 8.5.0")
+}
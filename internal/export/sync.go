@@ -0,0 +1,156 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/elastic/elastic-package/internal/builder"
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+const syncStateFolder = "saved-objects"
+
+// Conflict describes a saved object whose copies in the package and in Kibana have diverged
+// since the last export or import.
+type Conflict struct {
+	Type string
+	ID   string
+
+	// Path is the path of the saved object in the package.
+	Path string
+
+	// Differences are the changes that would be applied when overwriting the object.
+	Differences []string
+}
+
+// ConflictResolver is called before overwriting saved objects modified since the last export
+// or import. The object is overwritten only if it returns true.
+type ConflictResolver func(conflict Conflict) (bool, error)
+
+// syncState keeps the hashes of the contents of the saved objects as they were in the last
+// export or import, by object key.
+type syncState struct {
+	path    string
+	Objects map[string]string `json:"objects"`
+}
+
+func syncStatePath(packageName string) (string, error) {
+	buildDir, err := builder.BuildDirectory()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(buildDir, syncStateFolder, packageName+".json"), nil
+}
+
+// readSyncState reads the state of the last export or import of the package. An empty state
+// is returned if there is no previous state.
+func readSyncState(packageName string) (*syncState, error) {
+	statePath, err := syncStatePath(packageName)
+	if err != nil {
+		return nil, fmt.Errorf("can't locate saved objects state: %w", err)
+	}
+	state := syncState{path: statePath, Objects: make(map[string]string)}
+	content, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return &state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading saved objects state failed: %w", err)
+	}
+	err = json.Unmarshal(content, &state)
+	if err != nil {
+		logger.Debugf("Ignoring invalid saved objects state (path: %s): %v", statePath, err)
+		state.Objects = make(map[string]string)
+	}
+	return &state, nil
+}
+
+func (s *syncState) write() error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling saved objects state failed: %w", err)
+	}
+	err = os.MkdirAll(filepath.Dir(s.path), 0755)
+	if err != nil {
+		return fmt.Errorf("creating saved objects state directory failed: %w", err)
+	}
+	err = os.WriteFile(s.path, content, 0644)
+	if err != nil {
+		return fmt.Errorf("writing saved objects state failed: %w", err)
+	}
+	return nil
+}
+
+func objectKey(aType, id string) string {
+	return aType + "/" + id
+}
+
+// semanticContent returns the parts of the saved object relevant when comparing two copies
+// of it: its decoded attributes and its references, in a stable order.
+func semanticContent(object common.MapStr) (map[string]any, error) {
+	copied, err := roundTrip(object)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := decodeObject(nil, common.MapStr(copied.(map[string]any)))
+	if err != nil {
+		return nil, err
+	}
+
+	references, _ := decoded["references"].([]any)
+	references = slices.Clone(references)
+	referenceKey := func(reference any, key string) string {
+		m, _ := reference.(map[string]any)
+		s, _ := m[key].(string)
+		return s
+	}
+	slices.SortStableFunc(references, func(a, b any) int {
+		return cmp.Or(
+			cmp.Compare(referenceKey(a, "name"), referenceKey(b, "name")),
+			cmp.Compare(referenceKey(a, "type"), referenceKey(b, "type")),
+			cmp.Compare(referenceKey(a, "id"), referenceKey(b, "id")),
+		)
+	})
+
+	content, err := roundTrip(map[string]any{
+		"attributes": decoded["attributes"],
+		"references": references,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return content.(map[string]any), nil
+}
+
+// roundTrip returns a copy of the value as decoded from its JSON representation.
+func roundTrip(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling saved object failed: %w", err)
+	}
+	var copied any
+	err = json.Unmarshal(b, &copied)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling saved object failed: %w", err)
+	}
+	return copied, nil
+}
+
+func contentHash(content map[string]any) string {
+	// Keys of maps are sorted on encoding, so the result is stable.
+	b, _ := json.Marshal(content)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
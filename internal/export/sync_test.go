@@ -0,0 +1,112 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+)
+
+func readTestObject(t *testing.T, path string) common.MapStr {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var object common.MapStr
+	err = json.Unmarshal(b, &object)
+	require.NoError(t, err)
+	return object
+}
+
+func TestEncodeObject(t *testing.T) {
+	ctx := &transformationContext{packageName: "system"}
+
+	kibanaContent, err := normalizeKibanaObject(ctx, readTestObject(t, "./testdata/system-navigation.json"))
+	require.NoError(t, err)
+
+	exported, err := applyTransformations(ctx, []common.MapStr{readTestObject(t, "./testdata/system-navigation.json")})
+	require.NoError(t, err)
+	require.Len(t, exported, 1)
+
+	localContent, err := semanticContent(exported[0])
+	require.NoError(t, err)
+	assert.Equal(t, kibanaContent, localContent)
+
+	encoded, err := encodeObject(ctx, exported[0])
+	require.NoError(t, err)
+	for _, field := range encodedFields {
+		value, err := encoded.GetValue(field)
+		if err == common.ErrKeyNotFound {
+			continue
+		}
+		require.NoError(t, err)
+		assert.IsType(t, "", value, field)
+	}
+
+	encodedContent, err := semanticContent(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, localContent, encodedContent)
+}
+
+func TestConfirmLocalOverwrite(t *testing.T) {
+	objectPath := filepath.Join(t.TempDir(), "dashboard.json")
+	writeObject := func(title string) map[string]any {
+		object := common.MapStr{
+			"id":         "example-dashboard",
+			"type":       "dashboard",
+			"attributes": map[string]any{"title": title},
+			"references": []any{},
+		}
+		b, err := json.Marshal(object)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(objectPath, b, 0644))
+
+		content, err := semanticContent(object)
+		require.NoError(t, err)
+		return content
+	}
+
+	exported := writeObject("Exported")
+	kibana, err := semanticContent(common.MapStr{
+		"attributes": map[string]any{"title": "Edited in Kibana"},
+		"references": []any{},
+	})
+	require.NoError(t, err)
+
+	var conflicts []Conflict
+	resolve := func(conflict Conflict) (bool, error) {
+		conflicts = append(conflicts, conflict)
+		return false, nil
+	}
+	state := &syncState{Objects: map[string]string{
+		objectKey("dashboard", "example-dashboard"): contentHash(exported),
+	}}
+
+	// Local file not modified since the last export.
+	overwrite, err := confirmLocalOverwrite(objectPath, "dashboard", "example-dashboard", kibana, state, resolve)
+	require.NoError(t, err)
+	assert.True(t, overwrite)
+	assert.Empty(t, conflicts)
+
+	// Local file modified since the last export.
+	writeObject("Edited locally")
+	overwrite, err = confirmLocalOverwrite(objectPath, "dashboard", "example-dashboard", kibana, state, resolve)
+	require.NoError(t, err)
+	assert.False(t, overwrite)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, []string{`~ attributes.title: "Edited locally" => "Edited in Kibana"`}, conflicts[0].Differences)
+
+	// Without resolver, files are always overwritten.
+	overwrite, err = confirmLocalOverwrite(objectPath, "dashboard", "example-dashboard", kibana, state, nil)
+	require.NoError(t, err)
+	assert.True(t, overwrite)
+}
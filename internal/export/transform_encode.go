@@ -0,0 +1,76 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/elastic/elastic-package/internal/common"
+)
+
+// encodeObject encodes the fields of the saved object that Kibana stores as encoded JSON.
+// It reverts the changes done by decodeObject, so objects stored in packages can be imported
+// into Kibana.
+func encodeObject(ctx *transformationContext, object common.MapStr) (common.MapStr, error) {
+	err := encodeEmbeddedPanels(ctx, object)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fieldToEncode := range encodedFields {
+		v, err := object.GetValue(fieldToEncode)
+		if err == common.ErrKeyNotFound {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("retrieving value failed (key: %s): %w", fieldToEncode, err)
+		}
+		if _, ok := v.(string); ok {
+			// Already encoded.
+			continue
+		}
+
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("can't marshal field (key: %s): %w", fieldToEncode, err)
+		}
+		_, err = object.Put(fieldToEncode, string(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("can't update field (key: %s): %w", fieldToEncode, err)
+		}
+	}
+	return object, nil
+}
+
+func encodeEmbeddedPanels(ctx *transformationContext, object common.MapStr) error {
+	embeddedPanelsValue, err := object.GetValue(panelsAttribute)
+	if err == common.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("retrieving embedded panels failed: %w", err)
+	}
+	embeddedPanels, ok := embeddedPanelsValue.([]any)
+	if !ok {
+		// Panels already encoded.
+		return nil
+	}
+	for _, panelValue := range embeddedPanels {
+		panel, ok := panelValue.(map[string]any)
+		if !ok {
+			return fmt.Errorf("expected panel in map format, found %T", panelValue)
+		}
+		embeddableConfig, ok := panel[embeddableConfigAttribute].(map[string]any)
+		if !ok {
+			continue
+		}
+		encoded, err := encodeObject(ctx, common.MapStr(embeddableConfig))
+		if err != nil {
+			return fmt.Errorf("encoding embedded object failed: %w", err)
+		}
+		panel[embeddableConfigAttribute] = map[string]any(encoded)
+	}
+	return nil
+}
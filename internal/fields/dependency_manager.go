@@ -7,16 +7,12 @@ package fields
 import (
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/elastic/elastic-package/internal/common"
-	"github.com/elastic/elastic-package/internal/configuration/locations"
 	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
 )
@@ -81,50 +77,7 @@ func readECSFieldsSchemaFile(dep buildmanifest.ECSDependency) ([]byte, error) {
 		return nil, fmt.Errorf("can't process the value as Git reference: %w", err)
 	}
 
-	loc, err := locations.NewLocationManager()
-	if err != nil {
-		return nil, fmt.Errorf("error fetching profile path: %w", err)
-	}
-	cachedSchemaPath := filepath.Join(loc.CacheDir(locations.FieldsCacheName), ecsSchemaName, gitReference, ecsSchemaFile)
-	content, err := os.ReadFile(cachedSchemaPath)
-	if errors.Is(err, os.ErrNotExist) {
-		logger.Debugf("Pulling ECS dependency using reference: %s", dep.Reference)
-
-		url := fmt.Sprintf(ecsSchemaURL, gitReference, ecsSchemaFile)
-		logger.Debugf("Schema URL: %s", url)
-		resp, err := http.Get(url)
-		if err != nil {
-			return nil, fmt.Errorf("can't download the online schema (URL: %s): %w", url, err)
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode == http.StatusNotFound {
-			return nil, fmt.Errorf("unsatisfied ECS dependency, reference defined in build manifest doesn't exist (HTTP StatusNotFound, URL: %s)", url)
-		} else if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected HTTP status code: %d", resp.StatusCode)
-		}
-
-		content, err = io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("can't read schema content (URL: %s): %w", url, err)
-		}
-		logger.Debugf("Downloaded %d bytes", len(content))
-
-		cachedSchemaDir := filepath.Dir(cachedSchemaPath)
-		err = os.MkdirAll(cachedSchemaDir, 0755)
-		if err != nil {
-			return nil, fmt.Errorf("can't create cache directories for schema (path: %s): %w", cachedSchemaDir, err)
-		}
-
-		logger.Debugf("Cache downloaded schema: %s", cachedSchemaPath)
-		err = os.WriteFile(cachedSchemaPath, content, 0644)
-		if err != nil {
-			return nil, fmt.Errorf("can't write cached schema (path: %s): %w", cachedSchemaPath, err)
-		}
-	} else if err != nil {
-		return nil, fmt.Errorf("can't read cached schema (path: %s): %w", cachedSchemaPath, err)
-	}
-
-	return content, nil
+	return readCachedECSSchema(dep.Reference, gitReference)
 }
 
 func parseECSFieldsSchema(content []byte) ([]FieldDefinition, error) {
@@ -0,0 +1,240 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// FieldChangeType is the kind of change of a field between two versions of a package.
+type FieldChangeType string
+
+const (
+	FieldAdded           FieldChangeType = "added"
+	FieldRemoved         FieldChangeType = "removed"
+	FieldTypeChanged     FieldChangeType = "type changed"
+	FieldMetadataChanged FieldChangeType = "metadata changed"
+)
+
+// FieldChange is a change in the definition of a field.
+type FieldChange struct {
+	// DataStream is the name of the data stream, empty for fields defined at the package level.
+	DataStream string
+	Field      string
+	Change     FieldChangeType
+
+	// Details describe the change, as the previous and new types.
+	Details string
+
+	// Breaking is set for changes that can break mappings or assets using the field.
+	Breaking bool
+}
+
+// DiffPackageFields compares the field definitions of two versions of a package, resolving
+// external fields when dependencies are defined.
+func DiffPackageFields(basePackageRoot, packageRoot string) ([]FieldChange, error) {
+	baseFields, err := loadPackageFieldsByDataStream(basePackageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("loading fields of base package failed: %w", err)
+	}
+	currentFields, err := loadPackageFieldsByDataStream(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("loading fields of package failed: %w", err)
+	}
+	dashboardsContent, err := readKibanaAssetsContent(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading Kibana assets failed: %w", err)
+	}
+
+	var dataStreams []string
+	for dataStream := range baseFields {
+		dataStreams = append(dataStreams, dataStream)
+	}
+	for dataStream := range currentFields {
+		if _, found := baseFields[dataStream]; !found {
+			dataStreams = append(dataStreams, dataStream)
+		}
+	}
+	sort.Strings(dataStreams)
+
+	var changes []FieldChange
+	for _, dataStream := range dataStreams {
+		changes = append(changes, diffFields(dataStream, baseFields[dataStream], currentFields[dataStream], dashboardsContent)...)
+	}
+	return changes, nil
+}
+
+func diffFields(dataStream string, base, current map[string]FieldDefinition, dashboardsContent []string) []FieldChange {
+	var names []string
+	for name := range base {
+		names = append(names, name)
+	}
+	for name := range current {
+		if _, found := base[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []FieldChange
+	for _, name := range names {
+		before, inBase := base[name]
+		after, inCurrent := current[name]
+		change := FieldChange{DataStream: dataStream, Field: name}
+		switch {
+		case !inBase:
+			change.Change = FieldAdded
+			change.Details = after.Type
+		case !inCurrent:
+			change.Change = FieldRemoved
+			change.Details = before.Type
+			if isFieldUsedInAssets(name, dashboardsContent) {
+				change.Breaking = true
+				change.Details = fmt.Sprintf("%s, used in Kibana assets", before.Type)
+			}
+		case before.Type != after.Type:
+			change.Change = FieldTypeChanged
+			change.Details = fmt.Sprintf("%s -> %s", before.Type, after.Type)
+			change.Breaking = true
+		default:
+			metadata := changedMetadata(before, after)
+			if len(metadata) == 0 {
+				continue
+			}
+			change.Change = FieldMetadataChanged
+			change.Details = strings.Join(metadata, ", ")
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func changedMetadata(before, after FieldDefinition) []string {
+	var changed []string
+	compare := func(name string, a, b any) {
+		if fmt.Sprint(a) != fmt.Sprint(b) {
+			changed = append(changed, name)
+		}
+	}
+	compare("description", strings.TrimSpace(before.Description), strings.TrimSpace(after.Description))
+	compare("unit", before.Unit, after.Unit)
+	compare("metric_type", before.MetricType, after.MetricType)
+	compare("dimension", before.Dimension, after.Dimension)
+	compare("object_type", before.ObjectType, after.ObjectType)
+	compare("value", before.Value, after.Value)
+	compare("pattern", before.Pattern, after.Pattern)
+	compare("index", boolPointerString(before.Index), boolPointerString(after.Index))
+	compare("doc_values", boolPointerString(before.DocValues), boolPointerString(after.DocValues))
+	compare("enabled", boolPointerString(before.Enabled), boolPointerString(after.Enabled))
+	compare("normalize", before.Normalize, after.Normalize)
+	compare("multi_fields", multiFieldsString(before.MultiFields), multiFieldsString(after.MultiFields))
+	return changed
+}
+
+func boolPointerString(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return fmt.Sprint(*b)
+}
+
+func multiFieldsString(fields []FieldDefinition) string {
+	var multiFields []string
+	for _, f := range fields {
+		multiFields = append(multiFields, f.Name+":"+f.Type)
+	}
+	slices.Sort(multiFields)
+	return strings.Join(multiFields, ",")
+}
+
+// loadPackageFieldsByDataStream loads the flattened field definitions of each data stream.
+// Fields defined at the package level, as in input packages, are stored with an empty name.
+func loadPackageFieldsByDataStream(packageRoot string) (map[string]map[string]FieldDefinition, error) {
+	fdm, err := createDependencyManagerForPackage(packageRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldsParentDirs := map[string]string{"": packageRoot}
+	manifests, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("listing data streams failed: %w", err)
+	}
+	for _, manifest := range manifests {
+		dataStreamRoot := filepath.Dir(manifest)
+		fieldsParentDirs[filepath.Base(dataStreamRoot)] = dataStreamRoot
+	}
+
+	result := make(map[string]map[string]FieldDefinition)
+	for dataStream, fieldsParentDir := range fieldsParentDirs {
+		fieldsDir := filepath.Join(fieldsParentDir, "fields")
+		definitions, err := loadFieldsFromDir(fieldsDir, fdm, InjectFieldsOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("can't load fields from directory (path: %s): %w", fieldsDir, err)
+		}
+		if len(definitions) == 0 && dataStream == "" {
+			continue
+		}
+		flat := make(map[string]FieldDefinition)
+		flattenFieldDefinitions("", definitions, flat)
+		result[dataStream] = flat
+	}
+	return result, nil
+}
+
+func flattenFieldDefinitions(root string, definitions []FieldDefinition, flat map[string]FieldDefinition) {
+	for _, definition := range definitions {
+		name := strings.TrimLeft(root+"."+definition.Name, ".")
+		if len(definition.Fields) > 0 || definition.Type == "group" {
+			flattenFieldDefinitions(name, definition.Fields, flat)
+			continue
+		}
+		flat[name] = definition
+	}
+}
+
+func readKibanaAssetsContent(packageRoot string) ([]string, error) {
+	var contents []string
+	kibanaDir := filepath.Join(packageRoot, "kibana")
+	err := filepath.WalkDir(kibanaDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		contents = append(contents, string(content))
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// isFieldUsedInAssets looks for references to the field in the assets, the name of the field
+// cannot be part of a longer name.
+func isFieldUsedInAssets(name string, contents []string) bool {
+	pattern := regexp.MustCompile(`(^|[^\w.@])` + regexp.QuoteMeta(name) + `($|[^\w.@])`)
+	for _, content := range contents {
+		if pattern.MatchString(content) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPackageFields(t *testing.T) {
+	basePackageRoot := t.TempDir()
+	writeFile(t, filepath.Join(basePackageRoot, "data_stream", "logs", "manifest.yml"), "title: Logs\ntype: logs\n")
+	writeFile(t, filepath.Join(basePackageRoot, "data_stream", "logs", "fields", "fields.yml"), `- name: foo
+  type: group
+  fields:
+    - name: message
+      type: keyword
+      description: Message.
+    - name: count
+      type: long
+    - name: used
+      type: keyword
+    - name: unused
+      type: keyword
+    - name: same
+      type: keyword
+`)
+	writeFile(t, filepath.Join(basePackageRoot, "data_stream", "old", "manifest.yml"), "title: Old\ntype: logs\n")
+	writeFile(t, filepath.Join(basePackageRoot, "data_stream", "old", "fields", "fields.yml"), `- name: old.field
+  type: keyword
+`)
+
+	packageRoot := t.TempDir()
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "logs", "manifest.yml"), "title: Logs\ntype: logs\n")
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "logs", "fields", "fields.yml"), `- name: foo.message
+  type: keyword
+  description: Original message.
+- name: foo.count
+  type: double
+- name: foo.same
+  type: keyword
+- name: foo.added
+  type: ip
+`)
+	writeFile(t, filepath.Join(packageRoot, "kibana", "dashboard", "dashboard.json"), `{"attributes": {"kibanaSavedObjectMeta": {"searchSourceJSON": "{\"query\":{\"query\":\"foo.used : \\\"value\\\"\"}}"}}}`)
+
+	changes, err := DiffPackageFields(basePackageRoot, packageRoot)
+	require.NoError(t, err)
+
+	expected := []FieldChange{
+		{DataStream: "logs", Field: "foo.added", Change: FieldAdded, Details: "ip"},
+		{DataStream: "logs", Field: "foo.count", Change: FieldTypeChanged, Details: "long -> double", Breaking: true},
+		{DataStream: "logs", Field: "foo.message", Change: FieldMetadataChanged, Details: "description"},
+		{DataStream: "logs", Field: "foo.unused", Change: FieldRemoved, Details: "keyword"},
+		{DataStream: "logs", Field: "foo.used", Change: FieldRemoved, Details: "keyword, used in Kibana assets", Breaking: true},
+		{DataStream: "old", Field: "old.field", Change: FieldRemoved, Details: "keyword"},
+	}
+	assert.Equal(t, expected, changes)
+}
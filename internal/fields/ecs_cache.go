@@ -0,0 +1,212 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/elastic/elastic-package/internal/configuration/locations"
+	"github.com/elastic/elastic-package/internal/environment"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// OfflineEnv is the environment variable used to enable the offline mode. In offline mode
+// schemas are only read from the cache, and missing schemas are reported as errors.
+var OfflineEnv = environment.WithElasticPackagePrefix("OFFLINE")
+
+// ErrECSSchemaNotCached is returned when an ECS schema is needed in offline mode, but it is
+// not available in the cache.
+var ErrECSSchemaNotCached = errors.New("ECS schema not cached")
+
+// CachedECSSchema is an ECS schema available in the cache.
+type CachedECSSchema struct {
+	Reference string
+	Path      string
+	Size      int64
+	ModTime   time.Time
+}
+
+// IsOfflineMode returns true if the offline mode is enabled.
+func IsOfflineMode() bool {
+	offline, _ := strconv.ParseBool(os.Getenv(OfflineEnv))
+	return offline
+}
+
+// FetchECSSchema downloads the ECS schema for the given reference and stores it in the cache,
+// replacing any previously cached copy. It returns the path of the cached schema.
+func FetchECSSchema(reference string) (string, error) {
+	gitReference, err := asGitReference(reference)
+	if err != nil {
+		return "", fmt.Errorf("can't process the value as Git reference: %w", err)
+	}
+	if IsOfflineMode() {
+		return "", fmt.Errorf("can't download ECS schema for reference %q, offline mode is enabled (%s)", reference, OfflineEnv)
+	}
+
+	content, err := downloadECSSchema(gitReference)
+	if err != nil {
+		return "", err
+	}
+	return storeECSSchema(gitReference, content)
+}
+
+// ImportECSSchema stores the ECS schema in the given file in the cache, so it is used for the
+// given reference. It returns the path of the cached schema.
+func ImportECSSchema(reference, schemaPath string) (string, error) {
+	gitReference, err := asGitReference(reference)
+	if err != nil {
+		return "", fmt.Errorf("can't process the value as Git reference: %w", err)
+	}
+
+	content, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return "", fmt.Errorf("can't read schema file (path: %s): %w", schemaPath, err)
+	}
+	fields, err := parseECSFieldsSchema(content)
+	if err != nil {
+		return "", fmt.Errorf("invalid ECS schema (path: %s): %w", schemaPath, err)
+	}
+	if len(fields) == 0 {
+		return "", fmt.Errorf("invalid ECS schema (path: %s): no fields found", schemaPath)
+	}
+	return storeECSSchema(gitReference, content)
+}
+
+// ListCachedECSSchemas returns the ECS schemas available in the cache, sorted by reference.
+func ListCachedECSSchemas() ([]CachedECSSchema, error) {
+	cacheDir, err := ecsSchemaCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(cacheDir, "*", ecsSchemaFile))
+	if err != nil {
+		return nil, fmt.Errorf("can't list cached schemas: %w", err)
+	}
+
+	var schemas []CachedECSSchema
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("can't read cached schema (path: %s): %w", path, err)
+		}
+		gitReference, err := url.PathUnescape(filepath.Base(filepath.Dir(path)))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected cached schema (path: %s): %w", path, err)
+		}
+		schemas = append(schemas, CachedECSSchema{
+			Reference: gitReferencePrefix + gitReference,
+			Path:      path,
+			Size:      info.Size(),
+			ModTime:   info.ModTime(),
+		})
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Reference < schemas[j].Reference
+	})
+	return schemas, nil
+}
+
+// readCachedECSSchema reads the ECS schema for the reference from the cache. If it is not cached,
+// it is downloaded, unless offline mode is enabled.
+func readCachedECSSchema(reference, gitReference string) ([]byte, error) {
+	cachedSchemaPath, err := ecsSchemaCachePath(gitReference)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(cachedSchemaPath)
+	if err == nil {
+		return content, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("can't read cached schema (path: %s): %w", cachedSchemaPath, err)
+	}
+
+	if IsOfflineMode() {
+		return nil, fmt.Errorf("%w: reference %q not found in cache and offline mode is enabled (%s), use \"elastic-package ecs fetch %s\" or \"elastic-package ecs import\" to add it",
+			ErrECSSchemaNotCached, reference, OfflineEnv, reference)
+	}
+
+	logger.Debugf("Pulling ECS dependency using reference: %s", reference)
+	content, err = downloadECSSchema(gitReference)
+	if err != nil {
+		return nil, err
+	}
+	_, err = storeECSSchema(gitReference, content)
+	if err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func ecsSchemaCacheDir() (string, error) {
+	loc, err := locations.NewLocationManager()
+	if err != nil {
+		return "", fmt.Errorf("error fetching profile path: %w", err)
+	}
+	return filepath.Join(loc.CacheDir(locations.FieldsCacheName), ecsSchemaName), nil
+}
+
+func ecsSchemaCachePath(gitReference string) (string, error) {
+	if gitReference == "" || gitReference == "." || gitReference == ".." {
+		return "", fmt.Errorf("invalid Git reference %q", gitReference)
+	}
+	cacheDir, err := ecsSchemaCacheDir()
+	if err != nil {
+		return "", err
+	}
+	// References can contain slashes (e.g. branch names), escape them so each reference
+	// is stored in a single directory of the cache.
+	return filepath.Join(cacheDir, url.PathEscape(gitReference), ecsSchemaFile), nil
+}
+
+func downloadECSSchema(gitReference string) ([]byte, error) {
+	url := fmt.Sprintf(ecsSchemaURL, gitReference, ecsSchemaFile)
+	logger.Debugf("Schema URL: %s", url)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("can't download the online schema (URL: %s): %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("unsatisfied ECS dependency, reference defined in build manifest doesn't exist (HTTP StatusNotFound, URL: %s)", url)
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status code: %d", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read schema content (URL: %s): %w", url, err)
+	}
+	logger.Debugf("Downloaded %d bytes", len(content))
+	return content, nil
+}
+
+func storeECSSchema(gitReference string, content []byte) (string, error) {
+	cachedSchemaPath, err := ecsSchemaCachePath(gitReference)
+	if err != nil {
+		return "", err
+	}
+	cachedSchemaDir := filepath.Dir(cachedSchemaPath)
+	err = os.MkdirAll(cachedSchemaDir, 0755)
+	if err != nil {
+		return "", fmt.Errorf("can't create cache directories for schema (path: %s): %w", cachedSchemaDir, err)
+	}
+
+	logger.Debugf("Cache schema: %s", cachedSchemaPath)
+	err = os.WriteFile(cachedSchemaPath, content, 0644)
+	if err != nil {
+		return "", fmt.Errorf("can't write cached schema (path: %s): %w", cachedSchemaPath, err)
+	}
+	return cachedSchemaPath, nil
+}
@@ -0,0 +1,75 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
+)
+
+func TestECSSchemaCacheOffline(t *testing.T) {
+	t.Setenv("ELASTIC_PACKAGE_DATA_HOME", t.TempDir())
+	t.Setenv(OfflineEnv, "true")
+
+	dep := buildmanifest.ECSDependency{Reference: "git@v8.10.0"}
+	_, err := loadECSFieldsSchema(dep)
+	require.ErrorIs(t, err, ErrECSSchemaNotCached)
+	assert.ErrorContains(t, err, `reference "git@v8.10.0" not found in cache`)
+
+	_, err = FetchECSSchema(dep.Reference)
+	assert.ErrorContains(t, err, "offline mode is enabled")
+
+	path, err := ImportECSSchema(dep.Reference, filepath.Join("testdata", "ecs_nested_v8.10.0.yml"))
+	require.NoError(t, err)
+
+	schemas, err := ListCachedECSSchemas()
+	require.NoError(t, err)
+	require.Len(t, schemas, 1)
+	assert.Equal(t, dep.Reference, schemas[0].Reference)
+	assert.Equal(t, path, schemas[0].Path)
+
+	fields, err := loadECSFieldsSchema(dep)
+	require.NoError(t, err)
+	assert.NotEmpty(t, fields)
+}
+
+func TestImportECSSchemaInvalid(t *testing.T) {
+	t.Setenv("ELASTIC_PACKAGE_DATA_HOME", t.TempDir())
+
+	schemaPath := filepath.Join(t.TempDir(), "ecs_nested.yml")
+	writeFile(t, schemaPath, "not: [a list")
+	_, err := ImportECSSchema("git@v8.10.0", schemaPath)
+	assert.Error(t, err)
+
+	_, err = ImportECSSchema("v8.10.0", filepath.Join("testdata", "ecs_nested_v8.10.0.yml"))
+	assert.Error(t, err)
+
+	schemas, err := ListCachedECSSchemas()
+	require.NoError(t, err)
+	assert.Empty(t, schemas)
+}
+
+func TestECSSchemaCacheReferenceWithSlashes(t *testing.T) {
+	t.Setenv("ELASTIC_PACKAGE_DATA_HOME", t.TempDir())
+
+	references := []string{"git@feature/x", "git@feature/y"}
+	for _, reference := range references {
+		_, err := ImportECSSchema(reference, filepath.Join("testdata", "ecs_nested_v8.10.0.yml"))
+		require.NoError(t, err)
+	}
+
+	schemas, err := ListCachedECSSchemas()
+	require.NoError(t, err)
+	require.Len(t, schemas, len(references))
+	for i, reference := range references {
+		assert.Equal(t, reference, schemas[i].Reference)
+		assert.Equal(t, ecsSchemaFile, filepath.Base(schemas[i].Path))
+	}
+}
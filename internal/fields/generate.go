@@ -0,0 +1,458 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages/buildmanifest"
+)
+
+const (
+	// DefaultGeneratedFieldsFile is the file where generated package-specific fields are written by default.
+	DefaultGeneratedFieldsFile = "fields.yml"
+
+	// ECSGeneratedFieldsFile is the file where generated references to ECS fields are written.
+	ECSGeneratedFieldsFile = "ecs.yml"
+)
+
+// Keys of objects are considered field names if they match this expression. Objects
+// with other keys are likely to contain dynamic data, so they are mapped as flattened.
+var fieldNameRegexp = regexp.MustCompile(`^[a-zA-Z_@][a-zA-Z0-9_\-@]*$`)
+
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// GeneratedField is a field definition inferred from observed documents.
+type GeneratedField struct {
+	Name     string
+	Type     string
+	External string
+}
+
+// GenerateOptions contains the settings used to generate field definitions.
+type GenerateOptions struct {
+	// PackageRoot is the root of the package, used to resolve external dependencies.
+	PackageRoot string
+
+	// FieldsParentDir is the directory containing the "fields" directory where
+	// definitions are written, it is the data stream directory in integration packages.
+	FieldsParentDir string
+}
+
+// GenerateFieldDefinitions infers definitions for the fields found in the given documents
+// that are not defined yet in the fields directory. Fields that can be resolved with the
+// external ECS schema are returned as references to it.
+func GenerateFieldDefinitions(options GenerateOptions, docs []common.MapStr) ([]GeneratedField, error) {
+	fdm, err := createDependencyManagerForPackage(options.PackageRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldsDir := filepath.Join(options.FieldsParentDir, "fields")
+	schema, err := loadFieldsFromDir(fieldsDir, fdm, InjectFieldsOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("can't load fields from directory (path: %s): %w", fieldsDir, err)
+	}
+
+	var generated []GeneratedField
+	for _, field := range InferFieldDefinitions(docs) {
+		switch {
+		case FindElementDefinition(field.Name, schema) != nil:
+			continue // already defined
+		case isFlattenedSubfield(field.Name, schema):
+			continue
+		case couldBeMultifield(field.Name, schema):
+			continue
+		case !isParentEnabled(field.Name, schema):
+			continue
+		}
+
+		if fdm != nil {
+			if _, err := fdm.importField(ecsSchemaName, field.Name); err == nil {
+				generated = append(generated, GeneratedField{Name: field.Name, External: ecsSchemaName})
+				continue
+			}
+		}
+
+		if skipValidationForField(field.Name) {
+			// These fields are usually defined by other components, as Elastic Agent.
+			logger.Debugf("Skipping definition for common field %q", field.Name)
+			continue
+		}
+		generated = append(generated, field)
+	}
+	return generated, nil
+}
+
+func createDependencyManagerForPackage(packageRoot string) (*DependencyManager, error) {
+	buildManifest, ok, err := buildmanifest.ReadBuildManifest(packageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("can't read build manifest: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	fdm, err := CreateFieldDependencyManager(buildManifest.Dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("can't create field dependency manager: %w", err)
+	}
+	return fdm, nil
+}
+
+// InferFieldDefinitions infers the type of the fields found in the given documents. When a field
+// is found with different types, the most generic one is used.
+func InferFieldDefinitions(docs []common.MapStr) []GeneratedField {
+	types := make(map[string]string)
+	for _, doc := range docs {
+		inferObjectTypes("", doc, types)
+	}
+
+	fields := make([]GeneratedField, 0, len(types))
+	for name, fieldType := range types {
+		fields = append(fields, GeneratedField{Name: name, Type: fieldType})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+	return fields
+}
+
+func inferObjectTypes(root string, object map[string]any, types map[string]string) {
+	for name, value := range object {
+		key := strings.TrimLeft(root+"."+name, ".")
+
+		if m, ok := toMap(value); ok {
+			switch {
+			case isGeoPoint(m):
+				types[key] = mergeFieldTypes(types[key], "geo_point")
+			case !hasFieldNameKeys(m):
+				types[key] = mergeFieldTypes(types[key], "flattened")
+			default:
+				inferObjectTypes(key, m, types)
+			}
+			continue
+		}
+
+		fieldType := inferValueType(value)
+		if fieldType == "" {
+			continue
+		}
+		types[key] = mergeFieldTypes(types[key], fieldType)
+	}
+}
+
+func inferValueType(value any) string {
+	switch value := value.(type) {
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "long"
+		}
+		return "double"
+	case float64:
+		if value == math.Trunc(value) {
+			return "long"
+		}
+		return "double"
+	case int, int64:
+		return "long"
+	case string:
+		return inferStringType(value)
+	case []any:
+		var fieldType string
+		for _, elem := range value {
+			if _, isMap := toMap(elem); isMap {
+				// Arrays of objects require explicit definitions that
+				// cannot be inferred, map them as a single field.
+				return "flattened"
+			}
+			fieldType = mergeFieldTypes(fieldType, inferValueType(elem))
+		}
+		return fieldType
+	}
+	return ""
+}
+
+func inferStringType(value string) string {
+	if net.ParseIP(value) != nil {
+		return "ip"
+	}
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return "date"
+		}
+	}
+	return "keyword"
+}
+
+// mergeFieldTypes returns the type that can hold values of both types.
+func mergeFieldTypes(current, found string) string {
+	switch {
+	case current == "":
+		return found
+	case found == "", current == found:
+		return current
+	case isNumericType(current) && isNumericType(found):
+		return "double"
+	case current == "flattened" || found == "flattened":
+		return "flattened"
+	}
+	return "keyword"
+}
+
+func isNumericType(fieldType string) bool {
+	return fieldType == "long" || fieldType == "double"
+}
+
+func isGeoPoint(m map[string]any) bool {
+	if len(m) != 2 {
+		return false
+	}
+
+	lat, latFound := m["lat"]
+	lon, lonFound := m["lon"]
+	if latFound && lonFound {
+		return isNumericType(inferValueType(lat)) && isNumericType(inferValueType(lon))
+	}
+
+	// GeoJSON point.
+	pointType, _ := m["type"].(string)
+	_, found := m["coordinates"]
+	return strings.EqualFold(pointType, "point") && found
+}
+
+func hasFieldNameKeys(m map[string]any) bool {
+	for key := range m {
+		for _, part := range strings.Split(key, ".") {
+			if !fieldNameRegexp.MatchString(part) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func toMap(value any) (map[string]any, bool) {
+	switch value := value.(type) {
+	case map[string]any:
+		return value, true
+	case common.MapStr:
+		return value, true
+	}
+	return nil, false
+}
+
+// ReadDocumentsFile reads documents from a JSON file. It supports files with a single document,
+// with an array of documents, or with documents under an "expected" key, as the ones used by
+// pipeline tests.
+func ReadDocumentsFile(path string) ([]common.MapStr, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading documents file failed: %w", err)
+	}
+
+	docs, err := decodeDocuments(content)
+	if err != nil {
+		return nil, fmt.Errorf("decoding documents from %s failed: %w", path, err)
+	}
+	return docs, nil
+}
+
+func decodeDocuments(content []byte) ([]common.MapStr, error) {
+	var raw any
+	err := DecodeJSONDocument(bytes.NewReader(content), &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if m, ok := raw.(map[string]any); ok {
+		expected, found := m["expected"]
+		if !found {
+			return []common.MapStr{m}, nil
+		}
+		raw = expected
+	}
+
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, errors.New("unexpected format, documents should be objects")
+	}
+	var docs []common.MapStr
+	for _, elem := range list {
+		m, ok := elem.(map[string]any)
+		if !ok {
+			// Pipeline tests can expect null documents when they are dropped.
+			continue
+		}
+		docs = append(docs, m)
+	}
+	return docs, nil
+}
+
+// DecodeJSONDocument decodes JSON keeping numbers as json.Number, so integer and floating
+// point values can be differentiated when inferring their types.
+func DecodeJSONDocument(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// WriteGeneratedFields writes the given field definitions in the fields directory. References to
+// external fields are written to the ECS fields file, other fields to the given file. Existing files
+// are patched, keeping their definitions and comments. It returns the paths of the modified files.
+func WriteGeneratedFields(fieldsParentDir, fileName string, generated []GeneratedField) ([]string, error) {
+	if fileName == "" {
+		fileName = DefaultGeneratedFieldsFile
+	}
+
+	byFile := make(map[string][]GeneratedField)
+	for _, field := range generated {
+		file := fileName
+		if field.External != "" {
+			file = ECSGeneratedFieldsFile
+		}
+		byFile[file] = append(byFile[file], field)
+	}
+
+	fieldsDir := filepath.Join(fieldsParentDir, "fields")
+	err := os.MkdirAll(fieldsDir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("can't create fields directory: %w", err)
+	}
+
+	var files []string
+	for file, fields := range byFile {
+		path := filepath.Join(fieldsDir, file)
+		err := patchFieldsFile(path, fields)
+		if err != nil {
+			return nil, fmt.Errorf("can't write fields file (path: %s): %w", path, err)
+		}
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func patchFieldsFile(path string, fields []GeneratedField) error {
+	var doc yaml.Node
+	content, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		err = yaml.Unmarshal(content, &doc)
+		if err != nil {
+			return fmt.Errorf("unmarshalling fields file failed: %w", err)
+		}
+	}
+
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.SequenceNode, Tag: "!!seq"}},
+		}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.SequenceNode {
+		return errors.New("fields file should contain a list of fields")
+	}
+	// Empty lists in existing files are in flow style.
+	root.Style = 0
+
+	for _, field := range fields {
+		insertFieldNode(root, field)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(&doc)
+	if err != nil {
+		return fmt.Errorf("marshalling fields file failed: %w", err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// insertFieldNode adds the field to the list of fields, inside the first group whose name
+// is a prefix of the field name.
+func insertFieldNode(list *yaml.Node, field GeneratedField) {
+	for _, item := range list.Content {
+		if item.Kind != yaml.MappingNode || mappingValue(item, "type") != "group" {
+			continue
+		}
+		name := mappingValue(item, "name")
+		if name == "" || !strings.HasPrefix(field.Name, name+".") {
+			continue
+		}
+
+		children := mappingNode(item, "fields")
+		if children == nil {
+			children = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			item.Content = append(item.Content, scalarNode("fields"), children)
+		}
+		if children.Kind != yaml.SequenceNode {
+			continue
+		}
+		children.Style = 0
+
+		field.Name = strings.TrimPrefix(field.Name, name+".")
+		insertFieldNode(children, field)
+		return
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	node.Content = append(node.Content, scalarNode("name"), scalarNode(field.Name))
+	if field.External != "" {
+		node.Content = append(node.Content, scalarNode("external"), scalarNode(field.External))
+	}
+	if field.Type != "" {
+		node.Content = append(node.Content, scalarNode("type"), scalarNode(field.Type))
+	}
+	list.Content = append(list.Content, node)
+}
+
+func mappingNode(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func mappingValue(node *yaml.Node, key string) string {
+	value := mappingNode(node, key)
+	if value == nil || value.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return value.Value
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
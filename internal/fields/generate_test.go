@@ -0,0 +1,147 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+)
+
+func TestInferFieldDefinitions(t *testing.T) {
+	docs, err := decodeDocuments([]byte(`{"expected": [
+		{
+			"@timestamp": "2024-01-02T03:04:05.123Z",
+			"source": {"ip": "10.0.0.1", "geo": {"location": {"lat": 40.1, "lon": -3.7}}},
+			"foo": {
+				"count": 3,
+				"ratio": 1,
+				"enabled": true,
+				"message": "hello",
+				"tags": ["a", "b"],
+				"headers": {"Content-Type": "text/plain", "X Custom": "1"},
+				"items": [{"id": 1}]
+			}
+		},
+		{
+			"foo": {"ratio": 0.5, "message": "10.0.0.1"}
+		},
+		null
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	expected := []GeneratedField{
+		{Name: "@timestamp", Type: "date"},
+		{Name: "foo.count", Type: "long"},
+		{Name: "foo.enabled", Type: "boolean"},
+		{Name: "foo.headers", Type: "flattened"},
+		{Name: "foo.items", Type: "flattened"},
+		{Name: "foo.message", Type: "keyword"},
+		{Name: "foo.ratio", Type: "double"},
+		{Name: "foo.tags", Type: "keyword"},
+		{Name: "source.geo.location", Type: "geo_point"},
+		{Name: "source.ip", Type: "ip"},
+	}
+	assert.Equal(t, expected, InferFieldDefinitions(docs))
+}
+
+func TestGenerateFieldDefinitions(t *testing.T) {
+	packageRoot := t.TempDir()
+	ecsSchemaPath, err := filepath.Abs("./testdata/ecs_nested_v8.10.0.yml")
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(packageRoot, "_dev", "build", "build.yml"), `dependencies:
+  ecs:
+    reference: file://`+ecsSchemaPath+`
+`)
+
+	dataStreamRoot := filepath.Join(packageRoot, "data_stream", "logs")
+	writeFile(t, filepath.Join(dataStreamRoot, "fields", "base-fields.yml"), `- name: '@timestamp'
+  type: date
+- name: foo.labels
+  type: flattened
+`)
+
+	docs := []common.MapStr{
+		{
+			"@timestamp": "2024-01-02T03:04:05.123Z",
+			"source":     map[string]any{"ip": "10.0.0.1"},
+			"agent":      map[string]any{"custom": "value"},
+			"foo": map[string]any{
+				"labels":  map[string]any{"a": "b"},
+				"message": "hello",
+			},
+		},
+	}
+
+	generated, err := GenerateFieldDefinitions(GenerateOptions{
+		PackageRoot:     packageRoot,
+		FieldsParentDir: dataStreamRoot,
+	}, docs)
+	require.NoError(t, err)
+
+	expected := []GeneratedField{
+		{Name: "foo.message", Type: "keyword"},
+		{Name: "source.ip", External: "ecs"},
+	}
+	assert.Equal(t, expected, generated)
+}
+
+func TestWriteGeneratedFields(t *testing.T) {
+	dataStreamRoot := t.TempDir()
+	writeFile(t, filepath.Join(dataStreamRoot, "fields", "fields.yml"), `# Fields of the foo data stream.
+- name: foo
+  type: group
+  fields:
+    - name: message
+      type: keyword # Original message.
+`)
+
+	files, err := WriteGeneratedFields(dataStreamRoot, "", []GeneratedField{
+		{Name: "foo.count", Type: "long"},
+		{Name: "bar", Type: "keyword"},
+		{Name: "source.ip", External: "ecs"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dataStreamRoot, "fields", "ecs.yml"),
+		filepath.Join(dataStreamRoot, "fields", "fields.yml"),
+	}, files)
+
+	assert.Equal(t, `# Fields of the foo data stream.
+- name: foo
+  type: group
+  fields:
+    - name: message
+      type: keyword # Original message.
+    - name: count
+      type: long
+- name: bar
+  type: keyword
+`, readFile(t, filepath.Join(dataStreamRoot, "fields", "fields.yml")))
+
+	assert.Equal(t, `- name: source.ip
+  external: ecs
+`, readFile(t, filepath.Join(dataStreamRoot, "fields", "ecs.yml")))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(strings.TrimLeft(content, "\n")), 0644))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
@@ -0,0 +1,262 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/elastic/package-spec/v3/code/go/pkg/specerrors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// Codes of the checks performed when linting field definitions. They can be used
+// in the exclude_checks list of the validation.yml file of the package to skip them.
+const (
+	CodeDuplicatedField          = "FLD00001"
+	CodeConflictingECSType       = "FLD00002"
+	CodeMissingDescription       = "FLD00003"
+	CodeMissingMetricSettings    = "FLD00004"
+	CodeKeywordWithSpecificName  = "FLD00005"
+	CodeObjectWithoutDefinitions = "FLD00006"
+)
+
+var metricFieldTypes = []string{
+	"long", "integer", "short", "byte", "double", "float", "half_float",
+	"scaled_float", "unsigned_long", "aggregate_metric_double", "histogram",
+}
+
+type lintedField struct {
+	path string
+	file string
+	def  FieldDefinition
+}
+
+type fieldsLinter struct {
+	packageRoot string
+	ecsSchema   []FieldDefinition
+}
+
+// LintPackageFields checks the field definitions of the package for common issues that are not
+// covered by the package specification.
+func LintPackageFields(packageRoot string) (specerrors.ValidationErrors, error) {
+	linter := fieldsLinter{packageRoot: packageRoot}
+
+	fdm, err := createDependencyManagerForPackage(packageRoot)
+	if err != nil {
+		return nil, err
+	}
+	if fdm != nil {
+		linter.ecsSchema, err = fdm.ImportAllFields(ecsSchemaName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var errs specerrors.ValidationErrors
+	if _, err := os.Stat(filepath.Join(packageRoot, "fields")); err == nil {
+		// Input packages define fields at the root level.
+		dirErrs, err := linter.lintFieldsDir(packageRoot, false)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, dirErrs...)
+	}
+
+	manifests, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("listing data streams failed: %w", err)
+	}
+	sort.Strings(manifests)
+	for _, manifestPath := range manifests {
+		manifest, err := packages.ReadDataStreamManifest(manifestPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading data stream manifest failed: %w", err)
+		}
+		tsdb := manifest.Elasticsearch != nil && manifest.Elasticsearch.IndexMode == "time_series"
+
+		dirErrs, err := linter.lintFieldsDir(filepath.Dir(manifestPath), tsdb)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, dirErrs...)
+	}
+	return errs, nil
+}
+
+func (l *fieldsLinter) lintFieldsDir(fieldsParentDir string, tsdb bool) (specerrors.ValidationErrors, error) {
+	files, err := filepath.Glob(filepath.Join(fieldsParentDir, "fields", "*.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("reading directory with fields failed: %w", err)
+	}
+
+	var fields []lintedField
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading fields file failed: %w", err)
+		}
+		var defs FieldDefinitions
+		err = yaml.Unmarshal(content, &defs)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling fields file failed (path: %s): %w", file, err)
+		}
+
+		relPath, err := filepath.Rel(l.packageRoot, file)
+		if err != nil {
+			relPath = file
+		}
+		fields = appendLintedFields(fields, "", filepath.ToSlash(relPath), defs)
+	}
+
+	errs := lintDuplicatedFields(fields)
+	for _, field := range fields {
+		for _, err := range []error{
+			l.checkECSType(field),
+			checkDescription(field),
+			checkMetricSettings(field, tsdb),
+			checkKeywordName(field),
+			checkObjectDefinitions(field),
+		} {
+			var verr specerrors.ValidationError
+			if errors.As(err, &verr) {
+				errs = append(errs, verr)
+			}
+		}
+	}
+	return errs, nil
+}
+
+func appendLintedFields(fields []lintedField, root, file string, defs []FieldDefinition) []lintedField {
+	for _, def := range defs {
+		path := strings.TrimLeft(root+"."+def.Name, ".")
+		fields = append(fields, lintedField{path: path, file: file, def: def})
+		fields = appendLintedFields(fields, path, file, def.Fields)
+	}
+	return fields
+}
+
+func lintedFieldError(field lintedField, code string, format string, a ...any) *specerrors.StructuredError {
+	message := fmt.Sprintf(format, a...)
+	return specerrors.NewStructuredError(fmt.Errorf("file %q is invalid: field %q %s", field.file, field.path, message), code)
+}
+
+func isGroup(def FieldDefinition) bool {
+	return def.Type == "group" || (def.Type == "" && len(def.Fields) > 0)
+}
+
+func lintDuplicatedFields(fields []lintedField) specerrors.ValidationErrors {
+	defined := make(map[string][]lintedField)
+	var paths []string
+	for _, field := range fields {
+		if isGroup(field.def) {
+			// Groups can be declared in multiple files to define different children.
+			continue
+		}
+		if _, found := defined[field.path]; !found {
+			paths = append(paths, field.path)
+		}
+		defined[field.path] = append(defined[field.path], field)
+	}
+
+	var errs specerrors.ValidationErrors
+	for _, path := range paths {
+		duplicated := defined[path]
+		if len(duplicated) < 2 {
+			continue
+		}
+		var files []string
+		for _, field := range duplicated[1:] {
+			files = append(files, field.file)
+		}
+		errs = append(errs, lintedFieldError(duplicated[0], CodeDuplicatedField, "is defined multiple times (also in %s)", strings.Join(files, ", ")))
+	}
+	return errs
+}
+
+func (l *fieldsLinter) checkECSType(field lintedField) error {
+	if field.def.External != "" || field.def.Type == "" || isGroup(field.def) {
+		return nil
+	}
+	ecsField := FindElementDefinition(field.path, l.ecsSchema)
+	if ecsField == nil || ecsField.Type == field.def.Type || allowedTypeOverride(ecsField.Type, field.def.Type) {
+		return nil
+	}
+	return lintedFieldError(field, CodeConflictingECSType, "has type %q, but it is defined in ECS with type %q", field.def.Type, ecsField.Type)
+}
+
+func checkDescription(field lintedField) error {
+	if field.def.External != "" || isGroup(field.def) || strings.TrimSpace(field.def.Description) != "" {
+		return nil
+	}
+	return lintedFieldError(field, CodeMissingDescription, "has no description")
+}
+
+func checkMetricSettings(field lintedField, tsdb bool) error {
+	if !tsdb || field.def.External != "" || field.def.Dimension {
+		return nil
+	}
+	if !stringInArray(field.def.Type, metricFieldTypes) {
+		return nil
+	}
+
+	var missing []string
+	if field.def.MetricType == "" {
+		missing = append(missing, "metric_type")
+	}
+	if field.def.Unit == "" {
+		missing = append(missing, "unit")
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return lintedFieldError(field, CodeMissingMetricSettings, "is a metric in a time series data stream, but it has no %s", strings.Join(missing, " or "))
+}
+
+func checkKeywordName(field lintedField) error {
+	if field.def.Type != "keyword" || field.def.External != "" {
+		return nil
+	}
+
+	name := field.path
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.ToLower(name)
+
+	var expectedType string
+	switch {
+	case name == "ip", strings.HasSuffix(name, "_ip"), name == "ip_address", strings.HasSuffix(name, "_ip_address"):
+		expectedType = "ip"
+	case name == "timestamp", strings.HasSuffix(name, "_timestamp"), name == "date", strings.HasSuffix(name, "_date"), strings.HasSuffix(name, "_at"):
+		expectedType = "date"
+	default:
+		return nil
+	}
+	return lintedFieldError(field, CodeKeywordWithSpecificName, "is defined as keyword, but its name suggests it should be of type %q", expectedType)
+}
+
+func checkObjectDefinitions(field lintedField) error {
+	def := field.def
+	if def.External != "" || len(def.Fields) > 0 {
+		return nil
+	}
+	switch def.Type {
+	case "group":
+	case "object":
+		if def.ObjectType != "" || (def.Enabled != nil && !*def.Enabled) {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return lintedFieldError(field, CodeObjectWithoutDefinitions, "is defined as %s without children fields", def.Type)
+}
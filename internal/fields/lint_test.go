@@ -0,0 +1,104 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintPackageFields(t *testing.T) {
+	packageRoot := t.TempDir()
+	ecsSchemaPath, err := filepath.Abs("./testdata/ecs_nested_v8.10.0.yml")
+	require.NoError(t, err)
+	writeFile(t, filepath.Join(packageRoot, "_dev", "build", "build.yml"), `dependencies:
+  ecs:
+    reference: file://`+ecsSchemaPath+`
+`)
+
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "logs", "manifest.yml"), `title: Logs
+type: logs
+`)
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "logs", "fields", "ecs.yml"), `- name: source.ip
+  external: ecs
+- name: destination.ip
+  type: keyword
+  description: Destination IP.
+`)
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "logs", "fields", "fields.yml"), `- name: foo
+  type: group
+  fields:
+    - name: message
+      type: keyword
+      description: Message.
+    - name: client_ip
+      type: keyword
+      description: IP of the client.
+    - name: created_at
+      type: keyword
+      description: Creation time.
+    - name: empty
+      type: group
+    - name: dynamic
+      type: object
+      object_type: keyword
+      description: Dynamic keywords.
+    - name: disabled
+      type: object
+      enabled: false
+      description: Not indexed object.
+    - name: undocumented
+      type: long
+- name: foo.message
+  type: keyword
+  description: Duplicated message.
+`)
+
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "metrics", "manifest.yml"), `title: Metrics
+type: metrics
+elasticsearch:
+  index_mode: time_series
+`)
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "metrics", "fields", "fields.yml"), `- name: metrics.id
+  type: long
+  dimension: true
+  description: ID.
+- name: metrics.bytes
+  type: long
+  metric_type: counter
+  unit: byte
+  description: Bytes.
+- name: metrics.count
+  type: long
+  metric_type: counter
+  description: Count.
+- name: metrics.value
+  type: double
+  description: Value.
+`)
+
+	errs, err := LintPackageFields(packageRoot)
+	require.NoError(t, err)
+
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	expected := []string{
+		`file "data_stream/logs/fields/fields.yml" is invalid: field "foo.message" is defined multiple times (also in data_stream/logs/fields/fields.yml) (FLD00001)`,
+		`file "data_stream/logs/fields/ecs.yml" is invalid: field "destination.ip" has type "keyword", but it is defined in ECS with type "ip" (FLD00002)`,
+		`file "data_stream/logs/fields/ecs.yml" is invalid: field "destination.ip" is defined as keyword, but its name suggests it should be of type "ip" (FLD00005)`,
+		`file "data_stream/logs/fields/fields.yml" is invalid: field "foo.client_ip" is defined as keyword, but its name suggests it should be of type "ip" (FLD00005)`,
+		`file "data_stream/logs/fields/fields.yml" is invalid: field "foo.created_at" is defined as keyword, but its name suggests it should be of type "date" (FLD00005)`,
+		`file "data_stream/logs/fields/fields.yml" is invalid: field "foo.empty" is defined as group without children fields (FLD00006)`,
+		`file "data_stream/logs/fields/fields.yml" is invalid: field "foo.undocumented" has no description (FLD00003)`,
+		`file "data_stream/metrics/fields/fields.yml" is invalid: field "metrics.count" is a metric in a time series data stream, but it has no unit (FLD00004)`,
+		`file "data_stream/metrics/fields/fields.yml" is invalid: field "metrics.value" is a metric in a time series data stream, but it has no metric_type or unit (FLD00004)`,
+	}
+	assert.Equal(t, expected, messages)
+}
@@ -0,0 +1,234 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+const (
+	// DefaultTotalFieldsLimit is the default value of index.mapping.total_fields.limit in Elasticsearch.
+	DefaultTotalFieldsLimit = 1000
+
+	totalFieldsLimitSetting = "index.mapping.total_fields.limit"
+
+	largestSubtreesCount = 5
+)
+
+// MappingsSize contains the size of the mappings of a data stream.
+type MappingsSize struct {
+	// DataStream is the name of the data stream, or the package for input packages.
+	DataStream string
+
+	// TotalFields is the number of fields counted for the total fields limit,
+	// including objects and multi-fields.
+	TotalFields int
+
+	MultiFields      int
+	DynamicTemplates int
+
+	// Limit is the total fields limit configured for the data stream.
+	Limit int
+
+	// LargestSubtrees contains the top level objects with more fields.
+	LargestSubtrees []SubtreeSize
+}
+
+// SubtreeSize is the number of fields under an object.
+type SubtreeSize struct {
+	Path   string
+	Fields int
+}
+
+// ExceedsLimit returns true if the number of fields is over the configured limit.
+func (s MappingsSize) ExceedsLimit() bool {
+	return s.TotalFields > s.Limit
+}
+
+// AnalyzeMappingsSize calculates the size of the mappings of the data streams of a built package,
+// where external fields are already resolved and dynamic templates added.
+func AnalyzeMappingsSize(builtPackageRoot string) ([]MappingsSize, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(builtPackageRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	if manifest.Type == "input" {
+		size, err := analyzeMappingsSize(manifest.Name, builtPackageRoot, filepath.Join(builtPackageRoot, packages.PackageManifestFile))
+		if err != nil {
+			return nil, err
+		}
+		return []MappingsSize{size}, nil
+	}
+
+	manifestPaths, err := filepath.Glob(filepath.Join(builtPackageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("listing data streams failed: %w", err)
+	}
+	sort.Strings(manifestPaths)
+
+	var sizes []MappingsSize
+	for _, manifestPath := range manifestPaths {
+		dataStreamRoot := filepath.Dir(manifestPath)
+		size, err := analyzeMappingsSize(filepath.Base(dataStreamRoot), dataStreamRoot, manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
+
+func analyzeMappingsSize(name, fieldsParentDir, manifestPath string) (MappingsSize, error) {
+	fieldsDir := filepath.Join(fieldsParentDir, "fields")
+	definitions, err := loadFieldsFromDir(fieldsDir, nil, InjectFieldsOptions{})
+	if err != nil {
+		return MappingsSize{}, fmt.Errorf("can't load fields from directory (path: %s): %w", fieldsDir, err)
+	}
+
+	indexTemplate, err := readManifestIndexTemplate(manifestPath)
+	if err != nil {
+		return MappingsSize{}, err
+	}
+
+	size := MappingsSize{
+		DataStream: name,
+		Limit:      DefaultTotalFieldsLimit,
+	}
+	if limit, found := indexTemplate.totalFieldsLimit(); found {
+		size.Limit = limit
+	}
+	size.DynamicTemplates = len(indexTemplate.Mappings.DynamicTemplates)
+
+	root := newMappingsNode()
+	for _, definition := range definitions {
+		root.add(definition)
+	}
+	size.TotalFields = root.count() - 1 // The root object is not counted.
+	size.MultiFields = root.multiFields()
+
+	for name, child := range root.children {
+		size.LargestSubtrees = append(size.LargestSubtrees, SubtreeSize{Path: name, Fields: child.count()})
+	}
+	sort.Slice(size.LargestSubtrees, func(i, j int) bool {
+		if size.LargestSubtrees[i].Fields != size.LargestSubtrees[j].Fields {
+			return size.LargestSubtrees[i].Fields > size.LargestSubtrees[j].Fields
+		}
+		return size.LargestSubtrees[i].Path < size.LargestSubtrees[j].Path
+	})
+	if len(size.LargestSubtrees) > largestSubtreesCount {
+		size.LargestSubtrees = size.LargestSubtrees[:largestSubtreesCount]
+	}
+	return size, nil
+}
+
+// mappingsNode represents an object or a field in the mappings, children are
+// created for each segment of the dotted names.
+type mappingsNode struct {
+	children        map[string]*mappingsNode
+	multiFieldCount int
+}
+
+func newMappingsNode() *mappingsNode {
+	return &mappingsNode{children: make(map[string]*mappingsNode)}
+}
+
+func (n *mappingsNode) add(definition FieldDefinition) {
+	node := n
+	for _, segment := range strings.Split(definition.Name, ".") {
+		child, found := node.children[segment]
+		if !found {
+			child = newMappingsNode()
+			node.children[segment] = child
+		}
+		node = child
+	}
+	node.multiFieldCount += len(definition.MultiFields)
+
+	for _, child := range definition.Fields {
+		node.add(child)
+	}
+}
+
+func (n *mappingsNode) count() int {
+	count := 1 + n.multiFieldCount
+	for _, child := range n.children {
+		count += child.count()
+	}
+	return count
+}
+
+func (n *mappingsNode) multiFields() int {
+	count := n.multiFieldCount
+	for _, child := range n.children {
+		count += child.multiFields()
+	}
+	return count
+}
+
+type manifestIndexTemplate struct {
+	Settings map[string]any `yaml:"settings"`
+	Mappings struct {
+		DynamicTemplates []map[string]any `yaml:"dynamic_templates"`
+	} `yaml:"mappings"`
+}
+
+func readManifestIndexTemplate(manifestPath string) (*manifestIndexTemplate, error) {
+	content, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest failed: %w", err)
+	}
+
+	var manifest struct {
+		Elasticsearch struct {
+			IndexTemplate manifestIndexTemplate `yaml:"index_template"`
+		} `yaml:"elasticsearch"`
+	}
+	err = yaml.Unmarshal(content, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling manifest failed (path: %s): %w", manifestPath, err)
+	}
+	return &manifest.Elasticsearch.IndexTemplate, nil
+}
+
+// totalFieldsLimit looks for the total fields limit in the settings, that can be defined
+// with nested objects or dotted keys, and with or without the "index" prefix.
+func (t *manifestIndexTemplate) totalFieldsLimit() (int, bool) {
+	settings := make(map[string]any)
+	flattenSettings("", t.Settings, settings)
+
+	for _, key := range []string{totalFieldsLimitSetting, strings.TrimPrefix(totalFieldsLimitSetting, "index.")} {
+		value, found := settings[key]
+		if !found {
+			continue
+		}
+		limit, err := strconv.Atoi(fmt.Sprint(value))
+		if err != nil {
+			continue
+		}
+		return limit, true
+	}
+	return 0, false
+}
+
+func flattenSettings(prefix string, settings map[string]any, flat map[string]any) {
+	for key, value := range settings {
+		key = strings.TrimLeft(prefix+"."+key, ".")
+		if m, ok := value.(map[string]any); ok {
+			flattenSettings(key, m, flat)
+			continue
+		}
+		flat[key] = value
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeMappingsSize(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeFile(t, filepath.Join(packageRoot, "manifest.yml"), `name: test
+type: integration
+version: 1.0.0
+`)
+
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "logs", "manifest.yml"), `title: Logs
+type: logs
+elasticsearch:
+  index_template:
+    settings:
+      index.mapping:
+        total_fields:
+          limit: 5
+    mappings:
+      dynamic_templates:
+        - strings_as_keyword:
+            match_mapping_type: string
+            mapping:
+              type: keyword
+`)
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "logs", "fields", "base-fields.yml"), `- name: '@timestamp'
+  type: date
+`)
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "logs", "fields", "fields.yml"), `- name: foo
+  type: group
+  fields:
+    - name: message
+      type: keyword
+      multi_fields:
+        - name: text
+          type: match_only_text
+    - name: bar.count
+      type: long
+- name: foo.bar.other
+  type: long
+`)
+
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "metrics", "manifest.yml"), `title: Metrics
+type: metrics
+`)
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "metrics", "fields", "fields.yml"), `- name: metrics.value
+  type: double
+`)
+
+	sizes, err := AnalyzeMappingsSize(packageRoot)
+	require.NoError(t, err)
+
+	expected := []MappingsSize{
+		{
+			DataStream:       "logs",
+			TotalFields:      7, // @timestamp, foo, foo.message, foo.message.text, foo.bar, foo.bar.count, foo.bar.other
+			MultiFields:      1,
+			DynamicTemplates: 1,
+			Limit:            5,
+			LargestSubtrees: []SubtreeSize{
+				{Path: "foo", Fields: 6},
+				{Path: "@timestamp", Fields: 1},
+			},
+		},
+		{
+			DataStream:  "metrics",
+			TotalFields: 2,
+			Limit:       DefaultTotalFieldsLimit,
+			LargestSubtrees: []SubtreeSize{
+				{Path: "metrics", Fields: 2},
+			},
+		},
+	}
+	assert.Equal(t, expected, sizes)
+	assert.True(t, sizes[0].ExceedsLimit())
+	assert.False(t, sizes[1].ExceedsLimit())
+}
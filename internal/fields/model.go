@@ -24,6 +24,7 @@ type FieldDefinition struct {
 	Pattern        string            `yaml:"pattern"`
 	Unit           string            `yaml:"unit"`
 	MetricType     string            `yaml:"metric_type"`
+	Dimension      bool              `yaml:"dimension"`
 	External       string            `yaml:"external"`
 	Index          *bool             `yaml:"index"`
 	Enabled        *bool             `yaml:"enabled"`
@@ -0,0 +1,72 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package fields
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+// PackageFieldPaths returns the paths of all the fields defined in the package, including
+// the fields imported from external schemas. If the package depends on ECS, all ECS fields
+// are included, as they can be available through the ECS mappings. Paths of fields that can
+// contain any subfield, like objects and flattened fields, are returned as wildcard patterns.
+func PackageFieldPaths(packageRoot string) ([]string, error) {
+	fdm, err := createDependencyManagerForPackage(packageRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if fdm != nil {
+		ecsSchema, err := fdm.ImportAllFields(ecsSchemaName)
+		if err != nil {
+			return nil, err
+		}
+		paths = appendFieldPaths(paths, "", appendECSMappingMultifields(ecsSchema, ""))
+	}
+
+	fieldsParentDirs := []string{packageRoot}
+	manifests, err := filepath.Glob(filepath.Join(packageRoot, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("listing data streams failed: %w", err)
+	}
+	for _, manifestPath := range manifests {
+		fieldsParentDirs = append(fieldsParentDirs, filepath.Dir(manifestPath))
+	}
+
+	for _, dir := range fieldsParentDirs {
+		defs, err := loadFieldsFromDir(filepath.Join(dir, "fields"), fdm, InjectFieldsOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("can't load fields from directory (path: %s): %w", dir, err)
+		}
+		paths = appendFieldPaths(paths, "", defs)
+	}
+
+	slices.Sort(paths)
+	return slices.Compact(paths), nil
+}
+
+func appendFieldPaths(paths []string, root string, defs []FieldDefinition) []string {
+	for _, def := range defs {
+		path := strings.TrimLeft(root+"."+def.Name, ".")
+		if !isGroup(def) {
+			paths = append(paths, path)
+		}
+		switch def.Type {
+		case "object", "flattened", "nested":
+			paths = append(paths, path+".*")
+		}
+		for _, multiField := range def.MultiFields {
+			paths = append(paths, path+"."+multiField.Name)
+		}
+		paths = appendFieldPaths(paths, path, def.Fields)
+	}
+	return paths
+}
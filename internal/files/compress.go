@@ -5,18 +5,47 @@
 package files
 
 import (
+	"archive/zip"
 	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/elastic/elastic-package/internal/logger"
 
 	"github.com/mholt/archives"
 )
 
+// zipModTime is the modification time set to all the entries of the archives, so they are
+// reproducible. It is the earliest time supported by the zip format.
+var zipModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// reproducibleFileInfo normalizes the file attributes that depend on the machine where
+// the archive is created.
+type reproducibleFileInfo struct {
+	fs.FileInfo
+}
+
+func (fi reproducibleFileInfo) ModTime() time.Time {
+	return zipModTime
+}
+
+func (fi reproducibleFileInfo) Mode() fs.FileMode {
+	if fi.IsDir() {
+		return fs.ModeDir | 0o755
+	}
+	return fi.FileInfo.Mode().Type() | 0o644
+}
+
 // Zip function creates the .zip archive from the source path (built package content).
+// Archives are reproducible: entries are sorted, and their timestamps and permissions are
+// normalized, so the same content always produces the same archive.
 func Zip(ctx context.Context, sourcePath, destinationFile string) error {
 	logger.Debugf("Compress using archives.Zip (destination: %s)", destinationFile)
 
@@ -49,6 +78,12 @@ func Zip(ctx context.Context, sourcePath, destinationFile string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get files from disk: %w", err)
 	}
+	for i := range files {
+		files[i].FileInfo = reproducibleFileInfo{files[i].FileInfo}
+	}
+	slices.SortFunc(files, func(a, b archives.FileInfo) int {
+		return strings.Compare(a.NameInArchive, b.NameInArchive)
+	})
 
 	out, err := os.Create(destinationFile)
 	if err != nil {
@@ -78,3 +113,66 @@ func folderNameFromFileName(filename string) string {
 	}
 	return base
 }
+
+// Unzip function extracts the content of the .zip archive in the destination path.
+func Unzip(zipFile, destinationPath string) error {
+	logger.Debugf("Extract archive (source: %s, destination: %s)", zipFile, destinationPath)
+
+	r, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return fmt.Errorf("can't open archive (path: %s): %w", zipFile, err)
+	}
+	defer r.Close()
+
+	return UnzipReader(&r.Reader, destinationPath)
+}
+
+// UnzipReader extracts the files of an already opened zip archive into the destination path.
+// Entries pointing out of the destination path are rejected, and symbolic links are skipped.
+func UnzipReader(r *zip.Reader, destinationPath string) error {
+	for _, f := range r.File {
+		err := extractZipFile(f, destinationPath)
+		if err != nil {
+			return fmt.Errorf("can't extract %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, destinationPath string) error {
+	name := path.Clean(f.Name)
+	if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("invalid path in archive")
+	}
+	dest := filepath.Join(destinationPath, filepath.FromSlash(name))
+	if f.FileInfo().IsDir() {
+		return os.MkdirAll(dest, 0o755)
+	}
+	if f.Mode()&fs.ModeSymlink != 0 {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(dest), 0o755)
+	if err != nil {
+		return err
+	}
+
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	mode := f.Mode().Perm()
+	if mode == 0 {
+		mode = 0o644
+	}
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
+}
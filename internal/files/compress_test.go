@@ -0,0 +1,117 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package files
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZipReproducible(t *testing.T) {
+	sourcePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sourcePath, "docs"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(sourcePath, "manifest.yml"), []byte("name: test\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(sourcePath, "docs", "README.md"), []byte("# Test\n"), 0664))
+
+	first := filepath.Join(t.TempDir(), "test-1.0.0.zip")
+	require.NoError(t, Zip(context.Background(), sourcePath, first))
+
+	// Change timestamps and permissions, they shouldn't affect the result.
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(sourcePath, "manifest.yml"), later, later))
+	require.NoError(t, os.Chmod(filepath.Join(sourcePath, "manifest.yml"), 0640))
+
+	second := filepath.Join(t.TempDir(), "test-1.0.0.zip")
+	require.NoError(t, Zip(context.Background(), sourcePath, second))
+
+	firstContent, err := os.ReadFile(first)
+	require.NoError(t, err)
+	secondContent, err := os.ReadFile(second)
+	require.NoError(t, err)
+	assert.Equal(t, firstContent, secondContent)
+
+	zipReader, err := zip.OpenReader(first)
+	require.NoError(t, err)
+	defer zipReader.Close()
+
+	var names []string
+	for _, f := range zipReader.File {
+		names = append(names, f.Name)
+		assert.True(t, f.Modified.Equal(zipModTime), f.Name)
+		if f.FileInfo().IsDir() {
+			assert.Equal(t, os.FileMode(0755), f.Mode().Perm(), f.Name)
+		} else {
+			assert.Equal(t, os.FileMode(0644), f.Mode().Perm(), f.Name)
+		}
+	}
+	assert.Equal(t, []string{"test-1.0.0/", "test-1.0.0/docs/", "test-1.0.0/docs/README.md", "test-1.0.0/manifest.yml"}, names)
+}
+
+func TestUnzip(t *testing.T) {
+	sourcePath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sourcePath, "docs"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(sourcePath, "manifest.yml"), []byte("name: test\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(sourcePath, "docs", "README.md"), []byte("# Test\n"), 0664))
+
+	zipFile := filepath.Join(t.TempDir(), "test-1.0.0.zip")
+	require.NoError(t, Zip(context.Background(), sourcePath, zipFile))
+
+	destinationPath := t.TempDir()
+	require.NoError(t, Unzip(zipFile, destinationPath))
+
+	content, err := os.ReadFile(filepath.Join(destinationPath, "test-1.0.0", "docs", "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Test\n", string(content))
+}
+
+func TestUnzipReader(t *testing.T) {
+	newZipReader := func(t *testing.T, entries map[string]fs.FileMode) *zip.Reader {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		for name, mode := range entries {
+			header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+			header.SetMode(mode)
+			f, err := w.CreateHeader(header)
+			require.NoError(t, err)
+			_, err = f.Write([]byte("content"))
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("symbolic links are skipped", func(t *testing.T) {
+		r := newZipReader(t, map[string]fs.FileMode{
+			"file.txt": 0600,
+			"link.txt": fs.ModeSymlink | 0777,
+		})
+		destinationPath := t.TempDir()
+		require.NoError(t, UnzipReader(r, destinationPath))
+
+		info, err := os.Stat(filepath.Join(destinationPath, "file.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, fs.FileMode(0600), info.Mode().Perm())
+		assert.NoFileExists(t, filepath.Join(destinationPath, "link.txt"))
+	})
+
+	t.Run("paths out of the destination are rejected", func(t *testing.T) {
+		r := newZipReader(t, map[string]fs.FileMode{
+			"../file.txt": 0644,
+		})
+		err := UnzipReader(r, t.TempDir())
+		assert.Error(t, err)
+	})
+}
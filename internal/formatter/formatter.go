@@ -5,8 +5,10 @@
 package formatter
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/Masterminds/semver/v3"
@@ -40,29 +42,63 @@ func newFormatter(options formatterOptions) formatter {
 	}
 }
 
+// Options contains the options to format a package.
+type Options struct {
+	// FailFast returns an error on the first file that requires updates, instead of overwriting it.
+	FailFast bool
+
+	// Check reports the files that require updates, without overwriting them.
+	Check bool
+
+	// Strict enables additional rules that normalize ingest pipelines and canonicalize
+	// Kibana saved objects.
+	Strict bool
+}
+
+// FileChange contains the rules that change a file when formatting it.
+type FileChange struct {
+	Path  string
+	Rules []string
+}
+
+// rule is a formatting rule, it returns the formatted content.
+type rule struct {
+	name   string
+	format func(content []byte) ([]byte, error)
+}
+
 // Format method formats files inside of the integration directory.
 func Format(packageRoot string, failFast bool) error {
+	_, err := FormatPackage(packageRoot, Options{FailFast: failFast})
+	return err
+}
+
+// FormatPackage formats files inside of the integration directory, and returns the files
+// changed, or that would be changed in check mode, with the rules that change them.
+func FormatPackage(packageRoot string, options Options) ([]FileChange, error) {
 	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRoot)
 	if err != nil {
-		return fmt.Errorf("failed to read package manifest: %w", err)
+		return nil, fmt.Errorf("failed to read package manifest: %w", err)
 	}
 	specVersion, err := semver.NewVersion(manifest.SpecVersion)
 	if err != nil {
-		return fmt.Errorf("failed to parse package format version %q: %w", manifest.SpecVersion, err)
+		return nil, fmt.Errorf("failed to parse package format version %q: %w", manifest.SpecVersion, err)
 	}
 
+	var changes []FileChange
 	err = filepath.Walk(packageRoot, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		options := formatterOptions{
+		fOptions := formatterOptions{
 			specVersion: *specVersion,
 			extension:   filepath.Ext(info.Name()),
-			failFast:    failFast,
+			failFast:    options.FailFast,
 		}
 
-		if info.IsDir() && info.Name() == "ingest_pipeline" {
+		// Pipelines are formatted only in strict mode, as they can be Handlebars templates.
+		if info.IsDir() && info.Name() == "ingest_pipeline" && !options.Strict {
 			return filepath.SkipDir
 		}
 		if info.IsDir() {
@@ -72,27 +108,39 @@ func Format(packageRoot string, failFast bool) error {
 		// Configure handling of keys with dots.
 		if !specVersion.LessThan(semver.MustParse("3.0.0")) {
 			if info.Name() == "manifest.yml" {
-				options.preferedKeysWithDotAction = KeysWithDotActionNested
+				fOptions.preferedKeysWithDotAction = KeysWithDotActionNested
 			}
 		}
 
-		err = formatFile(path, options)
+		relPath, err := filepath.Rel(packageRoot, path)
+		if err != nil {
+			return err
+		}
+		rules := formattingRules(filepath.ToSlash(relPath), fOptions, options.Strict)
+
+		changed, err := formatFile(path, rules, options)
 		if err != nil {
 			return fmt.Errorf("formatting file failed (path: %s): %w", path, err)
 		}
+		if len(changed) > 0 {
+			changes = append(changes, FileChange{Path: path, Rules: changed})
+		}
 
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("walking through the integration files failed: %w", err)
+		return nil, fmt.Errorf("walking through the integration files failed: %w", err)
 	}
-	return nil
+	return changes, nil
 }
 
-func formatFile(path string, options formatterOptions) error {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("reading file content failed: %w", err)
+// formattingRules returns the rules applied to the file in the given path, relative to the package root.
+func formattingRules(relPath string, options formatterOptions, strict bool) []rule {
+	if isIngestPipeline(relPath) {
+		if !strict || (options.extension != ".yml" && options.extension != ".yaml") {
+			return nil
+		}
+		return strictPipelineRules()
 	}
 
 	format := newFormatter(options)
@@ -100,22 +148,75 @@ func formatFile(path string, options formatterOptions) error {
 		return nil // no errors returned as we have few files that will be never formatted (png, svg, log, etc.)
 	}
 
-	newContent, alreadyFormatted, err := format(content)
+	var rules []rule
+	if options.preferedKeysWithDotAction != KeysWithDotActionNone {
+		rules = append(rules, newFormatterRule("format", newFormatter(formatterOptions{extension: options.extension})))
+		rules = append(rules, newFormatterRule("keys-with-dots", format))
+	} else {
+		rules = append(rules, newFormatterRule("format", format))
+	}
+
+	if strict && isSavedObject(relPath) && options.extension == ".json" {
+		rules = append(rules, strictSavedObjectRules(options.specVersion)...)
+	}
+	return rules
+}
+
+func newFormatterRule(name string, format formatter) rule {
+	return rule{
+		name: name,
+		format: func(content []byte) ([]byte, error) {
+			formatted, _, err := format(content)
+			return formatted, err
+		},
+	}
+}
+
+func isIngestPipeline(relPath string) bool {
+	return path.Base(path.Dir(relPath)) == "ingest_pipeline"
+}
+
+func isSavedObject(relPath string) bool {
+	matched, _ := path.Match("kibana/*/*", relPath)
+	return matched
+}
+
+// formatFile applies the rules to the file and returns the names of the rules that changed it.
+func formatFile(path string, rules []rule, options Options) ([]string, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("formatting file content failed: %w", err)
+		return nil, fmt.Errorf("reading file content failed: %w", err)
 	}
 
-	if alreadyFormatted {
-		return nil
+	var changed []string
+	newContent := content
+	for _, rule := range rules {
+		formatted, err := rule.format(newContent)
+		if err != nil {
+			return nil, fmt.Errorf("formatting file content failed: %w", err)
+		}
+		if bytes.Equal(formatted, newContent) {
+			continue
+		}
+		changed = append(changed, rule.name)
+		newContent = formatted
+	}
+
+	if len(changed) == 0 || options.Check {
+		return changed, nil
 	}
 
-	if options.failFast {
-		return fmt.Errorf("file is not formatted (path: %s)", path)
+	if options.FailFast {
+		return nil, fmt.Errorf("file is not formatted (path: %s)", path)
 	}
 
 	err = os.WriteFile(path, newContent, 0755)
 	if err != nil {
-		return fmt.Errorf("rewriting file failed (path: %s): %w", path, err)
+		return nil, fmt.Errorf("rewriting file failed (path: %s): %w", path, err)
 	}
-	return nil
+	return changed, nil
 }
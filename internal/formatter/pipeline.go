@@ -0,0 +1,213 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+var (
+	// Processor options placed before and after the rest of options, that are sorted alphabetically.
+	leadingProcessorOptions  = []string{"tag", "description", "if"}
+	trailingProcessorOptions = []string{"ignore_missing", "ignore_failure", "on_failure"}
+
+	invalidTagChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+)
+
+// strictPipelineRules returns the rules used to normalize ingest pipelines in YAML format.
+func strictPipelineRules() []rule {
+	return []rule{
+		{name: "format", format: pipelineFormatter(nil)},
+		{name: "pipeline-processor-key-order", format: pipelineFormatter(sortProcessorOptions)},
+		{name: "pipeline-processor-tags", format: pipelineFormatter(addProcessorTags)},
+		{name: "pipeline-script-block-scalars", format: pipelineFormatter(scriptsAsBlockScalars)},
+	}
+}
+
+// pipelineFormatter returns a formatter that applies the given function to the processors of
+// the pipeline. Pipelines with template tags out of strings, like pipeline snippets, can't be
+// parsed as YAML documents, they are returned as they are.
+func pipelineFormatter(apply func(pipeline *yaml.Node)) func([]byte) ([]byte, error) {
+	return func(content []byte) ([]byte, error) {
+		var node yaml.Node
+		err := yaml.Unmarshal(content, &node)
+		if err != nil || countTemplateTags(&node) != bytes.Count(content, []byte("{{")) {
+			logger.Debugf("Pipeline can't be formatted as YAML document, it probably contains template tags")
+			return content, nil
+		}
+		if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+			return content, nil
+		}
+
+		if apply != nil {
+			apply(node.Content[0])
+		}
+
+		formatted, _, err := NewYAMLFormatter(KeysWithDotActionNone).encode(&node, content)
+		if err != nil {
+			return nil, err
+		}
+		return formatted, nil
+	}
+}
+
+// countTemplateTags counts the template tags in scalars and comments of the document.
+func countTemplateTags(node *yaml.Node) int {
+	count := 0
+	for _, s := range []string{node.Value, node.HeadComment, node.LineComment, node.FootComment} {
+		count += strings.Count(s, "{{")
+	}
+	for _, child := range node.Content {
+		count += countTemplateTags(child)
+	}
+	return count
+}
+
+// pipelineProcessors calls the function for each processor in the pipeline, including the
+// processors defined in failure handlers and foreach processors.
+func pipelineProcessors(pipeline *yaml.Node, f func(processorType string, options *yaml.Node)) {
+	for _, key := range []string{"processors", "on_failure"} {
+		if list := mappingValue(pipeline, key); list != nil {
+			processorList(list, f)
+		}
+	}
+}
+
+func processorList(list *yaml.Node, f func(string, *yaml.Node)) {
+	if list.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, processor := range list.Content {
+		processorNode(processor, f)
+	}
+}
+
+func processorNode(processor *yaml.Node, f func(string, *yaml.Node)) {
+	if processor.Kind != yaml.MappingNode || len(processor.Content) != 2 {
+		return
+	}
+	processorType, options := processor.Content[0].Value, processor.Content[1]
+	if options.Kind != yaml.MappingNode {
+		return
+	}
+	f(processorType, options)
+
+	if onFailure := mappingValue(options, "on_failure"); onFailure != nil {
+		processorList(onFailure, f)
+	}
+	if processorType == "foreach" {
+		if nested := mappingValue(options, "processor"); nested != nil {
+			processorNode(nested, f)
+		}
+	}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sortProcessorOptions sorts the options of the processors, common options for all processors
+// are placed first or last, and the rest are sorted alphabetically.
+func sortProcessorOptions(pipeline *yaml.Node) {
+	pipelineProcessors(pipeline, func(_ string, options *yaml.Node) {
+		type option struct {
+			key, value *yaml.Node
+		}
+		var sorted []option
+		for i := 0; i+1 < len(options.Content); i += 2 {
+			sorted = append(sorted, option{key: options.Content[i], value: options.Content[i+1]})
+		}
+		slices.SortStableFunc(sorted, func(a, b option) int {
+			ra, rb := processorOptionRank(a.key.Value), processorOptionRank(b.key.Value)
+			if ra != rb {
+				return ra - rb
+			}
+			if ra == 0 {
+				return strings.Compare(a.key.Value, b.key.Value)
+			}
+			return 0
+		})
+		options.Content = options.Content[:0]
+		for _, o := range sorted {
+			options.Content = append(options.Content, o.key, o.value)
+		}
+	})
+}
+
+// processorOptionRank returns a negative number for leading options, a positive number for
+// trailing options, and zero for the rest.
+func processorOptionRank(key string) int {
+	if i := slices.Index(leadingProcessorOptions, key); i >= 0 {
+		return i - len(leadingProcessorOptions)
+	}
+	if i := slices.Index(trailingProcessorOptions, key); i >= 0 {
+		return i + 1
+	}
+	return 0
+}
+
+// addProcessorTags adds a tag to the processors without one, based on the processor type and
+// the field it processes. Tags are unique in the pipeline.
+func addProcessorTags(pipeline *yaml.Node) {
+	tags := make(map[string]bool)
+	pipelineProcessors(pipeline, func(_ string, options *yaml.Node) {
+		if tag := mappingValue(options, "tag"); tag != nil {
+			tags[tag.Value] = true
+		}
+	})
+
+	pipelineProcessors(pipeline, func(processorType string, options *yaml.Node) {
+		if mappingValue(options, "tag") != nil {
+			return
+		}
+
+		base := processorType
+		if field := mappingValue(options, "field"); field != nil && field.Kind == yaml.ScalarNode && field.Value != "" {
+			base += "_" + field.Value
+		}
+		base = strings.Trim(invalidTagChars.ReplaceAllString(base, "_"), "_")
+
+		tag := base
+		for i := 2; tags[tag]; i++ {
+			tag = fmt.Sprintf("%s_%d", base, i)
+		}
+		tags[tag] = true
+
+		options.Content = append([]*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "tag"},
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: tag},
+		}, options.Content...)
+	})
+}
+
+// scriptsAsBlockScalars uses literal block scalars for the sources of Painless scripts.
+func scriptsAsBlockScalars(pipeline *yaml.Node) {
+	pipelineProcessors(pipeline, func(processorType string, options *yaml.Node) {
+		if processorType != "script" {
+			return
+		}
+		source := mappingValue(options, "source")
+		if source == nil || source.Kind != yaml.ScalarNode {
+			return
+		}
+		source.Style = yaml.LiteralStyle
+	})
+}
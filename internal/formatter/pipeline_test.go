@@ -0,0 +1,143 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictPipelineRules(t *testing.T) {
+	cases := []struct {
+		title    string
+		rule     string
+		doc      string
+		expected string
+	}{
+		{
+			title: "processor options are sorted",
+			rule:  "pipeline-processor-key-order",
+			doc: `processors:
+  - set:
+      value: bar
+      ignore_failure: true
+      field: foo
+      if: ctx.foo == null
+      tag: set_foo
+`,
+			expected: `processors:
+  - set:
+      tag: set_foo
+      if: ctx.foo == null
+      field: foo
+      value: bar
+      ignore_failure: true
+`,
+		},
+		{
+			title: "tags are added to processors without one",
+			rule:  "pipeline-processor-tags",
+			doc: `processors:
+  - set:
+      tag: set_foo
+      field: foo
+      value: bar
+  - set:
+      field: foo
+      value: baz
+  - foreach:
+      field: event.category
+      processor:
+        append:
+          field: tags
+          value: '{{{ _ingest._value }}}'
+on_failure:
+  - set:
+      field: error.message
+      value: '{{{ _ingest.on_failure_message }}}'
+`,
+			expected: `processors:
+  - set:
+      tag: set_foo
+      field: foo
+      value: bar
+  - set:
+      tag: set_foo_2
+      field: foo
+      value: baz
+  - foreach:
+      tag: foreach_event_category
+      field: event.category
+      processor:
+        append:
+          tag: append_tags
+          field: tags
+          value: '{{{ _ingest._value }}}'
+on_failure:
+  - set:
+      tag: set_error_message
+      field: error.message
+      value: '{{{ _ingest.on_failure_message }}}'
+`,
+		},
+		{
+			title: "scripts are written as block scalars",
+			rule:  "pipeline-script-block-scalars",
+			doc: `---
+processors:
+  - script:
+      tag: script_foo
+      lang: painless
+      source: "ctx.foo = 'bar';"
+`,
+			expected: `---
+processors:
+  - script:
+      tag: script_foo
+      lang: painless
+      source: |-
+        ctx.foo = 'bar';
+`,
+		},
+		{
+			title: "pipelines with template tags out of strings are not changed",
+			doc: `processors:
+    - set:
+          field: foo
+          value: bar
+    {{> snippet }}
+`,
+			expected: `processors:
+    - set:
+          field: foo
+          value: bar
+    {{> snippet }}
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			content := []byte(c.doc)
+			var changed []string
+			for _, rule := range strictPipelineRules() {
+				formatted, err := rule.format(content)
+				require.NoError(t, err)
+				if string(formatted) != string(content) {
+					changed = append(changed, rule.name)
+				}
+				content = formatted
+			}
+			assert.Equal(t, c.expected, string(content))
+			if c.rule == "" {
+				assert.Empty(t, changed)
+			} else {
+				assert.Equal(t, []string{c.rule}, changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/elastic/elastic-package/internal/common"
+)
+
+// Fields of saved objects that Kibana stores as encoded JSON.
+var savedObjectEncodedFields = []string{
+	"attributes.controlGroupInput.ignoreParentSettingsJSON",
+	"attributes.controlGroupInput.panelsJSON",
+	"attributes.kibanaSavedObjectMeta.searchSourceJSON",
+	"attributes.layerListJSON",
+	"attributes.mapStateJSON",
+	"attributes.optionsJSON",
+	"attributes.panelsJSON",
+	"attributes.uiStateJSON",
+	"attributes.visState",
+}
+
+// strictSavedObjectRules returns the rules used to canonicalize Kibana saved objects.
+func strictSavedObjectRules(specVersion semver.Version) []rule {
+	return []rule{
+		{name: "saved-object-key-order", format: savedObjectFormatter(specVersion, sortEmbeddedJSONKeys)},
+		{name: "saved-object-references", format: savedObjectFormatter(specVersion, sortReferences)},
+	}
+}
+
+// savedObjectFormatter returns a formatter that applies the given function to the saved object.
+// Keys of the saved object are sorted when encoding it, as when exporting dashboards.
+func savedObjectFormatter(specVersion semver.Version, apply func(object common.MapStr) error) func([]byte) ([]byte, error) {
+	return func(content []byte) ([]byte, error) {
+		var object common.MapStr
+		err := JSONUnmarshalUsingNumber(content, &object)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling saved object failed: %w", err)
+		}
+
+		err = apply(object)
+		if err != nil {
+			return nil, err
+		}
+
+		formatted, err := JSONFormatterBuilder(specVersion).Encode(object)
+		if err != nil {
+			return nil, fmt.Errorf("marshalling saved object failed: %w", err)
+		}
+		if bytes.HasSuffix(content, []byte("\n")) {
+			formatted = append(formatted, '\n')
+		}
+		return formatted, nil
+	}
+}
+
+// sortEmbeddedJSONKeys sorts the keys of the objects encoded as JSON strings in the saved object.
+// Decoded objects don't need changes, as their keys are sorted on encoding.
+func sortEmbeddedJSONKeys(object common.MapStr) error {
+	for _, field := range savedObjectEncodedFields {
+		value, err := object.GetValue(field)
+		if err != nil {
+			continue
+		}
+		encoded, ok := value.(string)
+		if !ok || encoded == "" {
+			continue
+		}
+
+		var decoded any
+		err = JSONUnmarshalUsingNumber([]byte(encoded), &decoded)
+		if err != nil {
+			return fmt.Errorf("unmarshalling encoded field failed (key: %s): %w", field, err)
+		}
+
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		err = enc.Encode(decoded)
+		if err != nil {
+			return fmt.Errorf("marshalling encoded field failed (key: %s): %w", field, err)
+		}
+		_, err = object.Put(field, string(bytes.TrimSpace(b.Bytes())))
+		if err != nil {
+			return fmt.Errorf("can't update field (key: %s): %w", field, err)
+		}
+	}
+	return nil
+}
+
+// sortReferences sorts the references of the saved object by name, type and id.
+func sortReferences(object common.MapStr) error {
+	value, err := object.GetValue("references")
+	if err != nil {
+		return nil
+	}
+	references, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("expected list of references, found %T", value)
+	}
+
+	referenceKey := func(reference any, key string) string {
+		m, _ := reference.(map[string]any)
+		s, _ := m[key].(string)
+		return s
+	}
+	slices.SortStableFunc(references, func(a, b any) int {
+		return cmp.Or(
+			cmp.Compare(referenceKey(a, "name"), referenceKey(b, "name")),
+			cmp.Compare(referenceKey(a, "type"), referenceKey(b, "type")),
+			cmp.Compare(referenceKey(a, "id"), referenceKey(b, "id")),
+		)
+	})
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package formatter
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrictSavedObjectRules(t *testing.T) {
+	doc := `{
+    "attributes": {
+        "title": "Dashboard <1>",
+        "optionsJSON": "{\"useMargins\":true,\"hidePanelTitles\":false}",
+        "version": 1.0
+    },
+    "id": "test-dashboard",
+    "references": [
+        {
+            "id": "test-visualization-2",
+            "name": "panel_2",
+            "type": "visualization"
+        },
+        {
+            "id": "test-visualization-1",
+            "name": "panel_1",
+            "type": "visualization"
+        }
+    ],
+    "type": "dashboard"
+}
+`
+	expected := `{
+    "attributes": {
+        "optionsJSON": "{\"hidePanelTitles\":false,\"useMargins\":true}",
+        "title": "Dashboard <1>",
+        "version": 1.0
+    },
+    "id": "test-dashboard",
+    "references": [
+        {
+            "id": "test-visualization-1",
+            "name": "panel_1",
+            "type": "visualization"
+        },
+        {
+            "id": "test-visualization-2",
+            "name": "panel_2",
+            "type": "visualization"
+        }
+    ],
+    "type": "dashboard"
+}
+`
+
+	content := []byte(doc)
+	var changed []string
+	for _, rule := range strictSavedObjectRules(*semver.MustParse("3.0.0")) {
+		formatted, err := rule.format(content)
+		require.NoError(t, err)
+		if string(formatted) != string(content) {
+			changed = append(changed, rule.name)
+		}
+		content = formatted
+	}
+	assert.Equal(t, expected, string(content))
+	assert.Equal(t, []string{"saved-object-key-order", "saved-object-references"}, changed)
+}
@@ -34,10 +34,15 @@ func (f *YAMLFormatter) Format(content []byte) ([]byte, bool, error) {
 
 	applyActionOnKeysWithDots(&node, f.keysWithDotsAction)
 
+	return f.encode(&node, content)
+}
+
+// encode encodes the node, using the original content to preserve the document start marker.
+func (f *YAMLFormatter) encode(node *yaml.Node, content []byte) ([]byte, bool, error) {
 	var b bytes.Buffer
 	encoder := yaml.NewEncoder(&b)
 	encoder.SetIndent(2)
-	err = encoder.Encode(&node)
+	err := encoder.Encode(node)
 	if err != nil {
 		return nil, false, fmt.Errorf("marshalling YAML node failed: %w", err)
 	}
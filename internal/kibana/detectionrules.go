@@ -0,0 +1,85 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package kibana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type exportDetectionRulesRequest struct {
+	Objects []exportDetectionRulesRequestObject `json:"objects"`
+}
+
+type exportDetectionRulesRequestObject struct {
+	RuleID string `json:"rule_id"`
+}
+
+type exportDetectionRulesDetails struct {
+	MissingRules []exportDetectionRulesRequestObject `json:"missing_rules"`
+}
+
+// ExportDetectionRules exports the security detection rules with the given rule IDs. Detection rules
+// cannot be exported with the Saved Objects API, so the Detection Engine API is used. Exception lists
+// exported with the rules are not included in the result.
+func (c *Client) ExportDetectionRules(ctx context.Context, ruleIDs []string) ([]map[string]any, error) {
+	var request exportDetectionRulesRequest
+	for _, ruleID := range ruleIDs {
+		request.Objects = append(request.Objects, exportDetectionRulesRequestObject{RuleID: ruleID})
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	path := DetectionEngineAPI + "/rules/_export"
+	statusCode, respBody, err := c.SendRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, fmt.Errorf("could not export detection rules; API status code = %d; response body = %s: %w", statusCode, string(respBody), err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not export detection rules; API status code = %d; response body = %s", statusCode, string(respBody))
+	}
+
+	var rules []map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(respBody))
+	for decoder.More() {
+		var object json.RawMessage
+		err := decoder.Decode(&object)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling response failed (body: \n%s): %w", string(respBody), err)
+		}
+
+		var rule map[string]any
+		err = json.Unmarshal(object, &rule)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshalling response failed (body: \n%s): %w", string(respBody), err)
+		}
+		switch {
+		case rule["rule_id"] != nil:
+			rules = append(rules, rule)
+		case rule["missing_rules"] != nil:
+			// Export details are included as the last object.
+			var details exportDetectionRulesDetails
+			err = json.Unmarshal(object, &details)
+			if err != nil {
+				return nil, fmt.Errorf("unmarshalling export details failed: %w", err)
+			}
+			if len(details.MissingRules) > 0 {
+				var missing []string
+				for _, rule := range details.MissingRules {
+					missing = append(missing, rule.RuleID)
+				}
+				return nil, fmt.Errorf("detection rules not found: %s", strings.Join(missing, ", "))
+			}
+		}
+	}
+
+	return rules, nil
+}
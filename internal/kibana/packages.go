@@ -115,12 +115,16 @@ type FleetPackage struct {
 	Status      string `json:"status"`
 	SavedObject *struct {
 		Attributes struct {
+			Version                      string           `json:"version"`
+			InstallSource                string           `json:"install_source"`
 			InstalledElasticsearchAssets []packages.Asset `json:"installed_es"`
 			InstalledKibanaAssets        []packages.Asset `json:"installed_kibana"`
 			PackageAssets                []packages.Asset `json:"package_assets"`
 		} `json:"attributes"`
 	} `json:"savedObject"`
 	InstallationInfo *struct {
+		Version                      string           `json:"version"`
+		InstallSource                string           `json:"install_source"`
 		InstalledElasticsearchAssets []packages.Asset `json:"installed_es"`
 		InstalledKibanaAssets        []packages.Asset `json:"installed_kibana"`
 	} `json:"installationInfo"`
@@ -139,6 +143,38 @@ func (p *FleetPackage) Assets() []packages.Asset {
 	return assets
 }
 
+// InstalledVersion returns the installed version of the package, that can be different to
+// the latest available version reported in Version.
+func (p *FleetPackage) InstalledVersion() string {
+	switch {
+	case p.SavedObject != nil && p.SavedObject.Attributes.Version != "":
+		return p.SavedObject.Attributes.Version
+	case p.InstallationInfo != nil && p.InstallationInfo.Version != "":
+		return p.InstallationInfo.Version
+	}
+	return p.Version
+}
+
+// InstallSource returns how the package was installed, e.g. "registry" or "upload". It is
+// empty if it is not reported.
+func (p *FleetPackage) InstallSource() string {
+	switch {
+	case p.SavedObject != nil:
+		return p.SavedObject.Attributes.InstallSource
+	case p.InstallationInfo != nil:
+		return p.InstallationInfo.InstallSource
+	}
+	return ""
+}
+
+// KibanaAssets returns the Kibana assets installed for the package.
+func (p *FleetPackage) KibanaAssets() []packages.Asset {
+	if p.SavedObject != nil {
+		return p.SavedObject.Attributes.InstalledKibanaAssets
+	}
+	return p.InstallationInfo.InstalledKibanaAssets
+}
+
 type ErrPackageNotFound struct {
 	name string
 }
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	"github.com/elastic/elastic-package/internal/packages"
 )
@@ -328,3 +329,78 @@ func (c *Client) DeletePackagePolicy(ctx context.Context, p PackagePolicy) error
 
 	return nil
 }
+
+// ListPackagePolicies returns the package policies of the given package.
+func (c *Client) ListPackagePolicies(ctx context.Context, packageName string) ([]PackagePolicy, error) {
+	kuery := url.QueryEscape(fmt.Sprintf("ingest-package-policies.package.name:%s", packageName))
+	statusCode, respBody, err := c.get(ctx, fmt.Sprintf("%s/package_policies?perPage=1000&kuery=%s", FleetAPI, kuery))
+	if err != nil {
+		return nil, fmt.Errorf("could not list package policies: %w", err)
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not list package policies; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	// Inputs are returned in a different format, so they are not decoded.
+	var resp struct {
+		Items []struct {
+			ID        string `json:"id"`
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+			PolicyID  string `json:"policy_id"`
+			Package   struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"package"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("could not convert package policies (response) to JSON: %w", err)
+	}
+
+	policies := make([]PackagePolicy, len(resp.Items))
+	for i, item := range resp.Items {
+		policies[i].ID = item.ID
+		policies[i].Name = item.Name
+		policies[i].Namespace = item.Namespace
+		policies[i].PolicyID = item.PolicyID
+		policies[i].Package.Name = item.Package.Name
+		policies[i].Package.Version = item.Package.Version
+	}
+	return policies, nil
+}
+
+// PackagePolicyUpgradeResult is the result of upgrading a package policy.
+type PackagePolicyUpgradeResult struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"statusCode"`
+	Body       struct {
+		Message string `json:"message"`
+	} `json:"body"`
+}
+
+// UpgradePackagePolicies upgrades the given package policies to the installed version of their package.
+func (c *Client) UpgradePackagePolicies(ctx context.Context, ids []string) ([]PackagePolicyUpgradeResult, error) {
+	reqBody, err := json.Marshal(map[string]any{"packagePolicyIds": ids})
+	if err != nil {
+		return nil, fmt.Errorf("could not convert package policies upgrade (request) to JSON: %w", err)
+	}
+
+	statusCode, respBody, err := c.post(ctx, fmt.Sprintf("%s/package_policies/upgrade", FleetAPI), reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("could not upgrade package policies: %w", err)
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not upgrade package policies; API status code = %d; response body = %s", statusCode, respBody)
+	}
+
+	var results []PackagePolicyUpgradeResult
+	if err := json.Unmarshal(respBody, &results); err != nil {
+		return nil, fmt.Errorf("could not convert package policies upgrade (response) to JSON: %w", err)
+	}
+	return results, nil
+}
@@ -148,6 +148,38 @@ func (c *Client) SetManagedSavedObject(ctx context.Context, savedObjectType stri
 	return nil
 }
 
+// ErrSavedObjectNotFound is returned when a saved object doesn't exist in Kibana.
+type ErrSavedObjectNotFound struct {
+	savedObjectType string
+	id              string
+}
+
+func (e *ErrSavedObjectNotFound) Error() string {
+	return fmt.Sprintf("%s %s not found", e.savedObjectType, e.id)
+}
+
+// GetSavedObject method obtains the saved object with the given type and ID.
+func (c *Client) GetSavedObject(ctx context.Context, savedObjectType string, id string) (map[string]any, error) {
+	path := fmt.Sprintf("%s/%s/%s", SavedObjectsAPI, savedObjectType, id)
+	statusCode, respBody, err := c.get(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("could not get saved object; API status code = %d; response body = %s: %w", statusCode, string(respBody), err)
+	}
+	if statusCode == http.StatusNotFound {
+		return nil, &ErrSavedObjectNotFound{savedObjectType: savedObjectType, id: id}
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get saved object; API status code = %d; response body = %s", statusCode, string(respBody))
+	}
+
+	var object map[string]any
+	err = json.Unmarshal(respBody, &object)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling response failed: %w", err)
+	}
+	return object, nil
+}
+
 type ExportSavedObjectsRequest struct {
 	ExcludeExportDetails  bool                              `json:"excludeExportDetails"`
 	IncludeReferencesDeep bool                              `json:"includeReferencesDeep"`
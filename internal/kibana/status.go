@@ -37,6 +37,22 @@ type statusType struct {
 			Level string `json:"level"`
 		} `json:"overall"`
 	} `json:"status"`
+	Metrics struct {
+		Process struct {
+			Memory struct {
+				Heap struct {
+					UsedInBytes uint64 `json:"used_in_bytes"`
+					SizeLimit   uint64 `json:"size_limit"`
+				} `json:"heap"`
+			} `json:"memory"`
+		} `json:"process"`
+	} `json:"metrics"`
+}
+
+// HeapUsage contains information about the heap memory used by Kibana.
+type HeapUsage struct {
+	UsedInBytes  uint64
+	LimitInBytes uint64
 }
 
 // Version method returns the version of Kibana (Elastic stack)
@@ -76,3 +92,17 @@ func (c *Client) CheckHealth(ctx context.Context) error {
 	}
 	return nil
 }
+
+// HeapUsage returns the heap memory used by the Kibana process.
+func (c *Client) HeapUsage(ctx context.Context) (HeapUsage, error) {
+	status, err := c.requestStatus(ctx)
+	if err != nil {
+		return HeapUsage{}, fmt.Errorf("could not reach status endpoint: %w", err)
+	}
+
+	heap := status.Metrics.Process.Memory.Heap
+	return HeapUsage{
+		UsedInBytes:  heap.UsedInBytes,
+		LimitInBytes: heap.SizeLimit,
+	}, nil
+}
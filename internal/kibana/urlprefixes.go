@@ -14,6 +14,9 @@ const (
 	// StatusAPI is the prefix for Kibana Status API resource.
 	StatusAPI = "/api/status"
 
+	// DetectionEngineAPI is the prefix for all Kibana Security Detection Engine API resources.
+	DetectionEngineAPI = "/api/detection_engine"
+
 	// FleetAPI is the prefix for all Kibana Fleet API resources.
 	FleetAPI = "/api/fleet"
 )
@@ -6,6 +6,7 @@ package packages
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -16,6 +17,8 @@ import (
 
 const kibanaVersionRequirement = "kibana.version"
 
+var constraintVersionRegexp = regexp.MustCompile(`\d+(\.\d+){0,2}`)
+
 type packageRequirements struct {
 	kibana struct {
 		version *semver.Version
@@ -47,6 +50,31 @@ func CheckConditions(manifest PackageManifest, keyValuePairs []string) error {
 	return nil
 }
 
+// MinimumKibanaVersion returns the minimum version of Kibana supported by the package, as the lowest
+// version mentioned in its Kibana constraint that satisfies it. It returns nil if the package doesn't
+// have a Kibana constraint, or the minimum version cannot be determined.
+func MinimumKibanaVersion(manifest PackageManifest) (*semver.Version, error) {
+	if len(manifest.Conditions.Kibana.Version) == 0 {
+		return nil, nil
+	}
+	kibanaConstraint, err := semver.NewConstraint(manifest.Conditions.Kibana.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid constraint for Kibana: %w", err)
+	}
+
+	var minimum *semver.Version
+	for _, candidate := range constraintVersionRegexp.FindAllString(manifest.Conditions.Kibana.Version, -1) {
+		version, err := semver.NewVersion(candidate)
+		if err != nil || !kibanaConstraint.Check(version) {
+			continue
+		}
+		if minimum == nil || version.LessThan(minimum) {
+			minimum = version
+		}
+	}
+	return minimum, nil
+}
+
 func parsePackageRequirements(keyValuePairs []string) (*packageRequirements, error) {
 	var pr packageRequirements
 
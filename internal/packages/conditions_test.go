@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCheckConditions_InvalidRelease(t *testing.T) {
@@ -57,3 +58,35 @@ func TestCheckConditions_ValidSnapshot(t *testing.T) {
 	err := CheckConditions(manifest, []string{"kibana.version=7.11.1-SNAPSHOT"})
 	assert.NoError(t, err)
 }
+
+func TestMinimumKibanaVersion(t *testing.T) {
+	cases := []struct {
+		constraint string
+		expected   string
+	}{
+		{constraint: "", expected: ""},
+		{constraint: "^8.13.0", expected: "8.13.0"},
+		{constraint: "^7.17.0 || ^8.0.0", expected: "7.17.0"},
+		{constraint: "^8.0.0 || ^7.16.2", expected: "7.16.2"},
+		{constraint: ">=8.10.0, <9.0.0", expected: "8.10.0"},
+		{constraint: ">8.10.0", expected: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.constraint, func(t *testing.T) {
+			manifest := PackageManifest{
+				Conditions: Conditions{
+					Kibana: KibanaConditions{Version: c.constraint},
+				},
+			}
+			version, err := MinimumKibanaVersion(manifest)
+			require.NoError(t, err)
+			if c.expected == "" {
+				assert.Nil(t, version)
+				return
+			}
+			require.NotNil(t, version)
+			assert.Equal(t, c.expected, version.String())
+		})
+	}
+}
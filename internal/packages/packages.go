@@ -66,15 +66,22 @@ func (vv VarValue) MarshalJSON() ([]byte, error) {
 
 // Variable is an instance of configuration variable (named, typed).
 type Variable struct {
-	Name    string   `config:"name" json:"name" yaml:"name"`
-	Type    string   `config:"type" json:"type" yaml:"type"`
-	Default VarValue `config:"default" json:"default" yaml:"default"`
+	Name        string   `config:"name" json:"name" yaml:"name"`
+	Type        string   `config:"type" json:"type" yaml:"type"`
+	Title       string   `config:"title" json:"title,omitempty" yaml:"title,omitempty"`
+	Description string   `config:"description" json:"description,omitempty" yaml:"description,omitempty"`
+	Multi       bool     `config:"multi" json:"multi,omitempty" yaml:"multi,omitempty"`
+	Required    bool     `config:"required" json:"required,omitempty" yaml:"required,omitempty"`
+	Secret      bool     `config:"secret" json:"secret,omitempty" yaml:"secret,omitempty"`
+	Default     VarValue `config:"default" json:"default" yaml:"default"`
 }
 
 // Input is a single input configuration.
 type Input struct {
-	Type string     `config:"type" json:"type" yaml:"type"`
-	Vars []Variable `config:"vars" json:"vars" yaml:"vars"`
+	Type        string     `config:"type" json:"type" yaml:"type"`
+	Title       string     `config:"title" json:"title,omitempty" yaml:"title,omitempty"`
+	Description string     `config:"description" json:"description,omitempty" yaml:"description,omitempty"`
+	Vars        []Variable `config:"vars" json:"vars" yaml:"vars"`
 }
 
 // Source contains metadata about the source code of the package.
@@ -89,7 +96,8 @@ type KibanaConditions struct {
 
 // ElasticConditions defines conditions related to Elastic subscriptions or partnerships.
 type ElasticConditions struct {
-	Subscription string `config:"subscription" json:"subscription" yaml:"subscription"`
+	Subscription string   `config:"subscription" json:"subscription" yaml:"subscription"`
+	Capabilities []string `config:"capabilities" json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
 }
 
 // Conditions define requirements for different parts of the Elastic stack.
@@ -111,6 +119,8 @@ type DiscoveryField struct {
 // PolicyTemplate is a configuration of inputs responsible for collecting log or metric data.
 type PolicyTemplate struct {
 	Name        string   `config:"name" json:"name" yaml:"name"`                                                       // Name of policy template.
+	Title       string   `config:"title" json:"title,omitempty" yaml:"title,omitempty"`                                // Title of policy template.
+	Description string   `config:"description" json:"description,omitempty" yaml:"description,omitempty"`              // Description of policy template.
 	DataStreams []string `config:"data_streams,omitempty" json:"data_streams,omitempty" yaml:"data_streams,omitempty"` // List of data streams compatible with the policy template.
 	Inputs      []Input  `config:"inputs,omitempty" json:"inputs,omitempty" yaml:"inputs,omitempty"`
 
@@ -179,6 +189,7 @@ type DataStreamManifest struct {
 	Type          string         `config:"type" json:"type" yaml:"type"`
 	Dataset       string         `config:"dataset" json:"dataset" yaml:"dataset"`
 	Hidden        bool           `config:"hidden" json:"hidden" yaml:"hidden"`
+	ILMPolicy     string         `config:"ilm_policy" json:"ilm_policy,omitempty" yaml:"ilm_policy,omitempty"`
 	Release       string         `config:"release" json:"release" yaml:"release"`
 	Elasticsearch *Elasticsearch `config:"elasticsearch" json:"elasticsearch" yaml:"elasticsearch"`
 	Streams       []struct {
@@ -0,0 +1,118 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package pipelinesnippets
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	snippetsFSPath          = "_dev/build/pipeline-snippets"
+	ingestPipelineFSPattern = "data_stream/*/elasticsearch/ingest_pipeline/*"
+)
+
+// PackageFS returns a filesystem with the contents of the package as they are after building it:
+// pipelines have their snippet includes expanded and the snippets directory is hidden. It allows
+// to validate source packages using pipeline snippets.
+func PackageFS(packageRoot string) fs.FS {
+	return &packageFS{
+		FS:          os.DirFS(packageRoot),
+		packageRoot: packageRoot,
+	}
+}
+
+type packageFS struct {
+	fs.FS
+	packageRoot string
+}
+
+func (p *packageFS) Open(name string) (fs.File, error) {
+	if name == snippetsFSPath || strings.HasPrefix(name, snippetsFSPath+"/") {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	f, err := p.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == path.Dir(snippetsFSPath) {
+		dir, ok := f.(fs.ReadDirFile)
+		if !ok {
+			return f, nil
+		}
+		return &filteredDir{ReadDirFile: dir, hidden: path.Base(snippetsFSPath)}, nil
+	}
+
+	if matched, _ := path.Match(ingestPipelineFSPattern, name); !matched {
+		return f, nil
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return p.FS.Open(name)
+	}
+	content, err := fs.ReadFile(p.FS, name)
+	if err != nil {
+		return nil, err
+	}
+	if !HasIncludes(content) {
+		return p.FS.Open(name)
+	}
+	expanded, err := Expand(p.packageRoot, filepath.Join(p.packageRoot, filepath.FromSlash(name)), content)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &expandedFile{
+		Reader: bytes.NewReader(expanded.Content),
+		info:   expandedFileInfo{FileInfo: info, size: int64(len(expanded.Content))},
+	}, nil
+}
+
+type filteredDir struct {
+	fs.ReadDirFile
+	hidden string
+}
+
+func (d *filteredDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries, err := d.ReadDirFile.ReadDir(n)
+	for i, entry := range entries {
+		if entry.Name() == d.hidden {
+			return append(entries[:i], entries[i+1:]...), err
+		}
+	}
+	return entries, err
+}
+
+type expandedFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *expandedFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *expandedFile) Close() error {
+	return nil
+}
+
+type expandedFileInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (i expandedFileInfo) Size() int64 {
+	return i.size
+}
@@ -0,0 +1,110 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package pipelinesnippets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// includeTag matches lines including a pipeline snippet, like `{{> error_handling }}`.
+var includeTag = regexp.MustCompile(`^([ \t]*){{>\s*([\w\-]+)\s*}}[ \t]*$`)
+
+// Line is the source of a line of an expanded pipeline.
+type Line struct {
+	// Path is the path of the file where the line is defined, the pipeline or a snippet.
+	Path string
+
+	// Number is the line number in the file.
+	Number int
+}
+
+// ExpandedPipeline is a pipeline whose snippet includes have been replaced by the content
+// of the snippets.
+type ExpandedPipeline struct {
+	Content []byte
+
+	// Lines contains the source of each line of the expanded content.
+	Lines []Line
+}
+
+// SnippetsDir returns the directory with the pipeline snippets of the package.
+func SnippetsDir(packageRoot string) string {
+	return filepath.Join(packageRoot, "_dev", "build", "pipeline-snippets")
+}
+
+// HasIncludes checks if the pipeline content includes any snippet.
+func HasIncludes(content []byte) bool {
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if includeTag.Match(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand replaces the snippet includes in the content of the pipeline defined in the given path
+// with the snippets of the package. Snippets are YAML files in the pipeline-snippets directory,
+// whose lines are included with the indentation of the include tag. Snippets can include other
+// snippets.
+func Expand(packageRoot, pipelinePath string, content []byte) (*ExpandedPipeline, error) {
+	var expanded ExpandedPipeline
+	var buf bytes.Buffer
+	err := expand(&buf, &expanded.Lines, SnippetsDir(packageRoot), pipelinePath, content, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the trailing line break of the pipeline as is.
+	if !bytes.HasSuffix(content, []byte("\n")) {
+		buf.Truncate(buf.Len() - 1)
+	}
+	expanded.Content = buf.Bytes()
+	return &expanded, nil
+}
+
+func expand(buf *bytes.Buffer, lines *[]Line, snippetsDir, path string, content []byte, indent string, stack []string) error {
+	content = bytes.TrimSuffix(content, []byte("\n"))
+
+	for i, line := range strings.Split(string(content), "\n") {
+		if len(stack) > 0 && strings.TrimSpace(line) == "---" {
+			// Document separators of snippets are not included.
+			continue
+		}
+		match := includeTag.FindStringSubmatch(line)
+		if match == nil {
+			if strings.TrimSpace(line) != "" {
+				buf.WriteString(indent)
+			}
+			buf.WriteString(line)
+			buf.WriteString("\n")
+			*lines = append(*lines, Line{Path: path, Number: i + 1})
+			continue
+		}
+
+		name := match[2]
+		if slices.Contains(stack, name) {
+			return fmt.Errorf("pipeline snippet %q includes itself (path: %s, line: %d)", name, path, i+1)
+		}
+		snippetPath := filepath.Join(snippetsDir, name+".yml")
+		snippet, err := os.ReadFile(snippetPath)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("pipeline snippet %q not found in %s (path: %s, line: %d)", name, snippetsDir, path, i+1)
+		}
+		if err != nil {
+			return fmt.Errorf("can't read pipeline snippet (path: %s): %w", snippetPath, err)
+		}
+		err = expand(buf, lines, snippetsDir, snippetPath, snippet, indent+match[1], append(stack, name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
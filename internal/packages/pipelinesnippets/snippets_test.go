@@ -0,0 +1,141 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package pipelinesnippets
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPipeline = `---
+description: Test pipeline
+processors:
+  - set:
+      field: foo
+      value: bar
+  {{> ecs_version }}
+on_failure:
+  {{> error_handling}}
+`
+
+func createTestPackage(t *testing.T) string {
+	t.Helper()
+	packageRoot := t.TempDir()
+	writeTestFile(t, filepath.Join(packageRoot, "data_stream", "logs", "elasticsearch", "ingest_pipeline", "default.yml"), testPipeline)
+	writeTestFile(t, filepath.Join(SnippetsDir(packageRoot), "ecs_version.yml"), `---
+- set:
+    field: ecs.version
+    value: '8.11.0'
+`)
+	writeTestFile(t, filepath.Join(SnippetsDir(packageRoot), "error_handling.yml"), `- set:
+    field: error.message
+    value: '{{{ _ingest.on_failure_message }}}'
+{{> tag_failure }}
+`)
+	writeTestFile(t, filepath.Join(SnippetsDir(packageRoot), "tag_failure.yml"), `- append:
+    field: tags
+    value: pipeline_error
+`)
+	return packageRoot
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestExpand(t *testing.T) {
+	packageRoot := createTestPackage(t)
+	pipelinePath := filepath.Join(packageRoot, "data_stream", "logs", "elasticsearch", "ingest_pipeline", "default.yml")
+
+	assert.True(t, HasIncludes([]byte(testPipeline)))
+	assert.False(t, HasIncludes([]byte("processors:\n  - set:\n      value: '{{ foo }}'\n")))
+
+	expanded, err := Expand(packageRoot, pipelinePath, []byte(testPipeline))
+	require.NoError(t, err)
+
+	expected := `---
+description: Test pipeline
+processors:
+  - set:
+      field: foo
+      value: bar
+  - set:
+      field: ecs.version
+      value: '8.11.0'
+on_failure:
+  - set:
+      field: error.message
+      value: '{{{ _ingest.on_failure_message }}}'
+  - append:
+      field: tags
+      value: pipeline_error
+`
+	assert.Equal(t, expected, string(expanded.Content))
+
+	ecsVersion := filepath.Join(SnippetsDir(packageRoot), "ecs_version.yml")
+	errorHandling := filepath.Join(SnippetsDir(packageRoot), "error_handling.yml")
+	tagFailure := filepath.Join(SnippetsDir(packageRoot), "tag_failure.yml")
+	expectedLines := []Line{
+		{Path: pipelinePath, Number: 1},
+		{Path: pipelinePath, Number: 2},
+		{Path: pipelinePath, Number: 3},
+		{Path: pipelinePath, Number: 4},
+		{Path: pipelinePath, Number: 5},
+		{Path: pipelinePath, Number: 6},
+		{Path: ecsVersion, Number: 2},
+		{Path: ecsVersion, Number: 3},
+		{Path: ecsVersion, Number: 4},
+		{Path: pipelinePath, Number: 8},
+		{Path: errorHandling, Number: 1},
+		{Path: errorHandling, Number: 2},
+		{Path: errorHandling, Number: 3},
+		{Path: tagFailure, Number: 1},
+		{Path: tagFailure, Number: 2},
+		{Path: tagFailure, Number: 3},
+	}
+	assert.Equal(t, expectedLines, expanded.Lines)
+}
+
+func TestExpandErrors(t *testing.T) {
+	packageRoot := createTestPackage(t)
+
+	_, err := Expand(packageRoot, "default.yml", []byte("processors:\n  {{> unknown }}\n"))
+	assert.ErrorContains(t, err, `pipeline snippet "unknown" not found`)
+
+	writeTestFile(t, filepath.Join(SnippetsDir(packageRoot), "recursive.yml"), "{{> recursive }}\n")
+	_, err = Expand(packageRoot, "default.yml", []byte("processors:\n  {{> recursive }}\n"))
+	assert.ErrorContains(t, err, `pipeline snippet "recursive" includes itself`)
+}
+
+func TestPackageFS(t *testing.T) {
+	packageRoot := createTestPackage(t)
+	writeTestFile(t, filepath.Join(packageRoot, "_dev", "build", "build.yml"), "dependencies: {}\n")
+
+	fsys := PackageFS(packageRoot)
+
+	entries, err := fs.ReadDir(fsys, "_dev/build")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "build.yml", entries[0].Name())
+
+	_, err = fs.Stat(fsys, "_dev/build/pipeline-snippets/ecs_version.yml")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	content, err := fs.ReadFile(fsys, "data_stream/logs/elasticsearch/ingest_pipeline/default.yml")
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "{{>")
+	assert.Contains(t, string(content), "field: ecs.version")
+
+	info, err := fs.Stat(fsys, "data_stream/logs/elasticsearch/ingest_pipeline/default.yml")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), info.Size())
+}
@@ -0,0 +1,102 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package packages
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/elastic/elastic-package/internal/files"
+)
+
+// ExtractPackageRevision extracts a previous revision of the package into the destination
+// directory and returns the root of the extracted package. The revision can be a zip file
+// with a built package, or a git reference of the repository containing the package.
+func ExtractPackageRevision(packageRoot, revision, destDir string) (string, error) {
+	if strings.HasSuffix(revision, ".zip") {
+		if _, err := os.Stat(revision); err == nil {
+			return ExtractZipPackage(revision, destDir)
+		}
+	}
+	return ExtractPackageFromGitReference(packageRoot, revision, destDir)
+}
+
+// ExtractZipPackage extracts a built package into the destination directory and returns
+// the root of the extracted package.
+func ExtractZipPackage(zipPath, destDir string) (string, error) {
+	zipReader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", fmt.Errorf("can't open zip package (path: %s): %w", zipPath, err)
+	}
+	defer zipReader.Close()
+
+	// Built packages contain all the files under a folder named "package-version".
+	manifests, err := fs.Glob(zipReader, "*/"+PackageManifestFile)
+	if err != nil {
+		return "", err
+	}
+	if len(manifests) != 1 {
+		return "", fmt.Errorf("a single package is expected in %s, %d found", zipPath, len(manifests))
+	}
+
+	err = files.UnzipReader(&zipReader.Reader, destDir)
+	if err != nil {
+		return "", fmt.Errorf("extracting zip package failed: %w", err)
+	}
+	return filepath.Join(destDir, path.Dir(manifests[0])), nil
+}
+
+// ExtractPackageFromGitReference extracts the package as it is in the given git reference
+// into the destination directory and returns the root of the extracted package.
+func ExtractPackageFromGitReference(packageRoot, reference, destDir string) (string, error) {
+	output, err := runGit(packageRoot, "rev-parse", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return "", fmt.Errorf("locating package in git repository failed: %w", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	topLevel := lines[0]
+	prefix := ""
+	if len(lines) > 1 {
+		prefix = strings.TrimSuffix(lines[1], "/")
+	}
+
+	// git archive must be executed from the top level directory to read paths out of the current directory.
+	archive, err := runGit(topLevel, "archive", "--format=zip", reference+":"+prefix)
+	if err != nil {
+		return "", fmt.Errorf("reading package from git reference %q failed: %w", reference, err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return "", fmt.Errorf("can't read git archive: %w", err)
+	}
+	err = files.UnzipReader(zipReader, destDir)
+	if err != nil {
+		return "", fmt.Errorf("extracting git archive failed: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(destDir, PackageManifestFile)); err != nil {
+		return "", fmt.Errorf("package not found in git reference %q: %w", reference, err)
+	}
+	return destDir, nil
+}
+
+func runGit(dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
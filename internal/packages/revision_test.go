@@ -0,0 +1,51 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package packages
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractZipPackage(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "test-1.0.0.zip")
+	f, err := os.Create(zipPath)
+	require.NoError(t, err)
+	w := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"test-1.0.0/manifest.yml":                          "name: test\nversion: 1.0.0\n",
+		"test-1.0.0/data_stream/logs/fields/fields.yml":    "- name: foo\n  type: keyword\n",
+		"test-1.0.0/data_stream/logs/manifest.yml":         "title: Logs\n",
+		"test-1.0.0/kibana/dashboard/test-dashboard.json":  "{}",
+		"test-1.0.0/docs/README.md":                        "# Test\n",
+		"test-1.0.0/data_stream/logs/sample_event.json":    "{}",
+		"test-1.0.0/data_stream/logs/agent/stream/log.yml": "paths: []\n",
+	} {
+		fw, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	destDir := t.TempDir()
+	packageRoot, err := ExtractPackageRevision(".", zipPath, destDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(destDir, "test-1.0.0"), packageRoot)
+
+	manifest, err := ReadPackageManifestFromPackageRoot(packageRoot)
+	require.NoError(t, err)
+	assert.Equal(t, "test", manifest.Name)
+
+	content, err := os.ReadFile(filepath.Join(packageRoot, "data_stream", "logs", "fields", "fields.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, "- name: foo\n  type: keyword\n", string(content))
+}
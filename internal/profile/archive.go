@@ -0,0 +1,505 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-package/internal/configuration/locations"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/version"
+)
+
+const (
+	// archiveManifestFile is the file stored in the root of profile archives with
+	// information about how the archive was generated.
+	archiveManifestFile = "elastic-package-profile-export.json"
+
+	// archiveFormatVersion is the version of the format of profile archives.
+	archiveFormatVersion = 1
+
+	// RedactedValue is the value used to replace secrets in exported profiles.
+	RedactedValue = "REDACTED"
+
+	// certificatesDir is the directory containing the CA and certificates in a profile.
+	certificatesDir = "certs"
+)
+
+// secretKeyPatterns are the substrings that identify a setting as a secret.
+var secretKeyPatterns = []string{"password", "api_key", "apikey", "token", "secret"}
+
+// ArchiveManifest contains information about an exported profile.
+type ArchiveManifest struct {
+	FormatVersion         int       `json:"format_version"`
+	ElasticPackageVersion string    `json:"elastic_package_version,omitempty"`
+	ExportedAt            time.Time `json:"exported_at"`
+	Profile               Metadata  `json:"profile"`
+	IncludesCertificates  bool      `json:"includes_certificates"`
+	Redacted              []string  `json:"redacted,omitempty"`
+}
+
+// ExportOptions are the options to export a profile.
+type ExportOptions struct {
+	ProfilesDirPath string
+	Name            string
+
+	// Path is the path of the archive to create.
+	Path string
+
+	// RedactSecrets replaces passwords, API keys and tokens in the exported files.
+	RedactSecrets bool
+
+	// ExcludeCertificates excludes the CA and the certificates from the archive.
+	ExcludeCertificates bool
+}
+
+// ExportProfile writes a profile into a portable zip archive. The profile is exported as
+// it is, without migrating it, profiles are migrated when they are imported.
+func ExportProfile(options ExportOptions) (_ *ArchiveManifest, err error) {
+	if options.ProfilesDirPath == "" {
+		loc, err := locations.NewLocationManager()
+		if err != nil {
+			return nil, fmt.Errorf("error finding profile dir location: %w", err)
+		}
+		options.ProfilesDirPath = loc.ProfileDir()
+	}
+
+	profile, err := loadProfile(options.ProfilesDirPath, options.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile %q: %w", options.Name, err)
+	}
+
+	manifest := ArchiveManifest{
+		FormatVersion:         archiveFormatVersion,
+		ElasticPackageVersion: version.Tag,
+		ExportedAt:            time.Now().UTC(),
+		Profile:               profile.metadata,
+		IncludesCertificates:  !options.ExcludeCertificates,
+	}
+
+	out, err := os.Create(options.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer func() {
+		out.Close()
+		// Don't leave partially written archives.
+		if err != nil {
+			os.Remove(options.Path)
+		}
+	}()
+
+	w := zip.NewWriter(out)
+	err = filepath.WalkDir(profile.ProfilePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(profile.ProfilePath, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if options.ExcludeCertificates && rel == certificatesDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if options.RedactSecrets {
+			var redacted []string
+			content, redacted, err = redactSecrets(rel, content)
+			if err != nil {
+				return fmt.Errorf("failed to redact secrets in %s: %w", rel, err)
+			}
+			for _, key := range redacted {
+				manifest.Redacted = append(manifest.Redacted, rel+": "+key)
+			}
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return writeArchiveFile(w, rel, info.Mode(), content)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add profile files to archive: %w", err)
+	}
+
+	d, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode archive manifest: %w", err)
+	}
+	err = writeArchiveFile(w, archiveManifestFile, 0644, d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add archive manifest: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	return &manifest, nil
+}
+
+func writeArchiveFile(w *zip.Writer, name string, mode fs.FileMode, content []byte) error {
+	header := &zip.FileHeader{
+		Name:   name,
+		Method: zip.Deflate,
+	}
+	header.SetMode(mode)
+	f, err := w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	return err
+}
+
+// ImportOptions are the options to import a profile from an archive.
+type ImportOptions struct {
+	ProfilesDirPath string
+
+	// Path is the path of the archive to import.
+	Path string
+
+	// Name is the name of the new profile, if empty, the name of the exported
+	// profile is used.
+	Name string
+
+	// OverwriteExisting replaces an existing profile with the same name. The existing
+	// profile is kept if the import fails.
+	OverwriteExisting bool
+}
+
+// ImportProfile creates a new profile from an archive created with ExportProfile. The
+// profile is created as a new one, and then the files in the archive are extracted on it.
+// Profile migrations are executed after extracting the files.
+func ImportProfile(options ImportOptions) (*Profile, *ArchiveManifest, error) {
+	if options.ProfilesDirPath == "" {
+		loc, err := locations.NewLocationManager()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error finding profile dir location: %w", err)
+		}
+		options.ProfilesDirPath = loc.ProfileDir()
+	}
+
+	r, err := zip.OpenReader(options.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer r.Close()
+
+	manifest, err := readArchiveManifest(&r.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if options.Name == "" {
+		options.Name = manifest.Profile.Name
+	}
+	if options.Name == "" {
+		return nil, nil, errors.New("profile name not found in archive, a name must be provided")
+	}
+
+	err = validateProfileName(options.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	profileDir := filepath.Join(options.ProfilesDirPath, options.Name)
+	existing, err := loadProfile(options.ProfilesDirPath, options.Name)
+	switch {
+	case err == nil && !options.OverwriteExisting:
+		return nil, nil, fmt.Errorf("profile %q already exists", options.Name)
+	case err == nil:
+		// Only directories of loaded profiles are replaced.
+	case !errors.Is(err, ErrNotAProfile):
+		return nil, nil, fmt.Errorf("failed to check if profile %q exists: %w", options.Name, err)
+	default:
+		if _, err := os.Stat(profileDir); err == nil {
+			return nil, nil, fmt.Errorf("directory %s already exists and it is not a profile", profileDir)
+		}
+	}
+
+	// The profile is imported into a staging directory in the same filesystem, so an existing
+	// profile is only replaced once the import succeeds.
+	err = os.MkdirAll(options.ProfilesDirPath, 0755)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	stagingDir, err := os.MkdirTemp(options.ProfilesDirPath, ".import-"+options.Name+"-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	err = extractProfile(&r.Reader, manifest, stagingDir, options.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if existing != nil {
+		previousDir := filepath.Join(stagingDir, "previous")
+		err = os.Rename(existing.ProfilePath, previousDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to replace existing profile %q: %w", options.Name, err)
+		}
+		defer func() {
+			// Restore the previous profile if the imported one couldn't be moved.
+			if _, statErr := os.Stat(profileDir); errors.Is(statErr, os.ErrNotExist) {
+				os.Rename(previousDir, profileDir)
+			}
+		}()
+	}
+	err = os.Rename(filepath.Join(stagingDir, options.Name), profileDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to move imported profile %q: %w", options.Name, err)
+	}
+
+	profile, err := loadProfile(options.ProfilesDirPath, options.Name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load imported profile: %w", err)
+	}
+	return profile, manifest, nil
+}
+
+// extractProfile creates a profile with the given name in the profiles directory, with the
+// files of the archive. Profile migrations are executed after extracting the files.
+func extractProfile(r *zip.Reader, manifest *ArchiveManifest, profilesDirPath, name string) error {
+	err := CreateProfile(Options{
+		ProfilesDirPath: profilesDirPath,
+		Name:            name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create profile %q: %w", name, err)
+	}
+
+	profileDir := filepath.Join(profilesDirPath, name)
+	err = files.UnzipReader(r, profileDir)
+	if err != nil {
+		return fmt.Errorf("failed to extract profile archive: %w", err)
+	}
+	err = os.Remove(filepath.Join(profileDir, archiveManifestFile))
+	if err != nil {
+		return fmt.Errorf("failed to remove archive manifest: %w", err)
+	}
+
+	// Update metadata so it matches the name of the imported profile.
+	metadata := manifest.Profile
+	metadata.Name = name
+	d, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode profile metadata: %w", err)
+	}
+	err = os.WriteFile(filepath.Join(profileDir, PackageProfileMetaFile), append(d, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write profile metadata: %w", err)
+	}
+
+	profile, err := loadProfile(profilesDirPath, name)
+	if err != nil {
+		return fmt.Errorf("failed to load imported profile: %w", err)
+	}
+	err = profile.migrate(currentVersion)
+	if err != nil {
+		return fmt.Errorf("error migrating profile to version %v: %w", currentVersion, err)
+	}
+	return nil
+}
+
+// validateProfileName checks that the name can be safely used as the directory of a profile.
+func validateProfileName(name string) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.Base(name) != name {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	return nil
+}
+
+func readArchiveManifest(r *zip.Reader) (*ArchiveManifest, error) {
+	f, err := r.Open(archiveManifestFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("not a profile archive, %s not found", archiveManifestFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive manifest: %w", err)
+	}
+	defer f.Close()
+
+	var manifest ArchiveManifest
+	err = json.NewDecoder(f).Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode archive manifest: %w", err)
+	}
+
+	if manifest.FormatVersion > archiveFormatVersion {
+		return nil, fmt.Errorf("archive format version %d not supported, please update elastic-package (supported up to version %d)", manifest.FormatVersion, archiveFormatVersion)
+	}
+	profileVersion, err := strconv.Atoi(manifest.Profile.Version)
+	if err == nil && profileVersion > currentVersion {
+		return nil, fmt.Errorf("profile version %d is newer than the supported one (%d), please update elastic-package", profileVersion, currentVersion)
+	}
+
+	return &manifest, nil
+}
+
+// redactSecrets replaces the values of secret settings in known configuration
+// formats. It returns the modified content and the list of redacted keys.
+func redactSecrets(name string, content []byte) ([]byte, []string, error) {
+	switch ext := path.Ext(name); {
+	case ext == ".json":
+		return redactJSONSecrets(content)
+	case ext == ".yml" || ext == ".yaml":
+		return redactYAMLSecrets(content)
+	case ext == ".env":
+		return redactEnvSecrets(content)
+	}
+	return content, nil, nil
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	return slices.ContainsFunc(secretKeyPatterns, func(pattern string) bool {
+		return strings.Contains(key, pattern)
+	})
+}
+
+func redactJSONSecrets(content []byte) ([]byte, []string, error) {
+	var doc any
+	err := json.Unmarshal(content, &doc)
+	if err != nil {
+		// Not a valid JSON, keep it as is.
+		return content, nil, nil
+	}
+
+	var redacted []string
+	var redact func(prefix string, v any)
+	redact = func(prefix string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for key, value := range v {
+				fullKey := strings.TrimPrefix(prefix+"."+key, ".")
+				if s, ok := value.(string); ok && isSecretKey(key) {
+					if s != "" {
+						v[key] = RedactedValue
+						redacted = append(redacted, fullKey)
+					}
+					continue
+				}
+				redact(fullKey, value)
+			}
+		case []any:
+			for i, value := range v {
+				redact(fmt.Sprintf("%s[%d]", prefix, i), value)
+			}
+		}
+	}
+	redact("", doc)
+	if len(redacted) == 0 {
+		return content, nil, nil
+	}
+	slices.Sort(redacted)
+
+	d, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, redacted, nil
+}
+
+func redactYAMLSecrets(content []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(content, &doc)
+	if err != nil {
+		// Not a valid YAML, keep it as is.
+		return content, nil, nil
+	}
+
+	var redacted []string
+	var redact func(prefix string, n *yaml.Node)
+	redact = func(prefix string, n *yaml.Node) {
+		switch n.Kind {
+		case yaml.DocumentNode, yaml.SequenceNode:
+			for _, c := range n.Content {
+				redact(prefix, c)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key, value := n.Content[i], n.Content[i+1]
+				fullKey := strings.TrimPrefix(prefix+"."+key.Value, ".")
+				if value.Kind == yaml.ScalarNode && isSecretKey(key.Value) {
+					if value.Value != "" {
+						value.Value = RedactedValue
+						value.Tag = "!!str"
+						value.Style = yaml.DoubleQuotedStyle
+						redacted = append(redacted, fullKey)
+					}
+					continue
+				}
+				redact(fullKey, value)
+			}
+		}
+	}
+	redact("", &doc)
+	if len(redacted) == 0 {
+		return content, nil, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(&doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), redacted, nil
+}
+
+func redactEnvSecrets(content []byte) ([]byte, []string, error) {
+	var buf bytes.Buffer
+	var redacted []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		key, value, found := strings.Cut(line, "=")
+		if found && value != "" && !strings.HasPrefix(strings.TrimSpace(key), "#") && isSecretKey(key) {
+			line = key + "=" + RedactedValue
+			redacted = append(redacted, strings.TrimSpace(key))
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(redacted) == 0 {
+		return content, nil, nil
+	}
+	return buf.Bytes(), redacted, nil
+}
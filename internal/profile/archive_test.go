@@ -0,0 +1,259 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package profile
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportProfile(t *testing.T) {
+	profilesDir := t.TempDir()
+	err := CreateProfile(Options{ProfilesDirPath: profilesDir, Name: "source"})
+	require.NoError(t, err)
+
+	profilePath := filepath.Join(profilesDir, "source")
+	writeFile(t, filepath.Join(profilePath, "stack", "config.json"), `{"provider":"compose","elasticsearch_password":"changeme","elasticsearch_host":"https://127.0.0.1:9200"}`)
+	writeFile(t, filepath.Join(profilePath, PackageProfileConfigFile), "# Some comment\nstack.apm_enabled: true\nstack.elastic_cloud.api_key: secret-key\n")
+	writeFile(t, filepath.Join(profilePath, "certs", "ca-cert.pem"), "cert")
+
+	cases := []struct {
+		title               string
+		redact              bool
+		excludeCertificates bool
+		expectedConfigJSON  string
+		expectedRedacted    []string
+	}{
+		{
+			title:              "full copy",
+			expectedConfigJSON: `{"provider":"compose","elasticsearch_password":"changeme","elasticsearch_host":"https://127.0.0.1:9200"}`,
+		},
+		{
+			title:               "redacted without certificates",
+			redact:              true,
+			excludeCertificates: true,
+			expectedConfigJSON:  `{"elasticsearch_host":"https://127.0.0.1:9200","elasticsearch_password":"REDACTED","provider":"compose"}`,
+			expectedRedacted: []string{
+				"config.yml: stack.elastic_cloud.api_key",
+				"stack/config.json: elasticsearch_password",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "profile.zip")
+			manifest, err := ExportProfile(ExportOptions{
+				ProfilesDirPath:     profilesDir,
+				Name:                "source",
+				Path:                archivePath,
+				RedactSecrets:       c.redact,
+				ExcludeCertificates: c.excludeCertificates,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedRedacted, manifest.Redacted)
+
+			importDir := t.TempDir()
+			imported, importedManifest, err := ImportProfile(ImportOptions{
+				ProfilesDirPath: importDir,
+				Path:            archivePath,
+				Name:            "imported",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "imported", imported.metadata.Name)
+			assert.Equal(t, !c.excludeCertificates, importedManifest.IncludesCertificates)
+
+			d, err := os.ReadFile(imported.Path("stack", "config.json"))
+			require.NoError(t, err)
+			assert.Equal(t, c.expectedConfigJSON, string(d))
+
+			if c.excludeCertificates {
+				assert.NoFileExists(t, imported.Path("certs", "ca-cert.pem"))
+			} else {
+				assert.FileExists(t, imported.Path("certs", "ca-cert.pem"))
+			}
+
+			// Importing again fails because the profile already exists.
+			_, _, err = ImportProfile(ImportOptions{
+				ProfilesDirPath: importDir,
+				Path:            archivePath,
+				Name:            "imported",
+			})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestImportProfileInvalidTargets(t *testing.T) {
+	profilesDir := t.TempDir()
+	err := CreateProfile(Options{ProfilesDirPath: profilesDir, Name: "source"})
+	require.NoError(t, err)
+
+	archivePath := filepath.Join(t.TempDir(), "profile.zip")
+	_, err = ExportProfile(ExportOptions{
+		ProfilesDirPath: profilesDir,
+		Name:            "source",
+		Path:            archivePath,
+	})
+	require.NoError(t, err)
+
+	importDir := t.TempDir()
+	for _, name := range []string{"..", ".", "../other", "some/profile", `some\profile`} {
+		_, _, err = ImportProfile(ImportOptions{
+			ProfilesDirPath:   importDir,
+			Path:              archivePath,
+			Name:              name,
+			OverwriteExisting: true,
+		})
+		if assert.Error(t, err, name) {
+			assert.Contains(t, err.Error(), "invalid profile name")
+		}
+	}
+
+	// Directories that are not profiles are not overwritten.
+	notAProfile := filepath.Join(importDir, "not-a-profile")
+	writeFile(t, filepath.Join(notAProfile, "important.txt"), "data")
+	_, _, err = ImportProfile(ImportOptions{
+		ProfilesDirPath:   importDir,
+		Path:              archivePath,
+		Name:              "not-a-profile",
+		OverwriteExisting: true,
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "it is not a profile")
+	}
+	assert.FileExists(t, filepath.Join(notAProfile, "important.txt"))
+}
+
+func TestImportProfileOverwrite(t *testing.T) {
+	profilesDir := t.TempDir()
+	err := CreateProfile(Options{ProfilesDirPath: profilesDir, Name: "source"})
+	require.NoError(t, err)
+
+	archivePath := filepath.Join(t.TempDir(), "profile.zip")
+	_, err = ExportProfile(ExportOptions{
+		ProfilesDirPath: profilesDir,
+		Name:            "source",
+		Path:            archivePath,
+	})
+	require.NoError(t, err)
+
+	importDir := t.TempDir()
+	existing, _, err := ImportProfile(ImportOptions{
+		ProfilesDirPath: importDir,
+		Path:            archivePath,
+		Name:            "imported",
+	})
+	require.NoError(t, err)
+	marker := existing.Path("marker.txt")
+	writeFile(t, marker, "data")
+
+	t.Run("failed import keeps the existing profile", func(t *testing.T) {
+		invalidArchivePath := filepath.Join(t.TempDir(), "invalid.zip")
+		writeInvalidProfileArchive(t, invalidArchivePath)
+
+		_, _, err := ImportProfile(ImportOptions{
+			ProfilesDirPath:   importDir,
+			Path:              invalidArchivePath,
+			Name:              "imported",
+			OverwriteExisting: true,
+		})
+		assert.Error(t, err)
+		assert.FileExists(t, marker)
+		assertOnlyProfileDirs(t, importDir, "imported")
+	})
+
+	t.Run("successful import replaces the existing profile", func(t *testing.T) {
+		imported, _, err := ImportProfile(ImportOptions{
+			ProfilesDirPath:   importDir,
+			Path:              archivePath,
+			Name:              "imported",
+			OverwriteExisting: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, existing.ProfilePath, imported.ProfilePath)
+		assert.NoFileExists(t, marker)
+		assertOnlyProfileDirs(t, importDir, "imported")
+	})
+}
+
+// writeInvalidProfileArchive writes a profile archive with a file that cannot be extracted.
+func writeInvalidProfileArchive(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	manifest, err := json.Marshal(ArchiveManifest{
+		FormatVersion: archiveFormatVersion,
+		Profile:       Metadata{Name: "imported", Version: "1"},
+	})
+	require.NoError(t, err)
+	for name, content := range map[string][]byte{
+		archiveManifestFile: manifest,
+		"../outside.txt":    []byte("data"),
+	} {
+		entry, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = entry.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+}
+
+func assertOnlyProfileDirs(t *testing.T, profilesDir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(profilesDir)
+	require.NoError(t, err)
+	var found []string
+	for _, entry := range entries {
+		found = append(found, entry.Name())
+	}
+	assert.ElementsMatch(t, names, found)
+}
+
+func TestExportProfileRemovesPartialArchive(t *testing.T) {
+	profilesDir := t.TempDir()
+	err := CreateProfile(Options{ProfilesDirPath: profilesDir, Name: "source"})
+	require.NoError(t, err)
+
+	// An unreadable file makes the export fail after creating the archive.
+	unreadable := filepath.Join(profilesDir, "source", "unreadable")
+	writeFile(t, unreadable, "data")
+	require.NoError(t, os.Chmod(unreadable, 0))
+	if _, err := os.ReadFile(unreadable); err == nil {
+		t.Skip("file permissions are not enforced for this user")
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "profile.zip")
+	_, err = ExportProfile(ExportOptions{
+		ProfilesDirPath: profilesDir,
+		Name:            "source",
+		Path:            archivePath,
+	})
+	assert.Error(t, err)
+	assert.NoFileExists(t, archivePath)
+}
+
+func TestRedactYAMLSecretsKeepsComments(t *testing.T) {
+	content := "# Comment\nstack:\n  password: foo\n  apm_enabled: true\n"
+	redacted, keys, err := redactYAMLSecrets([]byte(content))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stack.password"}, keys)
+	assert.Equal(t, "# Comment\nstack:\n  password: \"REDACTED\"\n  apm_enabled: true\n", string(redacted))
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
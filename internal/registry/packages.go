@@ -0,0 +1,32 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// DownloadPackage downloads the zip of a package revision into the destination directory, and
+// returns the path to the downloaded file.
+func (c *Client) DownloadPackage(packageName, version, destinationDir string) (string, error) {
+	fileName := fmt.Sprintf("%s-%s.zip", packageName, version)
+	statusCode, respBody, err := c.get(fmt.Sprintf("/epr/%s/%s", packageName, fileName))
+	if err != nil {
+		return "", fmt.Errorf("could not download package: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return "", fmt.Errorf("could not download package %s-%s; API status code = %d", packageName, version, statusCode)
+	}
+
+	path := filepath.Join(destinationDir, fileName)
+	err = os.WriteFile(path, respBody, 0644)
+	if err != nil {
+		return "", fmt.Errorf("could not write package: %w", err)
+	}
+	return path, nil
+}
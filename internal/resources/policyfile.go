@@ -0,0 +1,123 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/elastic/go-ucfg"
+	"github.com/elastic/go-ucfg/yaml"
+
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+const defaultPolicyNamespace = "default"
+
+// PolicyFile describes an agent policy and its package policies for a package, as
+// defined by users in a YAML file.
+type PolicyFile struct {
+	// Name of the agent policy, a name based on the package name is used by default.
+	Name string `config:"name"`
+
+	// Description of the agent policy.
+	Description string `config:"description"`
+
+	// Namespace of the agent policy, "default" is used by default.
+	Namespace string `config:"namespace"`
+
+	// PackagePolicies contains the package policies to add to the agent policy.
+	PackagePolicies []PolicyFilePackagePolicy `config:"package_policies"`
+}
+
+// PolicyFilePackagePolicy describes a package policy, variables use the same format as
+// in the configuration of system tests.
+type PolicyFilePackagePolicy struct {
+	Name       string         `config:"name"`
+	Template   string         `config:"policy_template"`
+	Input      string         `config:"input"`
+	Vars       map[string]any `config:"vars"`
+	DataStream struct {
+		Name string         `config:"name"`
+		Vars map[string]any `config:"vars"`
+	} `config:"data_stream"`
+}
+
+// ReadPolicyFile reads a policy file from the given path.
+func ReadPolicyFile(path string) (*PolicyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read policy file: %w", err)
+	}
+
+	cfg, err := yaml.NewConfig(data, ucfg.PathSep("."))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load policy file %s: %w", path, err)
+	}
+
+	var f PolicyFile
+	if err := cfg.Unpack(&f); err != nil {
+		return nil, fmt.Errorf("unable to unpack policy file %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// FleetAgentPolicy returns the agent policy resource described by this file for the package
+// in the given root path.
+func (f *PolicyFile) FleetAgentPolicy(packageRootPath string) (*FleetAgentPolicy, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(packageRootPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read package manifest at %s: %w", packageRootPath, err)
+	}
+	if len(f.PackagePolicies) == 0 {
+		return nil, errors.New("no package policies defined")
+	}
+
+	policy := FleetAgentPolicy{
+		Name:        f.Name,
+		Description: f.Description,
+		Namespace:   f.Namespace,
+	}
+	if policy.Name == "" {
+		policy.Name = fmt.Sprintf("%s-%d", manifest.Name, time.Now().Unix())
+	}
+	if policy.Namespace == "" {
+		policy.Namespace = defaultPolicyNamespace
+	}
+
+	names := make(map[string]struct{})
+	for i, packagePolicy := range f.PackagePolicies {
+		name := packagePolicy.Name
+		if name == "" {
+			name = defaultPackagePolicyName(manifest.Name, packagePolicy.DataStream.Name, i)
+		}
+		if _, found := names[name]; found {
+			return nil, fmt.Errorf("duplicated package policy name %q", name)
+		}
+		names[name] = struct{}{}
+
+		policy.PackagePolicies = append(policy.PackagePolicies, FleetPackagePolicy{
+			Name:           name,
+			RootPath:       packageRootPath,
+			TemplateName:   packagePolicy.Template,
+			DataStreamName: packagePolicy.DataStream.Name,
+			InputName:      packagePolicy.Input,
+			Vars:           packagePolicy.Vars,
+			DataStreamVars: packagePolicy.DataStream.Vars,
+		})
+	}
+
+	return &policy, nil
+}
+
+func defaultPackagePolicyName(packageName, dataStream string, index int) string {
+	name := packageName
+	if dataStream != "" {
+		name += "-" + dataStream
+	}
+	return fmt.Sprintf("%s-%d", name, index+1)
+}
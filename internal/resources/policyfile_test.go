@@ -0,0 +1,123 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package resources
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nginxPackageRootPath = "../../test/packages/parallel/nginx"
+
+func TestPolicyFile(t *testing.T) {
+	path := writePolicyFile(t, `
+name: nginx-test
+namespace: testing
+package_policies:
+  - name: nginx-status
+    input: nginx/metrics
+    vars:
+      hosts:
+        - http://127.0.0.1
+    data_stream:
+      name: stubstatus
+      vars:
+        period: 5s
+  - data_stream:
+      name: access
+`)
+
+	f, err := ReadPolicyFile(path)
+	require.NoError(t, err)
+
+	policy, err := f.FleetAgentPolicy(nginxPackageRootPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "nginx-test", policy.Name)
+	assert.Equal(t, "testing", policy.Namespace)
+	assert.Equal(t, []FleetPackagePolicy{
+		{
+			Name:           "nginx-status",
+			RootPath:       nginxPackageRootPath,
+			DataStreamName: "stubstatus",
+			InputName:      "nginx/metrics",
+			Vars:           map[string]any{"hosts": []any{"http://127.0.0.1"}},
+			DataStreamVars: map[string]any{"period": "5s"},
+		},
+		{
+			Name:           "nginx-access-2",
+			RootPath:       nginxPackageRootPath,
+			DataStreamName: "access",
+		},
+	}, policy.PackagePolicies)
+}
+
+func TestPolicyFileDefaults(t *testing.T) {
+	path := writePolicyFile(t, `
+package_policies:
+  - data_stream.name: stubstatus
+`)
+
+	f, err := ReadPolicyFile(path)
+	require.NoError(t, err)
+
+	policy, err := f.FleetAgentPolicy(nginxPackageRootPath)
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(policy.Name, "nginx-"))
+	assert.Equal(t, "default", policy.Namespace)
+	require.Len(t, policy.PackagePolicies, 1)
+	assert.Equal(t, "nginx-stubstatus-1", policy.PackagePolicies[0].Name)
+}
+
+func TestPolicyFileErrors(t *testing.T) {
+	cases := []struct {
+		title    string
+		content  string
+		expected string
+	}{
+		{
+			title:    "no package policies",
+			content:  `name: nginx-test`,
+			expected: "no package policies defined",
+		},
+		{
+			title: "duplicated names",
+			content: `
+package_policies:
+  - name: nginx
+    data_stream.name: stubstatus
+  - name: nginx
+    data_stream.name: access
+`,
+			expected: `duplicated package policy name "nginx"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			f, err := ReadPolicyFile(writePolicyFile(t, c.content))
+			require.NoError(t, err)
+
+			_, err = f.FleetAgentPolicy(nginxPackageRootPath)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), c.expected)
+			}
+		})
+	}
+}
+
+func writePolicyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yml")
+	err := os.WriteFile(path, []byte(content), 0644)
+	require.NoError(t, err)
+	return path
+}
@@ -0,0 +1,304 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package seed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+const (
+	// DefaultNamespace is the namespace of the data streams where documents are indexed by default.
+	DefaultNamespace = "default"
+
+	// DefaultTimeWindow is the default period of time over which documents are distributed.
+	DefaultTimeWindow = 24 * time.Hour
+
+	// DefaultEventsPerDataStream is the default number of documents indexed in each data stream.
+	DefaultEventsPerDataStream = 500
+
+	bulkBatchSize = 500
+
+	// noPipeline disables the default pipeline of the data stream in bulk requests.
+	noPipeline = "_none"
+)
+
+// Options contains the options to seed a stack with documents for a package.
+type Options struct {
+	ESAPI           *elasticsearch.API
+	PackageRootPath string
+
+	// DataStreams are the data streams to seed, all data streams of the package are seeded if empty.
+	DataStreams []string
+
+	Namespace           string
+	TimeWindow          time.Duration
+	EventsPerDataStream int
+
+	// Now is the end of the time window, current time is used if not set.
+	Now time.Time
+}
+
+// DataStreamResult summarizes the documents indexed in a data stream.
+type DataStreamResult struct {
+	DataStream string
+	Source     Source
+	Documents  int
+}
+
+// Seed indexes documents in the data streams of a package, distributing them over a time window
+// that ends now. Documents are generated with the corpus generator of benchmark scenarios, or taken
+// from the expected results of pipeline tests or the sample events of the data streams. The package
+// needs to be installed.
+func Seed(ctx context.Context, options Options) ([]DataStreamResult, error) {
+	if options.Namespace == "" {
+		options.Namespace = DefaultNamespace
+	}
+	if options.TimeWindow <= 0 {
+		options.TimeWindow = DefaultTimeWindow
+	}
+	if options.EventsPerDataStream <= 0 {
+		options.EventsPerDataStream = DefaultEventsPerDataStream
+	}
+	if options.Now.IsZero() {
+		options.Now = time.Now()
+	}
+
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(options.PackageRootPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed: %w", err)
+	}
+
+	dataStreams := options.DataStreams
+	if len(dataStreams) == 0 {
+		paths, err := filepath.Glob(filepath.Join(options.PackageRootPath, "data_stream", "*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			dataStreams = append(dataStreams, filepath.Base(path))
+		}
+	}
+
+	var results []DataStreamResult
+	for _, dataStream := range dataStreams {
+		dsManifest, err := packages.ReadDataStreamManifestFromPackageRoot(options.PackageRootPath, dataStream)
+		if err != nil {
+			return nil, fmt.Errorf("reading manifest of data stream %q failed: %w", dataStream, err)
+		}
+
+		source, err := findSource(ctx, options.PackageRootPath, dataStream, options.EventsPerDataStream)
+		if err != nil {
+			return nil, fmt.Errorf("looking for documents for data stream %q failed: %w", dataStream, err)
+		}
+		if source == nil {
+			logger.Debugf("No source of documents found for data stream %q, skipping", dataStream)
+			continue
+		}
+
+		target := newTargetDataStream(manifest.Name, dsManifest, options.Namespace)
+		count, err := seedDataStream(ctx, options, target, source)
+		source.close()
+		if err != nil {
+			return nil, fmt.Errorf("seeding data stream %q failed: %w", target.name(), err)
+		}
+
+		results = append(results, DataStreamResult{
+			DataStream: target.name(),
+			Source:     source.kind,
+			Documents:  count,
+		})
+	}
+
+	return results, nil
+}
+
+type targetDataStream struct {
+	Type      string
+	Dataset   string
+	Namespace string
+}
+
+func newTargetDataStream(packageName string, manifest *packages.DataStreamManifest, namespace string) targetDataStream {
+	dataset := manifest.Dataset
+	if dataset == "" {
+		dataset = fmt.Sprintf("%s.%s", packageName, manifest.Name)
+	}
+	return targetDataStream{
+		Type:      manifest.Type,
+		Dataset:   dataset,
+		Namespace: namespace,
+	}
+}
+
+func (t targetDataStream) name() string {
+	return fmt.Sprintf("%s-%s-%s", t.Type, t.Dataset, t.Namespace)
+}
+
+func seedDataStream(ctx context.Context, options Options, target targetDataStream, source *documentSource) (int, error) {
+	// Documents not coming from generators are already processed, so they are indexed
+	// without going through the ingest pipeline again.
+	pipeline := noPipeline
+	if source.kind == SourceGenerator {
+		pipeline = ""
+	}
+
+	timestamps := distributeTimestamps(options.Now, options.TimeWindow, options.EventsPerDataStream)
+
+	var buf bytes.Buffer
+	var body strings.Builder
+	indexed := 0
+	pending := 0
+	for i, timestamp := range timestamps {
+		document, err := source.next(i, &buf)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return indexed, err
+		}
+
+		err = prepareDocument(document, target, timestamp)
+		if err != nil {
+			return indexed, err
+		}
+
+		src, err := json.Marshal(document)
+		if err != nil {
+			return indexed, fmt.Errorf("failed to marshal document: %w", err)
+		}
+		body.WriteString(fmt.Sprintf("{\"create\":{\"_index\":\"%s\"}}\n", target.name()))
+		body.Write(src)
+		body.WriteString("\n")
+		pending++
+
+		if pending == bulkBatchSize {
+			if err := performBulkRequest(ctx, options.ESAPI, body.String(), pipeline); err != nil {
+				return indexed, err
+			}
+			indexed += pending
+			pending = 0
+			body.Reset()
+		}
+	}
+
+	if pending > 0 {
+		if err := performBulkRequest(ctx, options.ESAPI, body.String(), pipeline); err != nil {
+			return indexed, err
+		}
+		indexed += pending
+	}
+
+	return indexed, nil
+}
+
+// distributeTimestamps returns count timestamps sorted in time and distributed over the time window
+// that ends at now. Some jitter is added so documents don't look artificially regular.
+func distributeTimestamps(now time.Time, window time.Duration, count int) []time.Time {
+	if count <= 0 {
+		return nil
+	}
+	step := window / time.Duration(count)
+	start := now.Add(-window)
+	timestamps := make([]time.Time, count)
+	for i := range timestamps {
+		var jitter time.Duration
+		if step > 0 {
+			jitter = time.Duration(rand.Int63n(int64(step)))
+		}
+		timestamps[i] = start.Add(time.Duration(i)*step + jitter)
+	}
+	return timestamps
+}
+
+// prepareDocument sets the timestamp of the document and makes its data stream fields match the
+// data stream where it is going to be indexed.
+func prepareDocument(document common.MapStr, target targetDataStream, timestamp time.Time) error {
+	// Timestamps of previous ingestions don't make sense for the new document.
+	_ = document.Delete("event.ingested")
+
+	fields := map[string]interface{}{
+		"@timestamp":            timestamp.UTC().Format(time.RFC3339Nano),
+		"data_stream.type":      target.Type,
+		"data_stream.dataset":   target.Dataset,
+		"data_stream.namespace": target.Namespace,
+	}
+	for key, value := range fields {
+		if _, err := document.Put(key, value); err != nil {
+			return fmt.Errorf("failed to set %q: %w", key, err)
+		}
+	}
+
+	if dataset, err := document.GetValue("event.dataset"); err == nil && dataset != nil {
+		if _, err := document.Put("event.dataset", target.Dataset); err != nil {
+			return fmt.Errorf("failed to set \"event.dataset\": %w", err)
+		}
+	}
+	return nil
+}
+
+func performBulkRequest(ctx context.Context, esAPI *elasticsearch.API, body string, pipeline string) error {
+	opts := []func(*elasticsearch.BulkRequest){
+		esAPI.Bulk.WithContext(ctx),
+	}
+	if pipeline != "" {
+		opts = append(opts, esAPI.Bulk.WithPipeline(pipeline))
+	}
+	resp, err := esAPI.Bulk(strings.NewReader(body), opts...)
+	if err != nil {
+		return fmt.Errorf("bulk request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return fmt.Errorf("bulk request failed: %s", resp.String())
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	failed := 0
+	var firstError string
+	for _, item := range result.Items {
+		for _, action := range item {
+			if action.Error == nil {
+				continue
+			}
+			if failed == 0 {
+				firstError = fmt.Sprintf("%s: %s", action.Error.Type, action.Error.Reason)
+			}
+			failed++
+		}
+	}
+	return fmt.Errorf("%d documents failed to be indexed (first error: %s)", failed, firstError)
+}
@@ -0,0 +1,128 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package seed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/common"
+)
+
+func TestFindSource(t *testing.T) {
+	packageRoot := t.TempDir()
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "sample", "sample_event.json"), `{"message":"sample"}`)
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "tested", "sample_event.json"), `{"message":"sample"}`)
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "tested", "_dev", "test", "pipeline", "test-a.log-expected.json"), `{"expected":[{"message":"a"},null,{"message":"b"}]}`)
+	writeFile(t, filepath.Join(packageRoot, "data_stream", "generated", "sample_event.json"), `{"message":"sample"}`)
+	writeFile(t, filepath.Join(packageRoot, "_dev", "benchmark", "rally", "generated-benchmark.yml"), `
+data_stream:
+  name: generated
+corpora:
+  generator:
+    template:
+      type: gotext
+      raw: '{"message":"generated","count":{{ generate "count" }}}'
+    fields:
+      path: fields.yml
+`)
+	writeFile(t, filepath.Join(packageRoot, "_dev", "benchmark", "rally", "fields.yml"), `
+- name: count
+  type: long
+`)
+	require.NoError(t, os.MkdirAll(filepath.Join(packageRoot, "data_stream", "empty"), 0755))
+
+	cases := []struct {
+		dataStream       string
+		expectedKind     Source
+		expectedMessages []string
+	}{
+		{"sample", SourceSampleEvent, []string{"sample", "sample"}},
+		{"tested", SourcePipelineTests, []string{"a", "b", "a"}},
+		{"generated", SourceGenerator, []string{"generated", "generated"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.dataStream, func(t *testing.T) {
+			source, err := findSource(context.Background(), packageRoot, c.dataStream, len(c.expectedMessages))
+			require.NoError(t, err)
+			require.NotNil(t, source)
+			defer source.close()
+			assert.Equal(t, c.expectedKind, source.kind)
+
+			var buf bytes.Buffer
+			for i, expected := range c.expectedMessages {
+				document, err := source.next(i, &buf)
+				require.NoError(t, err)
+				assert.Equal(t, expected, document["message"])
+			}
+		})
+	}
+
+	t.Run("no source", func(t *testing.T) {
+		source, err := findSource(context.Background(), packageRoot, "empty", 10)
+		require.NoError(t, err)
+		assert.Nil(t, source)
+	})
+}
+
+func TestDistributeTimestamps(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	window := 24 * time.Hour
+	timestamps := distributeTimestamps(now, window, 100)
+	require.Len(t, timestamps, 100)
+
+	for i, timestamp := range timestamps {
+		assert.False(t, timestamp.Before(now.Add(-window)), "timestamp before the time window")
+		assert.True(t, timestamp.Before(now), "timestamp after the time window")
+		if i > 0 {
+			assert.False(t, timestamp.Before(timestamps[i-1]), "timestamps not sorted")
+		}
+	}
+}
+
+func TestPrepareDocument(t *testing.T) {
+	var document common.MapStr
+	err := json.Unmarshal([]byte(`{
+		"@timestamp": "2020-01-01T00:00:00.000Z",
+		"data_stream": {"type": "logs", "dataset": "other", "namespace": "ep"},
+		"event": {"dataset": "other", "ingested": "2020-01-01T00:00:01.000Z", "kind": "event"}
+	}`), &document)
+	require.NoError(t, err)
+
+	target := targetDataStream{Type: "logs", Dataset: "nginx.access", Namespace: "default"}
+	timestamp := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	err = prepareDocument(document, target, timestamp)
+	require.NoError(t, err)
+
+	expected := common.MapStr{
+		"@timestamp": "2024-01-01T12:00:00Z",
+		"data_stream": map[string]interface{}{
+			"type":      "logs",
+			"dataset":   "nginx.access",
+			"namespace": "default",
+		},
+		"event": map[string]interface{}{
+			"dataset": "nginx.access",
+			"kind":    "event",
+		},
+	}
+	assert.Equal(t, expected, document)
+	assert.Equal(t, "logs-nginx.access-default", target.name())
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
@@ -0,0 +1,287 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package seed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/fields"
+	ucfgyaml "github.com/elastic/go-ucfg/yaml"
+
+	"github.com/elastic/elastic-package/internal/common"
+	"github.com/elastic/elastic-package/internal/formatter"
+	"github.com/elastic/elastic-package/internal/logger"
+)
+
+// Source is the kind of source used to generate the documents of a data stream.
+type Source string
+
+const (
+	// SourceGenerator generates documents with the corpus generator configured in benchmark scenarios.
+	SourceGenerator Source = "generator"
+	// SourcePipelineTests uses the expected documents of pipeline tests.
+	SourcePipelineTests Source = "pipeline-tests"
+	// SourceSampleEvent uses the sample event of the data stream.
+	SourceSampleEvent Source = "sample-event"
+)
+
+const (
+	sampleEventFile         = "sample_event.json"
+	pipelineTestsPath       = "_dev/test/pipeline"
+	expectedTestResultSufix = "-expected.json"
+)
+
+// benchmarkScenarioPaths are the paths, relative to the package root, where benchmark scenarios
+// with corpus generator configurations can be found.
+var benchmarkScenarioPaths = []string{
+	"_dev/benchmark/rally",
+	"_dev/benchmark/system",
+}
+
+// documentSource provides the documents to index in a data stream.
+type documentSource struct {
+	kind Source
+
+	// documents are used as templates when the source is not a generator. These documents
+	// are already processed by the ingest pipeline.
+	documents []json.RawMessage
+
+	// generator emits raw documents that still need to be processed by the ingest pipeline.
+	generator genlib.Generator
+}
+
+// findSource looks for the best source of documents available for the given data stream.
+// Corpus generators are preferred, as they produce more diverse data, followed by the
+// expected results of pipeline tests and finally the sample event.
+func findSource(ctx context.Context, packageRootPath, dataStream string, count int) (*documentSource, error) {
+	generator, err := findGenerator(ctx, packageRootPath, dataStream, count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize corpus generator: %w", err)
+	}
+	if generator != nil {
+		return &documentSource{kind: SourceGenerator, generator: generator}, nil
+	}
+
+	dataStreamPath := filepath.Join(packageRootPath, "data_stream", dataStream)
+	documents, err := readPipelineTestsExpectedDocuments(filepath.Join(dataStreamPath, pipelineTestsPath))
+	if err != nil {
+		return nil, err
+	}
+	if len(documents) > 0 {
+		return &documentSource{kind: SourcePipelineTests, documents: documents}, nil
+	}
+
+	documents, err = readSampleEvent(filepath.Join(dataStreamPath, sampleEventFile))
+	if err != nil {
+		return nil, err
+	}
+	if len(documents) > 0 {
+		return &documentSource{kind: SourceSampleEvent, documents: documents}, nil
+	}
+
+	return nil, nil
+}
+
+func readPipelineTestsExpectedDocuments(testsPath string) ([]json.RawMessage, error) {
+	expectedFiles, err := filepath.Glob(filepath.Join(testsPath, "*"+expectedTestResultSufix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(expectedFiles)
+
+	var documents []json.RawMessage
+	for _, path := range expectedFiles {
+		d, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read expected results %s: %w", path, err)
+		}
+
+		var expected struct {
+			Expected []json.RawMessage `json:"expected"`
+		}
+		err = formatter.JSONUnmarshalUsingNumber(d, &expected)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal expected results %s: %w", path, err)
+		}
+
+		for _, document := range expected.Expected {
+			// Pipeline tests can expect null documents when events are dropped.
+			if len(document) == 0 || string(document) == "null" {
+				continue
+			}
+			documents = append(documents, document)
+		}
+	}
+	return documents, nil
+}
+
+func readSampleEvent(path string) ([]json.RawMessage, error) {
+	d, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sample event: %w", err)
+	}
+	return []json.RawMessage{d}, nil
+}
+
+type benchmarkScenario struct {
+	DataStream struct {
+		Name string `config:"name"`
+	} `config:"data_stream"`
+	Corpora struct {
+		Generator *struct {
+			Template struct {
+				Raw  string `config:"raw"`
+				Path string `config:"path"`
+				Type string `config:"type"`
+			} `config:"template"`
+			Config corporaAsset `config:"config"`
+			Fields corporaAsset `config:"fields"`
+		} `config:"generator"`
+	} `config:"corpora"`
+}
+
+type corporaAsset struct {
+	Raw  map[string]interface{} `config:"raw"`
+	Path string                 `config:"path"`
+}
+
+// findGenerator returns a corpus generator for the first benchmark scenario of the data stream
+// that defines one. It returns nil if there is none.
+func findGenerator(ctx context.Context, packageRootPath, dataStream string, count int) (genlib.Generator, error) {
+	for _, scenariosPath := range benchmarkScenarioPaths {
+		scenariosPath = filepath.Join(packageRootPath, scenariosPath)
+		scenarioFiles, err := filepath.Glob(filepath.Join(scenariosPath, "*.yml"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(scenarioFiles)
+
+		for _, scenarioFile := range scenarioFiles {
+			// Other files, like generator configurations, can be stored along with the scenarios,
+			// ignore the files that cannot be read as scenarios.
+			cfg, err := ucfgyaml.NewConfigWithFile(scenarioFile)
+			if err != nil {
+				logger.Debugf("Ignoring %s, can't load it as benchmark scenario: %v", scenarioFile, err)
+				continue
+			}
+			var scenario benchmarkScenario
+			if err := cfg.Unpack(&scenario); err != nil {
+				logger.Debugf("Ignoring %s, can't unpack it as benchmark scenario: %v", scenarioFile, err)
+				continue
+			}
+			if scenario.DataStream.Name != dataStream || scenario.Corpora.Generator == nil {
+				continue
+			}
+
+			logger.Debugf("Using corpus generator from benchmark scenario %s", scenarioFile)
+			return newGenerator(ctx, scenariosPath, &scenario, count)
+		}
+	}
+	return nil, nil
+}
+
+func newGenerator(ctx context.Context, scenariosPath string, scenario *benchmarkScenario, count int) (genlib.Generator, error) {
+	definition := scenario.Corpora.Generator
+
+	configData, err := readCorporaAsset(scenariosPath, definition.Config)
+	if err != nil {
+		return nil, fmt.Errorf("can't read generator config: %w", err)
+	}
+	cfg, err := config.LoadConfigFromYaml(configData)
+	if err != nil {
+		return nil, fmt.Errorf("can't get generator config: %w", err)
+	}
+
+	fieldsData, err := readCorporaAsset(scenariosPath, definition.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("can't read generator fields: %w", err)
+	}
+	fields, err := fields.LoadFieldsWithTemplateFromString(ctx, string(fieldsData))
+	if err != nil {
+		return nil, fmt.Errorf("could not load fields yaml: %w", err)
+	}
+
+	tpl := []byte(definition.Template.Raw)
+	if definition.Template.Path != "" {
+		tpl, err = os.ReadFile(os.ExpandEnv(filepath.Join(scenariosPath, definition.Template.Path)))
+		if err != nil {
+			return nil, fmt.Errorf("can't read template file: %w", err)
+		}
+	}
+
+	genlib.InitGeneratorTimeNow(time.Now())
+	genlib.InitGeneratorRandSeed(time.Now().UnixNano())
+
+	switch definition.Template.Type {
+	default:
+		logger.Debugf("unknown generator template type %q, defaulting to \"placeholder\"", definition.Template.Type)
+		fallthrough
+	case "", "placeholder":
+		return genlib.NewGeneratorWithCustomTemplate(tpl, cfg, fields, uint64(count))
+	case "gotext":
+		return genlib.NewGeneratorWithTextTemplate(tpl, cfg, fields, uint64(count))
+	}
+}
+
+func readCorporaAsset(scenariosPath string, asset corporaAsset) ([]byte, error) {
+	if asset.Path != "" {
+		return os.ReadFile(os.ExpandEnv(filepath.Join(scenariosPath, asset.Path)))
+	}
+	if len(asset.Raw) > 0 {
+		return yaml.Marshal(asset.Raw)
+	}
+	return nil, nil
+}
+
+// next returns the i-th document of the source, or io.EOF if there are no more documents.
+func (s *documentSource) next(i int, buf *bytes.Buffer) (common.MapStr, error) {
+	var raw []byte
+	if s.generator != nil {
+		buf.Reset()
+		if err := s.generator.Emit(buf); err != nil {
+			return nil, err
+		}
+		raw = bytes.TrimSpace(buf.Bytes())
+	} else {
+		raw = s.documents[i%len(s.documents)]
+	}
+
+	var document common.MapStr
+	err := formatter.JSONUnmarshalUsingNumber(raw, &document)
+	if err != nil {
+		if s.generator != nil {
+			// Templates can generate events that are not JSON, use them as messages.
+			return common.MapStr{"message": strings.TrimSpace(string(raw))}, nil
+		}
+		return nil, fmt.Errorf("failed to unmarshal document: %w", err)
+	}
+	return document, nil
+}
+
+// close releases the resources of the source.
+func (s *documentSource) close() {
+	if s.generator != nil {
+		if err := s.generator.Close(); err != nil && !errors.Is(err, io.EOF) {
+			logger.Debugf("failed to close generator: %v", err)
+		}
+	}
+}
@@ -35,14 +35,12 @@ func DockerComposeProjectName(profile *profile.Profile) string {
 func BootUp(ctx context.Context, options Options) error {
 	// Print information before starting the stack, for cases where
 	// this is executed in the foreground, without daemon mode.
-	config := Config{
-		Provider:              ProviderCompose,
-		ElasticsearchHost:     "https://127.0.0.1:9200",
-		ElasticsearchUsername: elasticsearchUsername,
-		ElasticsearchPassword: elasticsearchPassword,
-		KibanaHost:            "https://127.0.0.1:5601",
-		CACertFile:            options.Profile.Path(CACertificateFile),
+	config, err := defaultConfig(options.Profile)
+	if err != nil {
+		return err
 	}
+	config.Provider = ProviderCompose
+	config.CACertFile = options.Profile.Path(CACertificateFile)
 	printUserConfig(options.Printer, config)
 
 	buildPackagesPath, found, err := builder.FindBuildPackagesDirectory()
@@ -75,7 +73,12 @@ func BootUp(ctx context.Context, options Options) error {
 		options.Printer.Printf("- Local directory %s\n", buildPackagesPath)
 	}
 
-	err = applyResources(options.Profile, options.StackVersion)
+	images, err := resolveStackImages(options)
+	if err != nil {
+		return err
+	}
+
+	err = applyResources(options.Profile, options.StackVersion, images.PackageRegistry)
 	if err != nil {
 		return fmt.Errorf("creating stack files failed: %w", err)
 	}
@@ -113,6 +116,17 @@ func BootUp(ctx context.Context, options Options) error {
 		return fmt.Errorf("failed to store config: %w", err)
 	}
 
+	err = lockStackImages(options, images)
+	if err != nil {
+		return fmt.Errorf("failed to lock images: %w", err)
+	}
+
+	// Containers may have been recreated, connect them again with linked stacks.
+	err = connectRemoteClusters(options.Profile)
+	if err != nil {
+		options.Printer.Printf("Failed to connect with linked stacks: %v\n", err)
+	}
+
 	return nil
 }
 
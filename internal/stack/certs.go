@@ -14,6 +14,7 @@ import (
 	"github.com/elastic/go-resource"
 
 	"github.com/elastic/elastic-package/internal/certs"
+	"github.com/elastic/elastic-package/internal/profile"
 )
 
 type tlsService struct {
@@ -201,3 +202,21 @@ func verifyTLSCertificates(caFile, certFile, keyFile string, service tlsService)
 
 	return nil
 }
+
+// InitCertificates creates the CA and the certificates for all the services in
+// the given profile, if they are not present or they are not valid.
+func InitCertificates(profile *profile.Profile) error {
+	resourceManager := resource.NewManager()
+	resourceManager.RegisterProvider(CertsFolder, &resource.FileProvider{
+		Prefix: profile.ProfilePath,
+	})
+	certResources, err := initTLSCertificates(CertsFolder, profile.ProfilePath, tlsServices)
+	if err != nil {
+		return fmt.Errorf("failed to create TLS files: %w", err)
+	}
+	_, err = resourceManager.Apply(certResources)
+	if err != nil {
+		return fmt.Errorf("failed to write TLS files: %w", err)
+	}
+	return nil
+}
@@ -56,14 +56,14 @@ func dockerComposeBuild(ctx context.Context, options Options) error {
 		return fmt.Errorf("could not create docker compose project: %w", err)
 	}
 
-	appConfig, err := install.Configuration(install.OptionWithStackVersion(options.StackVersion))
+	images, err := resolveStackImages(options)
 	if err != nil {
-		return fmt.Errorf("can't read application configuration: %w", err)
+		return err
 	}
 
 	opts := compose.CommandOptions{
 		Env: newEnvBuilder().
-			withEnvs(appConfig.StackImageRefs().AsEnv()).
+			withEnvs(images.AsEnv()).
 			withEnv(stackVariantAsEnv(options.StackVersion)).
 			withEnvs(options.Profile.ComposeEnvVars()).
 			build(),
@@ -82,14 +82,14 @@ func dockerComposePull(ctx context.Context, options Options) error {
 		return fmt.Errorf("could not create docker compose project: %w", err)
 	}
 
-	appConfig, err := install.Configuration(install.OptionWithStackVersion(options.StackVersion))
+	images, err := resolveStackImages(options)
 	if err != nil {
-		return fmt.Errorf("can't read application configuration: %w", err)
+		return err
 	}
 
 	opts := compose.CommandOptions{
 		Env: newEnvBuilder().
-			withEnvs(appConfig.StackImageRefs().AsEnv()).
+			withEnvs(images.AsEnv()).
 			withEnv(stackVariantAsEnv(options.StackVersion)).
 			withEnvs(options.Profile.ComposeEnvVars()).
 			build(),
@@ -113,14 +113,14 @@ func dockerComposeUp(ctx context.Context, options Options) error {
 		args = append(args, "-d")
 	}
 
-	appConfig, err := install.Configuration(install.OptionWithStackVersion(options.StackVersion))
+	images, err := resolveStackImages(options)
 	if err != nil {
-		return fmt.Errorf("can't read application configuration: %w", err)
+		return err
 	}
 
 	opts := compose.CommandOptions{
 		Env: newEnvBuilder().
-			withEnvs(appConfig.StackImageRefs().AsEnv()).
+			withEnvs(images.AsEnv()).
 			withEnv(stackVariantAsEnv(options.StackVersion)).
 			withEnvs(options.Profile.ComposeEnvVars()).
 			build(),
@@ -230,9 +230,11 @@ func newServiceStatus(description *docker.ContainerDescription) (*ServiceStatus,
 }
 
 func getVersionFromDockerImage(dockerImage string) string {
-	_, version, found := strings.Cut(dockerImage, ":")
-	if found {
-		return version
+	// Images of locked stacks are referenced by tag and digest, as in "name:tag@sha256:...".
+	dockerImage, _, _ = strings.Cut(dockerImage, "@")
+	// Tags come after the last colon, if it is not part of the registry host with port.
+	if i := strings.LastIndex(dockerImage, ":"); i > strings.LastIndex(dockerImage, "/") {
+		return dockerImage[i+1:]
 	}
 	return "latest"
 }
@@ -21,6 +21,9 @@ func TestGetVersionFromDockerImage(t *testing.T) {
 	}{
 		{"docker.test/test:1.42.0", "1.42.0"},
 		{"docker.test/test", "latest"},
+		{"docker.test:5000/test:1.42.0", "1.42.0"},
+		{"docker.test:5000/test", "latest"},
+		{"docker.test/test:1.42.0@sha256:0123456789abcdef", "1.42.0"},
 	}
 
 	for _, c := range cases {
@@ -43,22 +43,30 @@ func configPath(profile *profile.Profile) string {
 	return profile.Path(ProfileStackPath, configFileName)
 }
 
-func defaultConfig() Config {
+// defaultConfig returns the configuration of a local stack. Hosts use the ports published
+// by the compose provider, so they include the ports offset of the profile.
+func defaultConfig(profile *profile.Profile) (Config, error) {
+	portsOffset, err := publishedPortsOffset(profile)
+	if err != nil {
+		return Config{}, err
+	}
 	return Config{
 		Provider: DefaultProvider,
 
-		// Hard-coded default values for backwards-compatibility.
-		ElasticsearchHost:     "https://127.0.0.1:9200",
+		ElasticsearchHost:     fmt.Sprintf("https://127.0.0.1:%d", 9200+portsOffset),
 		ElasticsearchUsername: elasticsearchUsername,
 		ElasticsearchPassword: elasticsearchPassword,
-		KibanaHost:            "https://127.0.0.1:5601",
-	}
+		KibanaHost:            fmt.Sprintf("https://127.0.0.1:%d", 5601+portsOffset),
+	}, nil
 }
 
 func LoadConfig(profile *profile.Profile) (Config, error) {
 	d, err := os.ReadFile(configPath(profile))
 	if errors.Is(err, os.ErrNotExist) {
-		config := defaultConfig()
+		config, err := defaultConfig(profile)
+		if err != nil {
+			return Config{}, err
+		}
 		caCertFile := profile.Path(CACertificateFile)
 		// Use CA file in the profile only if it exists.
 		if _, err := os.Stat(caCertFile); err == nil {
@@ -0,0 +1,183 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-package/internal/docker"
+	"github.com/elastic/elastic-package/internal/install"
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+// ImagesLockFile is the file, in the stack directory of the profile, where the digests of the
+// images used by the stack are recorded.
+const ImagesLockFile = "images.lock.json"
+
+// ImagesLock contains the digests of the images used by the stack, for each stack version.
+type ImagesLock struct {
+	Versions map[string]LockedImages `json:"versions"`
+}
+
+// LockedImages contains image references by tag and digest, as "name:tag@sha256:...". The
+// tag is informative, so the version of the services can be known. The Elastic Agent image
+// is used by both Elastic Agent and Fleet Server. Only images of enabled services are recorded.
+type LockedImages struct {
+	ElasticAgent    string    `json:"elastic-agent,omitempty"`
+	Elasticsearch   string    `json:"elasticsearch,omitempty"`
+	Kibana          string    `json:"kibana,omitempty"`
+	Logstash        string    `json:"logstash,omitempty"`
+	PackageRegistry string    `json:"package-registry,omitempty"`
+	LockedAt        time.Time `json:"locked_at"`
+}
+
+// stackImages contains the images used by the stack.
+type stackImages struct {
+	install.ImageRefs
+	PackageRegistry string
+}
+
+// LoadImagesLock loads the images lockfile of the profile. An empty lock is returned if
+// there is no lockfile.
+func LoadImagesLock(profile *profile.Profile) (*ImagesLock, error) {
+	lock := ImagesLock{Versions: make(map[string]LockedImages)}
+	d, err := os.ReadFile(profile.Path(ProfileStackPath, ImagesLockFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &lock, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read images lockfile: %w", err)
+	}
+	err = json.Unmarshal(d, &lock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode images lockfile: %w", err)
+	}
+	if lock.Versions == nil {
+		lock.Versions = make(map[string]LockedImages)
+	}
+	return &lock, nil
+}
+
+func storeImagesLock(profile *profile.Profile, lock *ImagesLock) error {
+	d, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode images lockfile: %w", err)
+	}
+	path := profile.Path(ProfileStackPath, ImagesLockFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create stack directory: %w", err)
+	}
+	err = os.WriteFile(path, append(d, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write images lockfile: %w", err)
+	}
+	return nil
+}
+
+// resolveStackImages returns the images to use for the stack. In locked mode, the images
+// recorded in the lockfile are used.
+func resolveStackImages(options Options) (stackImages, error) {
+	appConfig, err := install.Configuration(install.OptionWithStackVersion(options.StackVersion))
+	if err != nil {
+		return stackImages{}, fmt.Errorf("can't read application configuration: %w", err)
+	}
+	images := stackImages{
+		ImageRefs:       appConfig.StackImageRefs(),
+		PackageRegistry: PackageRegistryBaseImage,
+	}
+	if !options.Locked {
+		return images, nil
+	}
+
+	lock, err := LoadImagesLock(options.Profile)
+	if err != nil {
+		return stackImages{}, err
+	}
+	locked, found := lock.Versions[options.StackVersion]
+	if !found {
+		return stackImages{}, fmt.Errorf("no images locked for stack version %s in profile %q, run \"elastic-package stack update --relock\" to lock them", options.StackVersion, options.Profile.ProfileName)
+	}
+	return lockedStackImages(options, images, locked)
+}
+
+// lockedStackImages replaces the images of the enabled services with the locked ones. It fails
+// if any of them is not recorded in the lock, so locked stacks never use floating tags.
+func lockedStackImages(options Options, images stackImages, locked LockedImages) (stackImages, error) {
+	for _, image := range enabledServiceImages(options.Profile, &images, &locked) {
+		if *image.locked == "" {
+			return stackImages{}, fmt.Errorf("image of %s not locked for stack version %s in profile %q, run \"elastic-package stack update --relock\" to lock it", image.service, options.StackVersion, options.Profile.ProfileName)
+		}
+		*image.ref = *image.locked
+	}
+	return images, nil
+}
+
+// lockStackImages records the digests of the images of the stack in the lockfile. Images
+// are only locked if they are not locked yet for the stack version, or if relock is requested.
+func lockStackImages(options Options, images stackImages) error {
+	if options.Locked {
+		return nil
+	}
+
+	lock, err := LoadImagesLock(options.Profile)
+	if err != nil {
+		return err
+	}
+	if _, found := lock.Versions[options.StackVersion]; found && !options.Relock {
+		return nil
+	}
+
+	var locked LockedImages
+	for _, image := range enabledServiceImages(options.Profile, &images, &locked) {
+		digestRef, err := docker.ImageDigestRef(*image.ref)
+		if err != nil {
+			return fmt.Errorf("can't resolve digest of image %s used by %s: %w", *image.ref, image.service, err)
+		}
+		*image.locked = lockedImageRef(*image.ref, digestRef)
+	}
+	locked.LockedAt = time.Now().UTC()
+
+	lock.Versions[options.StackVersion] = locked
+	return storeImagesLock(options.Profile, lock)
+}
+
+// serviceImage links the image reference of a service with its entry in the lock.
+type serviceImage struct {
+	service string
+	ref     *string
+	locked  *string
+}
+
+// enabledServiceImages returns the images of the services enabled in the profile, with their
+// entries in the lock.
+func enabledServiceImages(profile *profile.Profile, images *stackImages, locked *LockedImages) []serviceImage {
+	serviceImages := []serviceImage{
+		{"elastic-agent", &images.ElasticAgent, &locked.ElasticAgent},
+		{"elasticsearch", &images.Elasticsearch, &locked.Elasticsearch},
+		{"kibana", &images.Kibana, &locked.Kibana},
+		{"package-registry", &images.PackageRegistry, &locked.PackageRegistry},
+	}
+	if profile.Config(configLogstashEnabled, "false") == "true" {
+		serviceImages = append(serviceImages, serviceImage{"logstash", &images.Logstash, &locked.Logstash})
+	}
+	return serviceImages
+}
+
+// lockedImageRef builds a reference with the tag of the image and the digest found in its
+// repository digest, as "name:tag@sha256:...". Docker uses the digest to pull the image.
+func lockedImageRef(image, repoDigest string) string {
+	image, _, _ = strings.Cut(image, "@")
+	_, digest, found := strings.Cut(repoDigest, "@")
+	if !found {
+		return repoDigest
+	}
+	return image + "@" + digest
+}
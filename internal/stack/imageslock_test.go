@@ -0,0 +1,127 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+func TestResolveStackImages(t *testing.T) {
+	const profileName = "locked"
+
+	elasticPackagePath := t.TempDir()
+	t.Setenv("ELASTIC_PACKAGE_DATA_HOME", elasticPackagePath)
+	err := os.WriteFile(filepath.Join(elasticPackagePath, "config.yml"), []byte("profile:\n  current: default\n"), 0644)
+	require.NoError(t, err)
+
+	err = profile.CreateProfile(profile.Options{
+		ProfilesDirPath: filepath.Join(elasticPackagePath, "profiles"),
+		Name:            profileName,
+	})
+	require.NoError(t, err)
+	p, err := profile.LoadProfile(profileName)
+	require.NoError(t, err)
+
+	// No lockfile yet.
+	lock, err := LoadImagesLock(p)
+	require.NoError(t, err)
+	assert.Empty(t, lock.Versions)
+
+	lock.Versions["8.15.0-SNAPSHOT"] = LockedImages{
+		ElasticAgent:    "docker.elastic.co/elastic-agent/elastic-agent-complete:8.15.0-SNAPSHOT@sha256:1111",
+		Elasticsearch:   "docker.elastic.co/elasticsearch/elasticsearch:8.15.0-SNAPSHOT@sha256:2222",
+		Kibana:          "docker.elastic.co/kibana/kibana:8.15.0-SNAPSHOT@sha256:3333",
+		PackageRegistry: "docker.elastic.co/package-registry/package-registry:v1.28.0@sha256:4444",
+		LockedAt:        time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, storeImagesLock(p, lock))
+
+	t.Run("locked", func(t *testing.T) {
+		images, err := resolveStackImages(Options{Profile: p, StackVersion: "8.15.0-SNAPSHOT", Locked: true})
+		require.NoError(t, err)
+		assert.Equal(t, "docker.elastic.co/elastic-agent/elastic-agent-complete:8.15.0-SNAPSHOT@sha256:1111", images.ElasticAgent)
+		assert.Equal(t, "docker.elastic.co/elasticsearch/elasticsearch:8.15.0-SNAPSHOT@sha256:2222", images.Elasticsearch)
+		assert.Equal(t, "docker.elastic.co/kibana/kibana:8.15.0-SNAPSHOT@sha256:3333", images.Kibana)
+		assert.Equal(t, "docker.elastic.co/package-registry/package-registry:v1.28.0@sha256:4444", images.PackageRegistry)
+
+		// Images of disabled services are not replaced.
+		assert.Equal(t, "docker.elastic.co/logstash/logstash:8.15.0-SNAPSHOT", images.Logstash)
+	})
+
+	t.Run("locked with enabled service not recorded", func(t *testing.T) {
+		p.RuntimeOverrides(map[string]string{configLogstashEnabled: "true"})
+		defer p.RuntimeOverrides(nil)
+
+		_, err := resolveStackImages(Options{Profile: p, StackVersion: "8.15.0-SNAPSHOT", Locked: true})
+		assert.ErrorContains(t, err, "image of logstash not locked")
+	})
+
+	t.Run("locked without recorded version", func(t *testing.T) {
+		_, err := resolveStackImages(Options{Profile: p, StackVersion: "8.16.0", Locked: true})
+		assert.Error(t, err)
+	})
+
+	t.Run("not locked", func(t *testing.T) {
+		images, err := resolveStackImages(Options{Profile: p, StackVersion: "8.15.0-SNAPSHOT"})
+		require.NoError(t, err)
+		assert.Equal(t, "docker.elastic.co/elasticsearch/elasticsearch:8.15.0-SNAPSHOT", images.Elasticsearch)
+		assert.Equal(t, PackageRegistryBaseImage, images.PackageRegistry)
+	})
+
+	t.Run("already locked version is kept", func(t *testing.T) {
+		err := lockStackImages(Options{Profile: p, StackVersion: "8.15.0-SNAPSHOT"}, stackImages{})
+		require.NoError(t, err)
+
+		lock, err := LoadImagesLock(p)
+		require.NoError(t, err)
+		assert.Equal(t, "docker.elastic.co/kibana/kibana:8.15.0-SNAPSHOT@sha256:3333", lock.Versions["8.15.0-SNAPSHOT"].Kibana)
+	})
+
+	t.Run("images without digest are not locked", func(t *testing.T) {
+		err := lockStackImages(Options{Profile: p, StackVersion: "8.16.0"}, stackImages{
+			PackageRegistry: "elastic-package.invalid/package-registry:not-pulled",
+		})
+		require.Error(t, err)
+
+		lock, err := LoadImagesLock(p)
+		require.NoError(t, err)
+		assert.NotContains(t, lock.Versions, "8.16.0")
+	})
+}
+
+func TestLockedImageRef(t *testing.T) {
+	cases := []struct {
+		image      string
+		repoDigest string
+		expected   string
+	}{
+		{
+			image:      "docker.elastic.co/kibana/kibana:8.15.0",
+			repoDigest: "docker.elastic.co/kibana/kibana@sha256:3333",
+			expected:   "docker.elastic.co/kibana/kibana:8.15.0@sha256:3333",
+		},
+		{
+			image:      "docker.elastic.co/kibana/kibana:8.15.0@sha256:2222",
+			repoDigest: "docker.elastic.co/kibana/kibana@sha256:3333",
+			expected:   "docker.elastic.co/kibana/kibana:8.15.0@sha256:3333",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.image, func(t *testing.T) {
+			ref := lockedImageRef(c.image, c.repoDigest)
+			assert.Equal(t, c.expected, ref)
+			assert.Equal(t, "8.15.0", getVersionFromDockerImage(ref))
+		})
+	}
+}
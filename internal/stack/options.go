@@ -15,6 +15,11 @@ type Options struct {
 
 	Services []string
 
+	// Locked uses the images recorded in the images lockfile of the profile.
+	Locked bool
+	// Relock records again the images in the lockfile, even if they were already recorded.
+	Relock bool
+
 	Profile *profile.Profile
 	Printer Printer
 }
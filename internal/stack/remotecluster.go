@@ -0,0 +1,450 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-package/internal/certs"
+	"github.com/elastic/elastic-package/internal/docker"
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/profile"
+	"github.com/elastic/elastic-package/internal/wait"
+)
+
+const (
+	// remoteClustersFile is the file in the stack directory of a profile with the
+	// list of stacks linked for cross-cluster search.
+	remoteClustersFile = "remote_clusters.json"
+
+	// remoteClusterCAsDirectory is the directory, relative to the Elasticsearch
+	// certificates directory, where the CAs of linked stacks are stored.
+	remoteClusterCAsDirectory = "remote"
+
+	transportPort = 9300
+
+	remoteClusterWaitPeriod  = 5 * time.Second
+	remoteClusterWaitTimeout = 5 * time.Minute
+)
+
+// RemoteCluster is a stack linked to the current one for cross-cluster search.
+type RemoteCluster struct {
+	// Alias is the name of the remote cluster, used in index patterns like
+	// <alias>:logs-*.
+	Alias string `json:"alias"`
+
+	// Profile is the profile of the linked stack.
+	Profile string `json:"profile"`
+}
+
+// LinkOptions are the options to link two stacks.
+type LinkOptions struct {
+	Profile       *profile.Profile
+	RemoteProfile *profile.Profile
+	Printer       Printer
+}
+
+// LoadRemoteClusters returns the stacks linked to the stack of the given profile.
+func LoadRemoteClusters(profile *profile.Profile) ([]RemoteCluster, error) {
+	d, err := os.ReadFile(profile.Path(ProfileStackPath, remoteClustersFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote clusters: %w", err)
+	}
+
+	var remoteClusters []RemoteCluster
+	err = json.Unmarshal(d, &remoteClusters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode remote clusters: %w", err)
+	}
+	return remoteClusters, nil
+}
+
+func storeRemoteClusters(profile *profile.Profile, remoteClusters []RemoteCluster) error {
+	d, err := json.MarshalIndent(remoteClusters, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode remote clusters: %w", err)
+	}
+
+	path := profile.Path(ProfileStackPath, remoteClustersFile)
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+
+	err = os.WriteFile(path, d, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write remote clusters: %w", err)
+	}
+	return nil
+}
+
+func addRemoteCluster(profile *profile.Profile, remoteCluster RemoteCluster) error {
+	remoteClusters, err := LoadRemoteClusters(profile)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(remoteClusters, remoteCluster) {
+		return nil
+	}
+	return storeRemoteClusters(profile, append(remoteClusters, remoteCluster))
+}
+
+// remoteClusterHost is the host name that linked stacks use to reach the
+// Elasticsearch node of the stack in the given profile.
+func remoteClusterHost(profile *profile.Profile) string {
+	return DockerComposeProjectName(profile) + "-elasticsearch"
+}
+
+// remoteClusterCertificateAuthorities returns the list of CAs trusted by the transport
+// layer of Elasticsearch, formatted to be used in a YAML flow sequence. It is empty if
+// the stack is not linked to any other stack.
+func remoteClusterCertificateAuthorities(profile *profile.Profile) (string, error) {
+	remoteClusters, err := LoadRemoteClusters(profile)
+	if err != nil {
+		return "", err
+	}
+	if len(remoteClusters) == 0 {
+		return "", nil
+	}
+
+	cas := []string{`"certs/ca-cert.pem"`}
+	for _, remoteCluster := range remoteClusters {
+		cas = append(cas, fmt.Sprintf("%q", "certs/"+remoteClusterCAsDirectory+"/"+remoteClusterCAFileName(remoteCluster.Profile)))
+	}
+	return strings.Join(cas, ", "), nil
+}
+
+func remoteClusterCAFileName(profileName string) string {
+	return profileName + "-ca.pem"
+}
+
+// trustRemoteCA copies the CA of the remote profile to the Elasticsearch certificates
+// of the given profile.
+func trustRemoteCA(p *profile.Profile, remote *profile.Profile) error {
+	caFile := remote.Path(CACertificateFile)
+	// Check that it is a valid CA before copying it.
+	_, err := certs.PoolWithCACertificate(caFile)
+	if err != nil {
+		return fmt.Errorf("invalid CA in profile %q: %w", remote.ProfileName, err)
+	}
+	d, err := os.ReadFile(caFile)
+	if err != nil {
+		return err
+	}
+
+	dest := p.Path(CertificatesDirectory, "elasticsearch", remoteClusterCAsDirectory, remoteClusterCAFileName(remote.ProfileName))
+	err = os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory for remote CAs: %w", err)
+	}
+	return os.WriteFile(dest, d, 0644)
+}
+
+// Link links the stacks of two profiles so they can use cross-cluster search in both
+// directions. Each stack configures the other one as a remote cluster, using the name
+// of its profile as alias. Both stacks must be running with the compose provider.
+func Link(ctx context.Context, options LinkOptions) error {
+	local, remote := options.Profile, options.RemoteProfile
+	if local.ProfileName == remote.ProfileName {
+		return errors.New("a stack cannot be linked with itself")
+	}
+
+	profiles := []*profile.Profile{local, remote}
+	versions := make(map[string]string)
+	for _, p := range profiles {
+		config, err := LoadConfig(p)
+		if err != nil {
+			return fmt.Errorf("failed to load stack configuration for profile %q: %w", p.ProfileName, err)
+		}
+		if config.Provider != ProviderCompose && config.Provider != "" {
+			return fmt.Errorf("stack in profile %q uses the %s provider, only %s stacks can be linked", p.ProfileName, config.Provider, ProviderCompose)
+		}
+		version, err := runningElasticsearchVersion(ctx, p)
+		if err != nil {
+			return fmt.Errorf("failed to check stack in profile %q: %w", p.ProfileName, err)
+		}
+		versions[p.ProfileName] = version
+	}
+
+	options.Printer.Println("Exchanging certificate authorities")
+	if err := trustRemoteCA(local, remote); err != nil {
+		return err
+	}
+	if err := trustRemoteCA(remote, local); err != nil {
+		return err
+	}
+
+	if err := addRemoteCluster(local, RemoteCluster{Alias: remote.ProfileName, Profile: remote.ProfileName}); err != nil {
+		return err
+	}
+	if err := addRemoteCluster(remote, RemoteCluster{Alias: local.ProfileName, Profile: local.ProfileName}); err != nil {
+		return err
+	}
+
+	options.Printer.Println("Restarting Elasticsearch to expose transport layer")
+	for _, p := range profiles {
+		registryImage, err := packageRegistryImage(p)
+		if err != nil {
+			return fmt.Errorf("failed to check package registry image in profile %q: %w", p.ProfileName, err)
+		}
+		err = applyResources(p, versions[p.ProfileName], registryImage)
+		if err != nil {
+			return fmt.Errorf("failed to update stack files for profile %q: %w", p.ProfileName, err)
+		}
+		containerID, err := elasticsearchContainerID(p)
+		if err != nil {
+			return err
+		}
+		err = docker.Restart(containerID)
+		if err != nil {
+			return fmt.Errorf("failed to restart Elasticsearch in profile %q: %w", p.ProfileName, err)
+		}
+	}
+
+	err := connectRemoteClusters(local)
+	if err != nil {
+		return err
+	}
+
+	options.Printer.Println("Configuring remote clusters")
+	for _, p := range profiles {
+		err := configureRemoteClusters(ctx, p)
+		if err != nil {
+			return fmt.Errorf("failed to configure remote clusters in profile %q: %w", p.ProfileName, err)
+		}
+	}
+
+	return nil
+}
+
+// connectRemoteClusters connects the Elasticsearch containers of the stack in the given
+// profile and its linked stacks to the networks of each other.
+func connectRemoteClusters(p *profile.Profile) error {
+	remoteClusters, err := LoadRemoteClusters(p)
+	if err != nil {
+		return err
+	}
+	if len(remoteClusters) == 0 {
+		return nil
+	}
+
+	localID, err := elasticsearchContainerID(p)
+	if err != nil {
+		return err
+	}
+	for _, remoteCluster := range remoteClusters {
+		remote, err := profile.LoadProfile(remoteCluster.Profile)
+		if err != nil {
+			return fmt.Errorf("failed to load profile of remote cluster %q: %w", remoteCluster.Alias, err)
+		}
+		remoteID, err := elasticsearchContainerID(remote)
+		if err != nil {
+			return fmt.Errorf("remote cluster %q not available: %w", remoteCluster.Alias, err)
+		}
+
+		err = connectToNetworkIfNeeded(remoteID, Network(p), remoteClusterHost(remote))
+		if err != nil {
+			return err
+		}
+		err = connectToNetworkIfNeeded(localID, Network(remote), remoteClusterHost(p))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func connectToNetworkIfNeeded(containerID, network, alias string) error {
+	err := docker.ConnectToNetworkWithAlias(containerID, network, []string{alias})
+	if err != nil && strings.Contains(err.Error(), "already exists") {
+		logger.Debugf("Container %s already connected to network %s", containerID, network)
+		return nil
+	}
+	return err
+}
+
+// configureRemoteClusters configures the linked stacks as remote clusters using
+// the Elasticsearch API, and waits till they are connected.
+func configureRemoteClusters(ctx context.Context, p *profile.Profile) error {
+	remoteClusters, err := LoadRemoteClusters(p)
+	if err != nil {
+		return err
+	}
+
+	client, err := newElasticsearchClientFromStackConfig(p)
+	if err != nil {
+		return err
+	}
+
+	_, err = wait.UntilTrue(ctx, func(ctx context.Context) (bool, error) {
+		return client.CheckHealth(ctx) == nil, nil
+	}, remoteClusterWaitPeriod, remoteClusterWaitTimeout)
+	if err != nil {
+		return err
+	}
+
+	settings := make(map[string]any)
+	for _, remoteCluster := range remoteClusters {
+		remote, err := profile.LoadProfile(remoteCluster.Profile)
+		if err != nil {
+			return fmt.Errorf("failed to load profile of remote cluster %q: %w", remoteCluster.Alias, err)
+		}
+		settings[remoteCluster.Alias] = map[string]any{
+			"mode":          "proxy",
+			"proxy_address": fmt.Sprintf("%s:%d", remoteClusterHost(remote), transportPort),
+		}
+	}
+	body, err := json.Marshal(map[string]any{
+		"persistent": map[string]any{
+			"cluster": map[string]any{
+				"remote": settings,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode remote clusters settings: %w", err)
+	}
+
+	resp, err := client.Cluster.PutSettings(bytes.NewReader(body), client.Cluster.PutSettings.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to update cluster settings: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return fmt.Errorf("failed to update cluster settings: %s", resp.String())
+	}
+
+	connected, err := wait.UntilTrue(ctx, func(ctx context.Context) (bool, error) {
+		return remoteClustersConnected(ctx, client, remoteClusters)
+	}, remoteClusterWaitPeriod, remoteClusterWaitTimeout)
+	if err != nil {
+		return err
+	}
+	if !connected {
+		return errors.New("timeout waiting for remote clusters to be connected")
+	}
+	return nil
+}
+
+func remoteClustersConnected(ctx context.Context, client *elasticsearch.Client, remoteClusters []RemoteCluster) (bool, error) {
+	resp, err := client.Cluster.RemoteInfo(client.Cluster.RemoteInfo.WithContext(ctx))
+	if err != nil {
+		return false, fmt.Errorf("failed to get remote clusters info: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return false, fmt.Errorf("failed to get remote clusters info: %s", resp.String())
+	}
+
+	var info map[string]struct {
+		Connected bool `json:"connected"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode remote clusters info: %w", err)
+	}
+
+	for _, remoteCluster := range remoteClusters {
+		if !info[remoteCluster.Alias].Connected {
+			logger.Debugf("Remote cluster %q not connected yet", remoteCluster.Alias)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// NewElasticsearchClientForRemoteCluster creates a client for the stack of a linked remote cluster.
+func NewElasticsearchClientForRemoteCluster(remoteCluster RemoteCluster) (*elasticsearch.Client, error) {
+	remote, err := profile.LoadProfile(remoteCluster.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profile of remote cluster %q: %w", remoteCluster.Alias, err)
+	}
+	return newElasticsearchClientFromStackConfig(remote)
+}
+
+// newElasticsearchClientFromStackConfig creates a client using only the stack configuration
+// of the profile, ignoring environment variables, as these refer to a single stack.
+func newElasticsearchClientFromStackConfig(p *profile.Profile) (*elasticsearch.Client, error) {
+	config, err := LoadConfig(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack configuration for profile %q: %w", p.ProfileName, err)
+	}
+	return elasticsearch.NewClient(
+		elasticsearch.OptionWithAddress(config.ElasticsearchHost),
+		elasticsearch.OptionWithAPIKey(config.ElasticsearchAPIKey),
+		elasticsearch.OptionWithUsername(config.ElasticsearchUsername),
+		elasticsearch.OptionWithPassword(config.ElasticsearchPassword),
+		elasticsearch.OptionWithCertificateAuthority(config.CACertFile),
+	)
+}
+
+func elasticsearchContainerID(p *profile.Profile) (string, error) {
+	description, err := stackContainer(p, "elasticsearch")
+	if err != nil {
+		return "", err
+	}
+	return description.ID, nil
+}
+
+// packageRegistryImage returns the image of the running package registry, so it is kept when
+// the stack files are updated, also when the stack uses locked images.
+func packageRegistryImage(p *profile.Profile) (string, error) {
+	description, err := stackContainer(p, "package-registry")
+	if errors.Is(err, errServiceContainerNotFound) {
+		return PackageRegistryBaseImage, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return description.Config.Image, nil
+}
+
+var errServiceContainerNotFound = errors.New("container not found")
+
+func stackContainer(p *profile.Profile, service string) (*docker.ContainerDescription, error) {
+	containerIDs, err := docker.ContainerIDsWithLabel(projectLabelDockerCompose, DockerComposeProjectName(p))
+	if err != nil {
+		return nil, err
+	}
+	if len(containerIDs) == 0 {
+		return nil, fmt.Errorf("stack in profile %q is not running: %w", p.ProfileName, ErrUnavailableStack)
+	}
+	descriptions, err := docker.InspectContainers(containerIDs...)
+	if err != nil {
+		return nil, err
+	}
+	for _, description := range descriptions {
+		if description.Config.Labels.ComposeService == service {
+			return &description, nil
+		}
+	}
+	return nil, fmt.Errorf("%s %w in profile %q", service, errServiceContainerNotFound, p.ProfileName)
+}
+
+func runningElasticsearchVersion(ctx context.Context, p *profile.Profile) (string, error) {
+	services, err := Status(ctx, Options{Profile: p})
+	if err != nil {
+		return "", err
+	}
+	for _, service := range services {
+		if service.Name == "elasticsearch" && strings.HasPrefix(service.Status, "running") {
+			return service.Version, nil
+		}
+	}
+	return "", ErrUnavailableStack
+}
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -65,6 +66,7 @@ const (
 	configLogstashEnabled     = "stack.logstash_enabled"
 	configSelfMonitorEnabled  = "stack.self_monitor_enabled"
 	configElasticSubscription = "stack.elastic_subscription"
+	configPortsOffset         = "stack.ports_offset"
 )
 
 var (
@@ -144,7 +146,7 @@ var (
 	}
 )
 
-func applyResources(profile *profile.Profile, stackVersion string) error {
+func applyResources(profile *profile.Profile, stackVersion string, registryImage string) error {
 	stackDir := filepath.Join(profile.ProfilePath, ProfileStackPath)
 
 	var agentPorts []string
@@ -157,9 +159,19 @@ func applyResources(profile *profile.Profile, stackVersion string) error {
 		return fmt.Errorf("unsupported Elastic subscription %q: supported subscriptions: %s", elasticSubscriptionProfile, strings.Join(elasticSubscriptionsSupported, ", "))
 	}
 
+	portsOffset, err := publishedPortsOffset(profile)
+	if err != nil {
+		return err
+	}
+
+	remoteClusterCAs, err := remoteClusterCertificateAuthorities(profile)
+	if err != nil {
+		return fmt.Errorf("failed to find certificate authorities of remote clusters: %w", err)
+	}
+
 	resourceManager := resource.NewManager()
 	resourceManager.AddFacter(resource.StaticFacter{
-		"registry_base_image":   PackageRegistryBaseImage,
+		"registry_base_image":   registryImage,
 		"elasticsearch_version": stackVersion,
 		"kibana_version":        stackVersion,
 		"agent_version":         stackVersion,
@@ -181,6 +193,16 @@ func applyResources(profile *profile.Profile, stackVersion string) error {
 		"logstash_enabled":     profile.Config(configLogstashEnabled, "false"),
 		"self_monitor_enabled": profile.Config(configSelfMonitorEnabled, "false"),
 		"elastic_subscription": elasticSubscriptionProfile,
+
+		"elasticsearch_published_port":    strconv.Itoa(9200 + portsOffset),
+		"kibana_published_port":           strconv.Itoa(5601 + portsOffset),
+		"registry_published_port":         strconv.Itoa(8080 + portsOffset),
+		"registry_metrics_published_port": strconv.Itoa(9000 + portsOffset),
+		"fleet_server_published_port":     strconv.Itoa(8220 + portsOffset),
+		"apm_server_published_port":       strconv.Itoa(8200 + portsOffset),
+		"logstash_published_port":         strconv.Itoa(5044 + portsOffset),
+		"logstash_api_published_port":     strconv.Itoa(9600 + portsOffset),
+		"remote_cluster_cas":              remoteClusterCAs,
 	})
 
 	if err := os.MkdirAll(stackDir, 0755); err != nil {
@@ -262,6 +284,17 @@ func addClientCertsToResources(resourceManager *resource.Manager, certResources
 	return nil
 }
 
+// publishedPortsOffset returns the offset added to the ports published in the host,
+// so stacks of different profiles can run at the same time.
+func publishedPortsOffset(profile *profile.Profile) (int, error) {
+	value := profile.Config(configPortsOffset, "0")
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid value for %s (%q): it must be a non-negative integer", configPortsOffset, value)
+	}
+	return offset, nil
+}
+
 func semverLessThan(a, b string) (bool, error) {
 	sa, err := semver.NewVersion(a)
 	if err != nil {
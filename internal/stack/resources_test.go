@@ -48,7 +48,7 @@ func TestApplyResourcesWithCustomGeoipDir(t *testing.T) {
 	require.Equal(t, expectedGeoipPath, v)
 
 	// Now, apply resources and check that the variable has been used.
-	err = applyResources(p, "8.6.1")
+	err = applyResources(p, "8.6.1", PackageRegistryBaseImage)
 	require.NoError(t, err)
 
 	d, err := os.ReadFile(p.Path(ProfileStackPath, ComposeFile))
@@ -113,3 +113,64 @@ vvG/LwXVsGCXgSJahuOLkBPOaX2N+oDdYt267A==
 	exp = "\n        "
 	assert.Equal(t, exp, s)
 }
+
+func TestApplyResourcesWithLinkedStack(t *testing.T) {
+	const profileName = "linked"
+
+	elasticPackagePath := t.TempDir()
+	profilesPath := filepath.Join(elasticPackagePath, "profiles")
+
+	t.Setenv("ELASTIC_PACKAGE_DATA_HOME", elasticPackagePath)
+
+	err := profile.CreateProfile(profile.Options{
+		ProfilesDirPath: profilesPath,
+		Name:            profileName,
+	})
+	require.NoError(t, err)
+
+	configPath := filepath.Join(profilesPath, profileName, profile.PackageProfileConfigFile)
+	err = os.WriteFile(configPath, []byte("stack.ports_offset: 100"), 0644)
+	require.NoError(t, err)
+
+	p, err := profile.LoadProfile(profileName)
+	require.NoError(t, err)
+
+	err = addRemoteCluster(p, RemoteCluster{Alias: "other", Profile: "other"})
+	require.NoError(t, err)
+
+	err = applyResources(p, "8.15.0", PackageRegistryBaseImage)
+	require.NoError(t, err)
+
+	d, err := os.ReadFile(p.Path(ProfileStackPath, ComposeFile))
+	require.NoError(t, err)
+
+	var composeFile struct {
+		Services struct {
+			Elasticsearch struct {
+				Ports []string `yaml:"ports"`
+			} `yaml:"elasticsearch"`
+			Kibana struct {
+				Ports []string `yaml:"ports"`
+			} `yaml:"kibana"`
+		} `yaml:"services"`
+	}
+	err = yaml.Unmarshal(d, &composeFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:9300:9200"}, composeFile.Services.Elasticsearch.Ports)
+	assert.Equal(t, []string{"127.0.0.1:5701:5601"}, composeFile.Services.Kibana.Ports)
+
+	// Clients use the published ports when the stack config is not stored yet.
+	config, err := LoadConfig(p)
+	require.NoError(t, err)
+	assert.Equal(t, "https://127.0.0.1:9300", config.ElasticsearchHost)
+	assert.Equal(t, "https://127.0.0.1:5701", config.KibanaHost)
+
+	d, err = os.ReadFile(p.Path(ProfileStackPath, ElasticsearchConfigFile))
+	require.NoError(t, err)
+
+	var elasticsearchConfig map[string]any
+	err = yaml.Unmarshal(d, &elasticsearchConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "0.0.0.0", elasticsearchConfig["transport.host"])
+	assert.Equal(t, []any{"certs/ca-cert.pem", "certs/remote/other-ca.pem"}, elasticsearchConfig["xpack.security.transport.ssl.certificate_authorities"])
+}
@@ -0,0 +1,171 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package stack
+
+import (
+	"context"
+	"sort"
+
+	"github.com/elastic/elastic-package/internal/docker"
+	"github.com/elastic/elastic-package/internal/elasticsearch/ingest"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/profile"
+)
+
+// ServiceResourceUsage contains the resources used by a service of the stack.
+type ServiceResourceUsage struct {
+	Name string `json:"name"`
+
+	CPUPercentage float64 `json:"cpu_pct"`
+	MemoryUsage   uint64  `json:"memory_usage_bytes"`
+	MemoryLimit   uint64  `json:"memory_limit_bytes"`
+
+	// DiskUsage is the size of the writable layer of the container, it is only available
+	// when requested, as it is expensive to obtain.
+	DiskUsage  uint64 `json:"disk_usage_bytes,omitempty"`
+	BlockRead  uint64 `json:"block_read_bytes"`
+	BlockWrite uint64 `json:"block_write_bytes"`
+
+	// HeapUsage and HeapLimit are only available for Elasticsearch and Kibana.
+	HeapUsage uint64 `json:"heap_usage_bytes,omitempty"`
+	HeapLimit uint64 `json:"heap_limit_bytes,omitempty"`
+}
+
+// ResourceUsageCollector collects the resources used by the containers of the stack that
+// were running when it was created. It can be used to take multiple samples without
+// looking for the containers and creating clients on each one.
+type ResourceUsageCollector struct {
+	containerIDs []string
+	serviceNames map[string]string
+	kibanaClient *kibana.Client
+}
+
+// NewResourceUsageCollector creates a collector for the running containers of the stack in
+// the given profile.
+func NewResourceUsageCollector(profile *profile.Profile) (*ResourceUsageCollector, error) {
+	var collector ResourceUsageCollector
+	containerIDs, err := docker.ContainerIDsWithLabel(projectLabelDockerCompose, DockerComposeProjectName(profile))
+	if err != nil {
+		return nil, err
+	}
+	if len(containerIDs) == 0 {
+		return &collector, nil
+	}
+
+	descriptions, err := docker.InspectContainers(containerIDs...)
+	if err != nil {
+		return nil, err
+	}
+	collector.serviceNames = make(map[string]string)
+	for _, description := range descriptions {
+		if description.State.Status != "running" {
+			continue
+		}
+		collector.containerIDs = append(collector.containerIDs, description.ID)
+		collector.serviceNames[description.ID] = description.Config.Labels.ComposeService
+	}
+
+	collector.kibanaClient, err = NewKibanaClientFromProfile(profile)
+	if err != nil {
+		logger.Debugf("failed to create Kibana client to get heap usage: %v", err)
+	}
+
+	return &collector, nil
+}
+
+// Collect returns the resources used by the containers. Heap usage of Elasticsearch is obtained
+// from the given nodes stats, and heap usage of Kibana from its API, if they are not available,
+// it is not reported. Disk usage is only obtained if requested.
+func (c *ResourceUsageCollector) Collect(ctx context.Context, nodesStats *ingest.NodesStats, withDiskUsage bool) ([]ServiceResourceUsage, error) {
+	if len(c.containerIDs) == 0 {
+		return nil, nil
+	}
+
+	stats, err := docker.Stats(c.containerIDs...)
+	if err != nil {
+		return nil, err
+	}
+	var sizes map[string]uint64
+	if withDiskUsage {
+		sizes, err = docker.ContainerSizes(c.containerIDs...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var usage []ServiceResourceUsage
+	for _, s := range stats {
+		usage = append(usage, ServiceResourceUsage{
+			Name:          c.serviceNames[s.ID],
+			CPUPercentage: s.CPUPercentage,
+			MemoryUsage:   s.MemoryUsage,
+			MemoryLimit:   s.MemoryLimit,
+			DiskUsage:     sizes[s.ID],
+			BlockRead:     s.BlockRead,
+			BlockWrite:    s.BlockWrite,
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Name < usage[j].Name })
+
+	for i := range usage {
+		switch usage[i].Name {
+		case "elasticsearch":
+			usage[i].HeapUsage, usage[i].HeapLimit = elasticsearchHeapUsage(nodesStats)
+		case "kibana":
+			usage[i].HeapUsage, usage[i].HeapLimit = c.kibanaHeapUsage(ctx)
+		}
+	}
+
+	return usage, nil
+}
+
+// ResourceUsage returns the resources used by the running containers of the stack in
+// the given profile, including their disk usage.
+func ResourceUsage(ctx context.Context, profile *profile.Profile) ([]ServiceResourceUsage, error) {
+	collector, err := NewResourceUsageCollector(profile)
+	if err != nil {
+		return nil, err
+	}
+	if len(collector.containerIDs) == 0 {
+		return nil, nil
+	}
+
+	var nodesStats *ingest.NodesStats
+	client, err := NewElasticsearchClientFromProfile(profile)
+	if err != nil {
+		logger.Debugf("failed to create Elasticsearch client to get heap usage: %v", err)
+	} else {
+		nodesStats, err = ingest.GetNodesStats(client.API)
+		if err != nil {
+			logger.Debugf("failed to get Elasticsearch heap usage: %v", err)
+		}
+	}
+
+	return collector.Collect(ctx, nodesStats, true)
+}
+
+func elasticsearchHeapUsage(stats *ingest.NodesStats) (used uint64, limit uint64) {
+	if stats == nil {
+		return 0, 0
+	}
+	for _, node := range stats.Nodes {
+		used += uint64(node.JVM.Mem.HeapUsedInBytes)
+		limit += uint64(node.JVM.Mem.HeapMaxInBytes)
+	}
+	return used, limit
+}
+
+func (c *ResourceUsageCollector) kibanaHeapUsage(ctx context.Context) (used uint64, limit uint64) {
+	if c.kibanaClient == nil {
+		return 0, 0
+	}
+	heap, err := c.kibanaClient.HeapUsage(ctx)
+	if err != nil {
+		logger.Debugf("failed to get Kibana heap usage: %v", err)
+		return 0, 0
+	}
+	return heap.UsedInBytes, heap.LimitInBytes
+}
@@ -13,12 +13,17 @@ import (
 
 // Update pulls down the most recent versions of the Docker images.
 func Update(ctx context.Context, options Options) error {
-	err := applyResources(options.Profile, options.StackVersion)
+	images, err := resolveStackImages(options)
+	if err != nil {
+		return err
+	}
+
+	err = applyResources(options.Profile, options.StackVersion, images.PackageRegistry)
 	if err != nil {
 		return fmt.Errorf("creating stack files failed: %w", err)
 	}
 
-	err = docker.Pull(PackageRegistryBaseImage)
+	err = docker.Pull(images.PackageRegistry)
 	if err != nil {
 		return fmt.Errorf("pulling package-registry docker image failed: %w", err)
 	}
@@ -27,5 +32,10 @@ func Update(ctx context.Context, options Options) error {
 	if err != nil {
 		return fmt.Errorf("pulling docker images failed: %w", err)
 	}
+
+	err = lockStackImages(options, images)
+	if err != nil {
+		return fmt.Errorf("locking docker images failed: %w", err)
+	}
 	return nil
 }
@@ -20,6 +20,7 @@ type globalTestConfig struct {
 	Policy   GlobalRunnerTestConfig `config:"policy"`
 	Static   GlobalRunnerTestConfig `config:"static"`
 	System   GlobalRunnerTestConfig `config:"system"`
+	Upgrade  GlobalRunnerTestConfig `config:"upgrade"`
 }
 
 type GlobalRunnerTestConfig struct {
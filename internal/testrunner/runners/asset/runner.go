@@ -23,6 +23,7 @@ type runner struct {
 	globalTestConfig testrunner.GlobalRunnerTestConfig
 	withCoverage     bool
 	coverageType     string
+	deep             bool
 }
 
 type AssetTestRunnerOptions struct {
@@ -31,6 +32,7 @@ type AssetTestRunnerOptions struct {
 	GlobalTestConfig testrunner.GlobalRunnerTestConfig
 	WithCoverage     bool
 	CoverageType     string
+	Deep             bool
 }
 
 func NewAssetTestRunner(options AssetTestRunnerOptions) *runner {
@@ -40,6 +42,7 @@ func NewAssetTestRunner(options AssetTestRunnerOptions) *runner {
 		globalTestConfig: options.GlobalTestConfig,
 		withCoverage:     options.WithCoverage,
 		coverageType:     options.CoverageType,
+		deep:             options.Deep,
 	}
 	return &runner
 }
@@ -70,6 +73,7 @@ func (r *runner) GetTests(ctx context.Context) ([]testrunner.Tester, error) {
 			GlobalTestConfig: r.globalTestConfig,
 			WithCoverage:     r.withCoverage,
 			CoverageType:     r.coverageType,
+			Deep:             r.deep,
 		}),
 	}
 	return testers, nil
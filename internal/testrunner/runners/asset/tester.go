@@ -8,8 +8,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 
+	"github.com/elastic/elastic-package/internal/dashboards"
 	"github.com/elastic/elastic-package/internal/kibana"
 	"github.com/elastic/elastic-package/internal/logger"
 	"github.com/elastic/elastic-package/internal/packages"
@@ -25,6 +27,7 @@ type tester struct {
 	globalTestConfig testrunner.GlobalRunnerTestConfig
 	withCoverage     bool
 	coverageType     string
+	deep             bool
 }
 
 type AssetTesterOptions struct {
@@ -34,6 +37,10 @@ type AssetTesterOptions struct {
 	GlobalTestConfig testrunner.GlobalRunnerTestConfig
 	WithCoverage     bool
 	CoverageType     string
+
+	// Deep enables the analysis of the saved objects, to check that they only reference
+	// fields and data views provided by the package.
+	Deep bool
 }
 
 func NewAssetTester(options AssetTesterOptions) *tester {
@@ -44,6 +51,7 @@ func NewAssetTester(options AssetTesterOptions) *tester {
 		globalTestConfig: options.GlobalTestConfig,
 		withCoverage:     options.WithCoverage,
 		coverageType:     options.CoverageType,
+		deep:             options.Deep,
 	}
 
 	manager := resources.NewManager()
@@ -142,6 +150,11 @@ func (r *tester) run(ctx context.Context) ([]testrunner.TestResult, error) {
 		return result.WithError(fmt.Errorf("could not load expected package assets: %w", err))
 	}
 
+	issues, err := r.analyzeAssets()
+	if err != nil {
+		return result.WithError(err)
+	}
+
 	results := make([]testrunner.TestResult, 0, len(expectedAssets))
 	for _, e := range expectedAssets {
 		rc := testrunner.NewResultComposer(testrunner.TestResult{
@@ -157,6 +170,11 @@ func (r *tester) run(ctx context.Context) ([]testrunner.TestResult, error) {
 				Reason:  "could not find expected asset",
 				Details: fmt.Sprintf("could not find %s asset \"%s\". Assets loaded:\n%s", e.Type, e.ID, formatAssetsAsString(installedAssets)),
 			})
+		} else if assetIssues := issues[e.SourcePath]; len(assetIssues) > 0 {
+			tr, _ = rc.WithError(testrunner.ErrTestCaseFailed{
+				Reason:  "dashboard analysis found issues",
+				Details: formatIssuesAsString(assetIssues),
+			})
 		} else {
 			tr, _ = rc.WithSuccess()
 		}
@@ -178,6 +196,26 @@ func (r *tester) run(ctx context.Context) ([]testrunner.TestResult, error) {
 	return results, nil
 }
 
+// analyzeAssets analyzes the saved objects of the package when running in deep mode, and returns
+// the issues found, grouped by the path of the asset.
+func (r *tester) analyzeAssets() (map[string][]dashboards.Issue, error) {
+	if !r.deep {
+		return nil, nil
+	}
+
+	logger.Debug("analyzing saved objects...")
+	issues, err := dashboards.AnalyzePackage(r.packageRootPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not analyze saved objects: %w", err)
+	}
+	grouped := make(map[string][]dashboards.Issue)
+	for _, issue := range issues {
+		sourcePath := filepath.Join(r.packageRootPath, filepath.FromSlash(issue.File))
+		grouped[sourcePath] = append(grouped[sourcePath], issue)
+	}
+	return grouped, nil
+}
+
 func (r *tester) TearDown(ctx context.Context) error {
 	// Avoid cancellations during cleanup.
 	cleanupCtx := context.WithoutCancel(ctx)
@@ -208,3 +246,11 @@ func formatAssetsAsString(assets []packages.Asset) string {
 	}
 	return sb.String()
 }
+
+func formatIssuesAsString(issues []dashboards.Issue) string {
+	var sb strings.Builder
+	for _, issue := range issues {
+		sb.WriteString(fmt.Sprintf("- [%s] %s\n", issue.Code, issue.Message))
+	}
+	return sb.String()
+}
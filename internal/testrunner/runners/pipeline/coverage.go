@@ -66,13 +66,25 @@ func getPipelineCoverage(pkgName string, options PipelineTesterOptions, pipeline
 			if err != nil {
 				return nil, err
 			}
-			covered, class, err := coberturaForSinglePipeline(pipelineName, pipelineRelPath, src, pstats)
+			sources, err := pipelineSourcesForCoverage(pipeline, pipelineName, pipelineRelPath, src, pstats, repositoryRootDir)
 			if err != nil {
-				return nil, fmt.Errorf("error calculating coverage for pipeline '%s': %w", pipeline.Filename(), err)
+				return nil, err
+			}
+			for _, source := range sources {
+				_, class, err := coberturaForSinglePipeline(source.name, source.relPath, source.src, source.stats)
+				if err != nil {
+					return nil, fmt.Errorf("error calculating coverage for pipeline '%s': %w", pipeline.Filename(), err)
+				}
+				pkg.Classes = appendCoberturaClass(pkg.Classes, class)
+			}
+		}
+		for _, class := range pkg.Classes {
+			for _, method := range class.Methods {
+				cobertura.LinesValid++
+				if len(method.Lines) > 0 && method.Lines[0].Hits > 0 {
+					cobertura.LinesCovered++
+				}
 			}
-			pkg.Classes = append(pkg.Classes, class)
-			cobertura.LinesValid += int64(len(class.Methods))
-			cobertura.LinesCovered += covered
 		}
 		return cobertura, nil
 	}
@@ -86,15 +98,21 @@ func getPipelineCoverage(pkgName string, options PipelineTesterOptions, pipeline
 
 		// Calculate coverage for each pipeline
 		for _, pipeline := range pipelines {
-			_, pipelineRelPath, src, pstats, err := pipelineDataForCoverage(pipeline, stats, repositoryRootDir, dataStreamPath)
+			pipelineName, pipelineRelPath, src, pstats, err := pipelineDataForCoverage(pipeline, stats, repositoryRootDir, dataStreamPath)
 			if err != nil {
 				return nil, err
 			}
-			_, file, err := genericCoverageForSinglePipeline(pipelineRelPath, src, pstats)
+			sources, err := pipelineSourcesForCoverage(pipeline, pipelineName, pipelineRelPath, src, pstats, repositoryRootDir)
 			if err != nil {
-				return nil, fmt.Errorf("error calculating coverage for pipeline '%s': %w", pipeline.Filename(), err)
+				return nil, err
+			}
+			for _, source := range sources {
+				_, file, err := genericCoverageForSinglePipeline(source.relPath, source.src, source.stats)
+				if err != nil {
+					return nil, fmt.Errorf("error calculating coverage for pipeline '%s': %w", pipeline.Filename(), err)
+				}
+				coverage.Files = appendGenericFile(coverage.Files, file)
 			}
-			coverage.Files = append(coverage.Files, file)
 		}
 		return coverage, nil
 
@@ -157,6 +175,88 @@ func pipelineDataForCoverage(pipeline ingest.Pipeline, stats ingest.PipelineStat
 	return pipelineName, pipelineRelPath, src, pstats, nil
 }
 
+// pipelineSource contains the processors of a pipeline defined in a file, that can be the
+// pipeline file or one of the pipeline snippets included in it.
+type pipelineSource struct {
+	name    string
+	relPath string
+	src     []ingest.Processor
+	stats   ingest.PipelineStats
+}
+
+// pipelineSourcesForCoverage splits the processors of the pipeline by the file where they are defined,
+// so processors included from pipeline snippets are reported with the lines of the snippet source.
+func pipelineSourcesForCoverage(pipeline ingest.Pipeline, pipelineName, pipelineRelPath string, src []ingest.Processor, pstats ingest.PipelineStats, basePath string) ([]*pipelineSource, error) {
+	if len(pipeline.SourceLines) == 0 {
+		return []*pipelineSource{{name: pipelineName, relPath: pipelineRelPath, src: src, stats: pstats}}, nil
+	}
+
+	sources := map[string]*pipelineSource{
+		pipeline.Path: {name: pipelineName, relPath: pipelineRelPath},
+	}
+	result := []*pipelineSource{sources[pipeline.Path]}
+	for idx, proc := range src {
+		path, firstLine := pipeline.LineSource(proc.FirstLine)
+		lastLine := firstLine
+		for num := proc.FirstLine + 1; num <= proc.LastLine; num++ {
+			if linePath, line := pipeline.LineSource(num); linePath == path && line > lastLine {
+				lastLine = line
+			}
+		}
+
+		source, found := sources[path]
+		if !found {
+			relPath, err := filepath.Rel(basePath, path)
+			if err != nil {
+				return nil, fmt.Errorf("cannot create relative path to pipeline snippet. Base path: '%s', snippet path: '%s': %w", basePath, path, err)
+			}
+			source = &pipelineSource{
+				name:    strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+				relPath: relPath,
+			}
+			sources[path] = source
+			result = append(result, source)
+		}
+		source.src = append(source.src, ingest.Processor{Type: proc.Type, FirstLine: firstLine, LastLine: lastLine})
+		source.stats.Processors = append(source.stats.Processors, pstats.Processors[idx])
+	}
+	if len(result[0].src) == 0 {
+		// All processors are included from snippets.
+		result = result[1:]
+	}
+	return result, nil
+}
+
+// appendCoberturaClass appends the class to the list, or adds its hits to an existing class
+// for the same file, as happens with pipeline snippets included in multiple pipelines.
+func appendCoberturaClass(classes []*testrunner.CoberturaClass, class *testrunner.CoberturaClass) []*testrunner.CoberturaClass {
+	for _, existing := range classes {
+		if existing.Filename != class.Filename || len(existing.Lines) != len(class.Lines) {
+			continue
+		}
+		for idx, line := range class.Lines {
+			existing.Lines[idx].Hits += line.Hits
+		}
+		return classes
+	}
+	return append(classes, class)
+}
+
+// appendGenericFile appends the file to the list, or merges its lines with an existing file
+// with the same path, as happens with pipeline snippets included in multiple pipelines.
+func appendGenericFile(files []*testrunner.GenericFile, file *testrunner.GenericFile) []*testrunner.GenericFile {
+	for _, existing := range files {
+		if existing.Path != file.Path || len(existing.Lines) != len(file.Lines) {
+			continue
+		}
+		for idx, line := range file.Lines {
+			existing.Lines[idx].Covered = existing.Lines[idx].Covered || line.Covered
+		}
+		return files
+	}
+	return append(files, file)
+}
+
 func genericCoverageForSinglePipeline(pipelineRelPath string, src []ingest.Processor, pstats ingest.PipelineStats) (linesCovered int64, class *testrunner.GenericFile, err error) {
 	// Report every pipeline as a "file".
 	file := &testrunner.GenericFile{
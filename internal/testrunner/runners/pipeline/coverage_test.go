@@ -8,8 +8,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/elastic-package/internal/elasticsearch/ingest"
+	"github.com/elastic/elastic-package/internal/packages/pipelinesnippets"
 	"github.com/elastic/elastic-package/internal/testrunner"
 )
 
@@ -266,3 +268,40 @@ func TestCoberturaForSinglePipeline(t *testing.T) {
 		})
 	}
 }
+
+func TestPipelineSourcesForCoverage(t *testing.T) {
+	pipeline := ingest.Pipeline{
+		Path: "/repo/packages/test/data_stream/logs/elasticsearch/ingest_pipeline/default.yml",
+		SourceLines: []pipelinesnippets.Line{
+			{Path: "/repo/packages/test/data_stream/logs/elasticsearch/ingest_pipeline/default.yml", Number: 1},
+			{Path: "/repo/packages/test/data_stream/logs/elasticsearch/ingest_pipeline/default.yml", Number: 2},
+			{Path: "/repo/packages/test/data_stream/logs/elasticsearch/ingest_pipeline/default.yml", Number: 3},
+			{Path: "/repo/packages/test/_dev/build/pipeline-snippets/ecs.yml", Number: 1},
+			{Path: "/repo/packages/test/_dev/build/pipeline-snippets/ecs.yml", Number: 2},
+			{Path: "/repo/packages/test/data_stream/logs/elasticsearch/ingest_pipeline/default.yml", Number: 5},
+		},
+	}
+	src := []ingest.Processor{
+		{Type: "set", FirstLine: 2, LastLine: 3},
+		{Type: "set", FirstLine: 4, LastLine: 5},
+	}
+	pstats := ingest.PipelineStats{
+		Processors: []ingest.ProcessorStats{
+			{Type: "set", Stats: ingest.StatsRecord{Count: 1}},
+			{Type: "set", Stats: ingest.StatsRecord{Count: 2}},
+		},
+	}
+
+	sources, err := pipelineSourcesForCoverage(pipeline, "default", "test/data_stream/logs/elasticsearch/ingest_pipeline/default.yml", src, pstats, "/repo/packages")
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+
+	assert.Equal(t, "default", sources[0].name)
+	assert.Equal(t, []ingest.Processor{{Type: "set", FirstLine: 2, LastLine: 3}}, sources[0].src)
+	assert.Equal(t, pstats.Processors[:1], sources[0].stats.Processors)
+
+	assert.Equal(t, "ecs", sources[1].name)
+	assert.Equal(t, "test/_dev/build/pipeline-snippets/ecs.yml", sources[1].relPath)
+	assert.Equal(t, []ingest.Processor{{Type: "set", FirstLine: 1, LastLine: 2}}, sources[1].src)
+	assert.Equal(t, pstats.Processors[1:], sources[1].stats.Processors)
+}
@@ -12,4 +12,5 @@ import (
 	_ "github.com/elastic/elastic-package/internal/testrunner/runners/policy"
 	_ "github.com/elastic/elastic-package/internal/testrunner/runners/static"
 	_ "github.com/elastic/elastic-package/internal/testrunner/runners/system"
+	_ "github.com/elastic/elastic-package/internal/testrunner/runners/upgrade"
 )
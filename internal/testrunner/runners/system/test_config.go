@@ -53,6 +53,10 @@ type testConfig struct {
 
 		// FieldsPresent list of fields that must be present in any of documents ingested
 		FieldsPresent []string `config:"fields_present"`
+
+		// RemoteClusters checks that ingested documents can also be found with cross-cluster
+		// search from the stacks linked with `elastic-package stack link`.
+		RemoteClusters bool `config:"remote_clusters"`
 	} `config:"assert"`
 
 	// NumericKeywordFields holds a list of fields that have keyword
@@ -1653,6 +1653,16 @@ func (r *tester) validateTestScenario(ctx context.Context, result *testrunner.Re
 		result.FailureMsg = message
 	}
 
+	if config.Assert.RemoteClusters {
+		assertionPass, message, err := r.assertRemoteClusterHits(ctx, scenario.dataStream, len(docs))
+		if err != nil {
+			return result.WithError(err)
+		}
+		if !assertionPass {
+			result.FailureMsg = message
+		}
+	}
+
 	// Check transforms if present
 	if err := r.checkTransforms(ctx, config, r.pkgManifest, scenario.kibanaDataStream, scenario.dataStream, scenario.syntheticEnabled); err != nil {
 		results, _ := result.WithError(err)
@@ -2331,6 +2341,58 @@ func assertHitCount(expected int, docs []common.MapStr) (pass bool, message stri
 	return true, ""
 }
 
+// assertRemoteClusterHits checks that the documents ingested in the data stream are found
+// when searching from the linked stacks, using the name of the current profile as alias.
+func (r *tester) assertRemoteClusterHits(ctx context.Context, dataStream string, expected int) (pass bool, message string, err error) {
+	remoteClusters, err := stack.LoadRemoteClusters(r.profile)
+	if err != nil {
+		return false, "", err
+	}
+	if len(remoteClusters) == 0 {
+		return false, "", fmt.Errorf("no remote clusters linked to profile %q, link them with 'elastic-package stack link'", r.profile.ProfileName)
+	}
+
+	index := fmt.Sprintf("%s:%s", r.profile.ProfileName, dataStream)
+	for _, remoteCluster := range remoteClusters {
+		count, err := countRemoteClusterDocuments(ctx, remoteCluster, index)
+		if err != nil {
+			return false, "", err
+		}
+		logger.Debugf("assert remote cluster hits from %q expected at least %d, observed %d", remoteCluster.Alias, expected, count)
+		if count < expected {
+			return false, fmt.Sprintf("observed hit count %d searching %s from profile %q is lower than expected hit count %d", count, index, remoteCluster.Profile, expected), nil
+		}
+	}
+	return true, "", nil
+}
+
+func countRemoteClusterDocuments(ctx context.Context, remoteCluster stack.RemoteCluster, index string) (int, error) {
+	client, err := stack.NewElasticsearchClientForRemoteCluster(remoteCluster)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Count(
+		client.Count.WithContext(ctx),
+		client.Count.WithIndex(index),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("could not count documents in %s from remote cluster %q: %w", index, remoteCluster.Alias, err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return 0, fmt.Errorf("failed to count documents in %s from remote cluster %q: %s", index, remoteCluster.Alias, resp.String())
+	}
+
+	var count struct {
+		Count int `json:"count"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&count)
+	if err != nil {
+		return 0, fmt.Errorf("could not decode count response: %w", err)
+	}
+	return count.Count, nil
+}
+
 func (r *tester) generateTestResultFile(docs []common.MapStr, specVersion semver.Version) error {
 	if !r.generateTestResult {
 		return nil
@@ -0,0 +1,110 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+const (
+	// TestType defining upgrade tests
+	TestType testrunner.TestType = "upgrade"
+)
+
+type runner struct {
+	packageRootPath  string
+	kibanaClient     *kibana.Client
+	esAPI            *elasticsearch.API
+	dataStreams      []string
+	globalTestConfig testrunner.GlobalRunnerTestConfig
+}
+
+// Ensures that runner implements testrunner.TestRunner interface
+var _ testrunner.TestRunner = new(runner)
+
+type UpgradeTestRunnerOptions struct {
+	KibanaClient     *kibana.Client
+	API              *elasticsearch.API
+	PackageRootPath  string
+	DataStreams      []string
+	GlobalTestConfig testrunner.GlobalRunnerTestConfig
+}
+
+func NewUpgradeTestRunner(options UpgradeTestRunnerOptions) *runner {
+	return &runner{
+		packageRootPath:  options.PackageRootPath,
+		kibanaClient:     options.KibanaClient,
+		esAPI:            options.API,
+		dataStreams:      options.DataStreams,
+		globalTestConfig: options.GlobalTestConfig,
+	}
+}
+
+// SetupRunner prepares global resources required by the test runner.
+func (r *runner) SetupRunner(ctx context.Context) error {
+	return nil
+}
+
+// TearDownRunner cleans up any global test runner resources. It must be called
+// after the test runner has finished executing all its tests.
+func (r *runner) TearDownRunner(ctx context.Context) error {
+	return nil
+}
+
+// GetTests returns a single tester for the package, as all data streams are upgraded at once.
+// No tests are returned if the package doesn't define the upgrade test folder.
+func (r *runner) GetTests(ctx context.Context) ([]testrunner.Tester, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(r.packageRootPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed (path: %s): %w", r.packageRootPath, err)
+	}
+
+	hasDataStreams, err := testrunner.PackageHasDataStreams(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine if package has data streams: %w", err)
+	}
+	if !hasDataStreams {
+		return nil, fmt.Errorf("upgrade tests are only supported for packages with data streams")
+	}
+
+	// Upgrade tests are only run for packages that enable them by defining the test folder.
+	testFolderPath := filepath.Join(r.packageRootPath, "_dev", "test", string(TestType))
+	_, err = os.Stat(testFolderPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to check upgrade test folder: %w", err)
+	}
+
+	config, err := newConfig(testFolderPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return []testrunner.Tester{
+		NewUpgradeTester(UpgradeTesterOptions{
+			PackageRootPath:  r.packageRootPath,
+			KibanaClient:     r.kibanaClient,
+			API:              r.esAPI,
+			DataStreams:      r.dataStreams,
+			Config:           config,
+			GlobalTestConfig: r.globalTestConfig,
+		}),
+	}, nil
+}
+
+func (r *runner) Type() testrunner.TestType {
+	return TestType
+}
@@ -0,0 +1,63 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/elastic/go-ucfg"
+	"github.com/elastic/go-ucfg/yaml"
+
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+type testConfig struct {
+	testrunner.SkippableConfig `config:",inline"`
+
+	// Version is the released version of the package to upgrade from. The latest released version
+	// older than the local one is used by default.
+	Version string `config:"version"`
+
+	// DataStreams contains the configuration of the package policies of each data stream. Package
+	// policies are created with the default configuration for data streams not included here.
+	DataStreams map[string]policyConfig `config:"data_streams"`
+}
+
+type policyConfig struct {
+	Input      string         `config:"input"`
+	Vars       map[string]any `config:"vars"`
+	DataStream struct {
+		Vars map[string]any `config:"vars"`
+	} `config:"data_stream"`
+}
+
+func newConfig(upgradeTestFolderPath string) (*testConfig, error) {
+	configFilePath := filepath.Join(upgradeTestFolderPath, "config.yml")
+
+	// Test configuration file is optional for upgrade tests.
+	if _, err := os.Stat(configFilePath); errors.Is(err, os.ErrNotExist) {
+		return &testConfig{}, nil
+	}
+
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load upgrade test configuration file: %s: %w", configFilePath, err)
+	}
+
+	cfg, err := yaml.NewConfig(data, ucfg.PathSep("."))
+	if err != nil {
+		return nil, fmt.Errorf("unable to load upgrade test configuration file: %s: %w", configFilePath, err)
+	}
+
+	var c testConfig
+	if err := cfg.Unpack(&c); err != nil {
+		return nil, fmt.Errorf("unable to unpack upgrade test configuration file: %s: %w", configFilePath, err)
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,716 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/elastic/elastic-package/internal/elasticsearch"
+	"github.com/elastic/elastic-package/internal/files"
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/logger"
+	"github.com/elastic/elastic-package/internal/packages"
+	"github.com/elastic/elastic-package/internal/packages/installer"
+	"github.com/elastic/elastic-package/internal/registry"
+	"github.com/elastic/elastic-package/internal/resources"
+	"github.com/elastic/elastic-package/internal/seed"
+	"github.com/elastic/elastic-package/internal/testrunner"
+)
+
+const (
+	// namespace is the namespace of the package policies and data streams used in the test.
+	namespace = "ep"
+
+	// eventsPerDataStream is the number of documents indexed in each data stream before and after the upgrade.
+	eventsPerDataStream = 20
+)
+
+type tester struct {
+	packageRootPath  string
+	kibanaClient     *kibana.Client
+	esAPI            *elasticsearch.API
+	dataStreams      []string
+	config           *testConfig
+	globalTestConfig testrunner.GlobalRunnerTestConfig
+
+	resourcesManager *resources.Manager
+
+	// Resources created during the test, to be cleaned up on tear down.
+	workDir            string
+	packageName        string
+	agentPolicy        *resources.FleetAgentPolicy
+	indexedDataStreams []string
+	packageInstalled   bool
+
+	// previousInstallation is the package installed before the test, if any, so it is
+	// restored on tear down.
+	previousInstallation *kibana.FleetPackage
+}
+
+// Ensures that runner implements testrunner.Tester interface
+var _ testrunner.Tester = new(tester)
+
+type UpgradeTesterOptions struct {
+	PackageRootPath  string
+	KibanaClient     *kibana.Client
+	API              *elasticsearch.API
+	DataStreams      []string
+	Config           *testConfig
+	GlobalTestConfig testrunner.GlobalRunnerTestConfig
+}
+
+func NewUpgradeTester(options UpgradeTesterOptions) *tester {
+	tester := tester{
+		packageRootPath:  options.PackageRootPath,
+		kibanaClient:     options.KibanaClient,
+		esAPI:            options.API,
+		dataStreams:      options.DataStreams,
+		config:           options.Config,
+		globalTestConfig: options.GlobalTestConfig,
+	}
+	tester.resourcesManager = resources.NewManager()
+	tester.resourcesManager.RegisterProvider(resources.DefaultKibanaProviderName, &resources.KibanaProvider{Client: tester.kibanaClient})
+	return &tester
+}
+
+func (r *tester) Type() testrunner.TestType {
+	return TestType
+}
+
+func (r *tester) String() string {
+	return string(TestType)
+}
+
+// Parallel indicates if this tester can run in parallel or not.
+func (r tester) Parallel() bool {
+	// The package is installed in different versions during the test, so it cannot run in parallel.
+	return false
+}
+
+// testedDataStream contains the state of a data stream along the upgrade.
+type testedDataStream struct {
+	// dir is the name of the data stream directory in the package.
+	dir string
+
+	// name is the name of the data stream in Elasticsearch.
+	name string
+
+	// indexed is the number of documents indexed before the upgrade.
+	indexed int
+
+	// backingIndices are the backing indices of the data stream before the upgrade.
+	backingIndices []string
+
+	// err is the first error found for this data stream.
+	err error
+}
+
+func (r *tester) Run(ctx context.Context) ([]testrunner.TestResult, error) {
+	manifest, err := packages.ReadPackageManifestFromPackageRoot(r.packageRootPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading package manifest failed (path: %s): %w", r.packageRootPath, err)
+	}
+	r.packageName = manifest.Name
+
+	result := testrunner.NewResultComposer(testrunner.TestResult{
+		TestType: TestType,
+		Name:     "upgrade",
+		Package:  manifest.Name,
+	})
+
+	if skip := testrunner.AnySkipConfig(r.config.Skip, r.globalTestConfig.Skip); skip != nil {
+		logger.Warnf("skipping %s test for %s: %s (details: %s)", TestType, manifest.Name, skip.Reason, skip.Link)
+		return result.WithSkip(skip)
+	}
+
+	fromVersion := r.config.Version
+	if fromVersion == "" {
+		fromVersion, err = r.findPreviousVersion(*manifest)
+		if err != nil {
+			return result.WithErrorf("failed to find released version to upgrade from: %w", err)
+		}
+		if fromVersion == "" {
+			return result.WithSkip(&testrunner.SkipConfig{
+				Reason: fmt.Sprintf("no released version of package %s older than %s", manifest.Name, manifest.Version),
+			})
+		}
+	}
+	testName := fmt.Sprintf("upgrade from %s", fromVersion)
+	result.Name = testName
+
+	releasedRootPath, err := r.downloadReleasedPackage(manifest.Name, fromVersion)
+	if err != nil {
+		return result.WithError(err)
+	}
+
+	dataStreams, err := r.selectDataStreams(releasedRootPath)
+	if err != nil {
+		return result.WithError(err)
+	}
+	if len(dataStreams) == 0 {
+		return result.WithSkip(&testrunner.SkipConfig{
+			Reason: fmt.Sprintf("no data streams in common between versions %s and %s", fromVersion, manifest.Version),
+		})
+	}
+
+	err = r.prepareReleasedVersion(ctx, manifest.Name, fromVersion, releasedRootPath, dataStreams)
+	if err != nil {
+		return result.WithError(err)
+	}
+
+	err = r.upgrade(ctx, manifest.Version, dataStreams)
+	if err != nil {
+		return result.WithError(err)
+	}
+
+	var results []testrunner.TestResult
+	for _, dataStream := range dataStreams {
+		dsResult := testrunner.NewResultComposer(testrunner.TestResult{
+			TestType:   TestType,
+			Name:       testName,
+			Package:    manifest.Name,
+			DataStream: dataStream.dir,
+		})
+		if dataStream.err == nil {
+			dataStream.err = r.verifyDataStream(ctx, dataStream)
+		}
+		tr, _ := dsResult.WithError(dataStream.err)
+		results = append(results, tr...)
+	}
+	return results, nil
+}
+
+// findPreviousVersion returns the latest released version of the package older than the local one,
+// and compatible with the version of Kibana. It returns an empty string if there is none.
+func (r *tester) findPreviousVersion(manifest packages.PackageManifest) (string, error) {
+	kibanaVersion, err := r.kibanaClient.Version()
+	if err != nil {
+		return "", fmt.Errorf("failed to get Kibana version: %w", err)
+	}
+	revisions, err := registry.Production.Revisions(manifest.Name, registry.SearchOptions{
+		All:           true,
+		KibanaVersion: strings.TrimSuffix(kibanaVersion.Version(), kibana.SNAPSHOT_SUFFIX),
+		Prerelease:    true,
+	})
+	if err != nil {
+		return "", err
+	}
+	return previousVersion(manifest.Version, revisions)
+}
+
+func previousVersion(localVersion string, revisions []packages.PackageManifest) (string, error) {
+	local, err := semver.NewVersion(localVersion)
+	if err != nil {
+		return "", fmt.Errorf("invalid package version %q: %w", localVersion, err)
+	}
+	var previous *semver.Version
+	for _, revision := range revisions {
+		version, err := semver.NewVersion(revision.Version)
+		if err != nil {
+			logger.Debugf("Ignoring released version with invalid format %q", revision.Version)
+			continue
+		}
+		if !version.LessThan(local) {
+			continue
+		}
+		if previous == nil || version.GreaterThan(previous) {
+			previous = version
+		}
+	}
+	if previous == nil {
+		return "", nil
+	}
+	return previous.Original(), nil
+}
+
+// downloadReleasedPackage downloads and extracts the released version of the package, and returns its root path.
+func (r *tester) downloadReleasedPackage(name, version string) (string, error) {
+	workDir, err := os.MkdirTemp("", "elastic-package-upgrade-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create work directory: %w", err)
+	}
+	r.workDir = workDir
+
+	zipPath, err := registry.Production.DownloadPackage(name, version, workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to download package %s-%s: %w", name, version, err)
+	}
+	err = files.Unzip(zipPath, workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract package %s-%s: %w", name, version, err)
+	}
+	return filepath.Join(workDir, fmt.Sprintf("%s-%s", name, version)), nil
+}
+
+// selectDataStreams returns the data streams to test, that are the ones present in both versions of the package.
+func (r *tester) selectDataStreams(releasedRootPath string) ([]*testedDataStream, error) {
+	paths, err := filepath.Glob(filepath.Join(releasedRootPath, "data_stream", "*", packages.DataStreamManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data streams of released package: %w", err)
+	}
+
+	var dataStreams []*testedDataStream
+	for _, path := range paths {
+		dir := filepath.Base(filepath.Dir(path))
+		if len(r.dataStreams) > 0 && !slices.Contains(r.dataStreams, dir) {
+			continue
+		}
+		_, err := os.Stat(filepath.Join(r.packageRootPath, "data_stream", dir, packages.DataStreamManifestFile))
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Warnf("Data stream %q has been removed from the package, it is not tested", dir)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		manifest, err := packages.ReadDataStreamManifest(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read data stream manifest of released package: %w", err)
+		}
+		dataset := manifest.Dataset
+		if dataset == "" {
+			dataset = fmt.Sprintf("%s.%s", r.packageName, manifest.Name)
+		}
+		dataStreams = append(dataStreams, &testedDataStream{
+			dir:  dir,
+			name: fmt.Sprintf("%s-%s-%s", manifest.Type, dataset, namespace),
+		})
+	}
+	return dataStreams, nil
+}
+
+// prepareReleasedVersion installs the released version of the package, creates package policies for
+// its data streams and ingests some documents on them.
+func (r *tester) prepareReleasedVersion(ctx context.Context, name, version, releasedRootPath string, dataStreams []*testedDataStream) error {
+	previous, err := r.kibanaClient.GetPackage(ctx, name)
+	var notFoundErr *kibana.ErrPackageNotFound
+	switch {
+	case errors.As(err, &notFoundErr):
+		// Not available, so not installed.
+	case err != nil:
+		return fmt.Errorf("failed to check if package %s is installed: %w", name, err)
+	case previous.Status == "installed":
+		r.previousInstallation = previous
+	}
+
+	logger.Debugf("Installing released package %s-%s...", name, version)
+	r.packageInstalled = true
+	_, err = r.kibanaClient.InstallPackage(ctx, name, version)
+	if err != nil {
+		return fmt.Errorf("failed to install released package %s-%s: %w", name, version, err)
+	}
+
+	policy := resources.FleetAgentPolicy{
+		Name:      fmt.Sprintf("ep-test-upgrade-%s-%d", name, time.Now().Unix()),
+		Namespace: namespace,
+	}
+	for _, dataStream := range dataStreams {
+		config := r.config.DataStreams[dataStream.dir]
+		policy.PackagePolicies = append(policy.PackagePolicies, resources.FleetPackagePolicy{
+			Name:           packagePolicyName(name, dataStream.dir),
+			RootPath:       releasedRootPath,
+			DataStreamName: dataStream.dir,
+			InputName:      config.Input,
+			Vars:           config.Vars,
+			DataStreamVars: config.DataStream.Vars,
+		})
+	}
+	r.agentPolicy = &policy
+	_, err = r.resourcesManager.ApplyCtx(ctx, resources.Resources{&policy})
+	if err != nil {
+		return fmt.Errorf("failed to create package policies for released package: %w", err)
+	}
+
+	var dataStreamDirs []string
+	for _, dataStream := range dataStreams {
+		dataStreamDirs = append(dataStreamDirs, dataStream.dir)
+		r.indexedDataStreams = append(r.indexedDataStreams, dataStream.name)
+	}
+	seeded, err := seed.Seed(ctx, seed.Options{
+		ESAPI:               r.esAPI,
+		PackageRootPath:     releasedRootPath,
+		DataStreams:         dataStreamDirs,
+		Namespace:           namespace,
+		EventsPerDataStream: eventsPerDataStream,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ingest documents with released package: %w", err)
+	}
+	for _, dataStream := range dataStreams {
+		idx := slices.IndexFunc(seeded, func(s seed.DataStreamResult) bool { return s.DataStream == dataStream.name })
+		if idx < 0 {
+			logger.Debugf("No documents found to ingest in data stream %q", dataStream.dir)
+			continue
+		}
+		dataStream.indexed = seeded[idx].Documents
+
+		err = r.refresh(ctx, dataStream.name)
+		if err != nil {
+			return err
+		}
+		dataStream.backingIndices, err = r.getBackingIndices(ctx, dataStream.name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upgrade installs the local version of the package and upgrades the package policies created for the test.
+func (r *tester) upgrade(ctx context.Context, version string, dataStreams []*testedDataStream) error {
+	logger.Debugf("Installing local package...")
+	packageInstaller, err := installer.NewForPackage(ctx, installer.Options{
+		Kibana:   r.kibanaClient,
+		RootPath: r.packageRootPath,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create package installer: %w", err)
+	}
+	_, err = packageInstaller.Install(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to install local package: %w", err)
+	}
+
+	packagePolicies, err := r.listTestPackagePolicies(ctx)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, packagePolicy := range packagePolicies {
+		if packagePolicy.Package.Version != version {
+			ids = append(ids, packagePolicy.ID)
+		}
+	}
+	if len(ids) > 0 {
+		upgradeResults, err := r.kibanaClient.UpgradePackagePolicies(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, upgradeResult := range upgradeResults {
+			if upgradeResult.Success {
+				continue
+			}
+			for _, dataStream := range dataStreams {
+				if upgradeResult.Name == packagePolicyName(r.packageName, dataStream.dir) {
+					dataStream.err = fmt.Errorf("package policy upgrade failed: %s (status code: %d)", upgradeResult.Body.Message, upgradeResult.StatusCode)
+				}
+			}
+		}
+	}
+
+	packagePolicies, err = r.listTestPackagePolicies(ctx)
+	if err != nil {
+		return err
+	}
+	for _, dataStream := range dataStreams {
+		if dataStream.err != nil {
+			continue
+		}
+		idx := slices.IndexFunc(packagePolicies, func(p kibana.PackagePolicy) bool {
+			return p.Name == packagePolicyName(r.packageName, dataStream.dir)
+		})
+		switch {
+		case idx < 0:
+			dataStream.err = errors.New("package policy not found after upgrade")
+		case packagePolicies[idx].Package.Version != version:
+			dataStream.err = fmt.Errorf("package policy not upgraded, found version %s, expected %s", packagePolicies[idx].Package.Version, version)
+		}
+	}
+	return nil
+}
+
+func (r *tester) listTestPackagePolicies(ctx context.Context) ([]kibana.PackagePolicy, error) {
+	packagePolicies, err := r.kibanaClient.ListPackagePolicies(ctx, r.packageName)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(packagePolicies, func(p kibana.PackagePolicy) bool {
+		return p.PolicyID != r.agentPolicy.ID
+	}), nil
+}
+
+// verifyDataStream checks that the data stream can be rolled over with the new index template, new
+// documents can be ingested, and documents ingested before the upgrade can still be queried.
+func (r *tester) verifyDataStream(ctx context.Context, dataStream *testedDataStream) error {
+	resp, err := r.esAPI.Indices.Rollover(dataStream.name,
+		r.esAPI.Indices.Rollover.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("rollover request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return fmt.Errorf("rollover failed: %s", resp.String())
+	}
+
+	_, err = seed.Seed(ctx, seed.Options{
+		ESAPI:               r.esAPI,
+		PackageRootPath:     r.packageRootPath,
+		DataStreams:         []string{dataStream.dir},
+		Namespace:           namespace,
+		EventsPerDataStream: eventsPerDataStream,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ingest documents after upgrade: %w", err)
+	}
+	err = r.refresh(ctx, dataStream.name)
+	if err != nil {
+		return err
+	}
+
+	if len(dataStream.backingIndices) > 0 {
+		found, err := r.countQueryableDocuments(ctx, dataStream.backingIndices)
+		if err != nil {
+			return fmt.Errorf("failed to query documents ingested before the upgrade: %w", err)
+		}
+		if found != dataStream.indexed {
+			return fmt.Errorf("found %d documents ingested before the upgrade, expected %d", found, dataStream.indexed)
+		}
+	}
+
+	conflicts, err := r.mappingConflicts(ctx, dataStream.name)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("fields with conflicting types in the backing indices: %s", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+func (r *tester) refresh(ctx context.Context, dataStream string) error {
+	resp, err := r.esAPI.Indices.Refresh(
+		r.esAPI.Indices.Refresh.WithContext(ctx),
+		r.esAPI.Indices.Refresh.WithIndex(dataStream),
+	)
+	if err != nil {
+		return fmt.Errorf("refresh request failed for data stream %s: %w", dataStream, err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return fmt.Errorf("refresh failed for data stream %s: %s", dataStream, resp.String())
+	}
+	return nil
+}
+
+func (r *tester) getBackingIndices(ctx context.Context, dataStream string) ([]string, error) {
+	resp, err := r.esAPI.Indices.GetDataStream(
+		r.esAPI.Indices.GetDataStream.WithContext(ctx),
+		r.esAPI.Indices.GetDataStream.WithName(dataStream),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data stream %s: %w", dataStream, err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return nil, fmt.Errorf("failed to get data stream %s: %s", dataStream, resp.String())
+	}
+
+	var dataStreamsResponse struct {
+		DataStreams []struct {
+			Indices []struct {
+				Name string `json:"index_name"`
+			} `json:"indices"`
+		} `json:"data_streams"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&dataStreamsResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode data stream %s: %w", dataStream, err)
+	}
+
+	var indices []string
+	for _, ds := range dataStreamsResponse.DataStreams {
+		for _, index := range ds.Indices {
+			indices = append(indices, index.Name)
+		}
+	}
+	return indices, nil
+}
+
+// countQueryableDocuments searches all the documents in the given indices, retrieving all their fields,
+// and returns the number of documents found. Any shard failure is reported as an error.
+func (r *tester) countQueryableDocuments(ctx context.Context, indices []string) (int, error) {
+	resp, err := r.esAPI.Search(
+		r.esAPI.Search.WithContext(ctx),
+		r.esAPI.Search.WithIndex(indices...),
+		r.esAPI.Search.WithTrackTotalHits(true),
+		r.esAPI.Search.WithBody(strings.NewReader(`{"size": 100, "fields": ["*"], "query": {"match_all": {}}}`)),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("search request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return 0, fmt.Errorf("search failed: %s", resp.String())
+	}
+
+	var searchResponse struct {
+		Shards struct {
+			Failed   int `json:"failed"`
+			Failures []struct {
+				Reason struct {
+					Reason string `json:"reason"`
+				} `json:"reason"`
+			} `json:"failures"`
+		} `json:"_shards"`
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+		} `json:"hits"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&searchResponse)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode search response: %w", err)
+	}
+	if searchResponse.Shards.Failed > 0 {
+		var reasons []string
+		for _, failure := range searchResponse.Shards.Failures {
+			reasons = append(reasons, failure.Reason.Reason)
+		}
+		return 0, fmt.Errorf("search failed in %d shards: %s", searchResponse.Shards.Failed, strings.Join(reasons, "; "))
+	}
+	return searchResponse.Hits.Total.Value, nil
+}
+
+// mappingConflicts returns the fields mapped with different types in the backing indices of the data stream.
+func (r *tester) mappingConflicts(ctx context.Context, dataStream string) ([]string, error) {
+	resp, err := r.esAPI.FieldCaps(
+		r.esAPI.FieldCaps.WithContext(ctx),
+		r.esAPI.FieldCaps.WithIndex(dataStream),
+		r.esAPI.FieldCaps.WithFields("*"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("field capabilities request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.IsError() {
+		return nil, fmt.Errorf("failed to get field capabilities: %s", resp.String())
+	}
+
+	var fieldCaps fieldCapsResponse
+	err = json.NewDecoder(resp.Body).Decode(&fieldCaps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode field capabilities: %w", err)
+	}
+	return fieldCaps.conflicts(), nil
+}
+
+type fieldCapsResponse struct {
+	Fields map[string]map[string]json.RawMessage `json:"fields"`
+}
+
+func (f fieldCapsResponse) conflicts() []string {
+	var conflicts []string
+	for field, types := range f.Fields {
+		if len(types) > 1 {
+			conflicts = append(conflicts, field)
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+func (r *tester) deleteDataStream(ctx context.Context, dataStream string) error {
+	resp, err := r.esAPI.Indices.DeleteDataStream([]string{dataStream},
+		r.esAPI.Indices.DeleteDataStream.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("delete request failed for data stream %s: %w", dataStream, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		// Data stream doesn't exist, there was nothing to do.
+		return nil
+	}
+	if resp.IsError() {
+		return fmt.Errorf("delete request failed for data stream %s: %s", dataStream, resp.String())
+	}
+	return nil
+}
+
+func packagePolicyName(packageName, dataStream string) string {
+	return fmt.Sprintf("ep-test-upgrade-%s-%s", packageName, dataStream)
+}
+
+// restorePackage leaves the package as it was before the test. If it was not installed, it is
+// removed. If it was installed from the registry, the same version is installed again.
+func (r *tester) restorePackage(ctx context.Context) error {
+	if previous := r.previousInstallation; previous != nil {
+		version := previous.InstalledVersion()
+		if source := previous.InstallSource(); source != "" && source != "registry" {
+			logger.Warnf("Package %s-%s was installed before the test from %s, it cannot be restored and it is not removed", r.packageName, version, source)
+			return nil
+		}
+		logger.Debugf("Restoring package %s-%s installed before the test...", r.packageName, version)
+		_, err := r.kibanaClient.InstallPackage(ctx, r.packageName, version)
+		if err != nil {
+			return fmt.Errorf("failed to restore package %s-%s: %w", r.packageName, version, err)
+		}
+		return nil
+	}
+
+	installed, err := r.kibanaClient.GetPackage(ctx, r.packageName)
+	if err != nil {
+		return fmt.Errorf("failed to get installed package: %w", err)
+	}
+	if installed.Status != "not_installed" {
+		_, err := r.kibanaClient.RemovePackage(ctx, r.packageName, installed.InstalledVersion())
+		if err != nil {
+			return fmt.Errorf("failed to uninstall package: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *tester) TearDown(ctx context.Context) error {
+	// Avoid cancellations during cleanup.
+	ctx = context.WithoutCancel(ctx)
+
+	var errs []error
+	if r.agentPolicy != nil && r.agentPolicy.ID != "" {
+		r.agentPolicy.Absent = true
+		_, err := r.resourcesManager.ApplyCtx(ctx, resources.Resources{r.agentPolicy})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete agent policy: %w", err))
+		}
+	}
+
+	if r.packageInstalled {
+		err := r.restorePackage(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, dataStream := range r.indexedDataStreams {
+		err := r.deleteDataStream(ctx, dataStream)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if r.workDir != "" {
+		err := os.RemoveAll(r.workDir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove work directory: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
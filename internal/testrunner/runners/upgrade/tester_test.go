@@ -0,0 +1,154 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License;
+// you may not use this file except in compliance with the Elastic License.
+
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-package/internal/kibana"
+	"github.com/elastic/elastic-package/internal/packages"
+)
+
+func TestPreviousVersion(t *testing.T) {
+	revisions := []packages.PackageManifest{
+		{Version: "1.0.0"},
+		{Version: "1.2.0"},
+		{Version: "1.10.0-preview1"},
+		{Version: "1.10.0"},
+		{Version: "2.0.0"},
+	}
+
+	cases := []struct {
+		local    string
+		expected string
+	}{
+		{local: "1.10.0", expected: "1.10.0-preview1"},
+		{local: "1.10.1", expected: "1.10.0"},
+		{local: "3.0.0", expected: "2.0.0"},
+		{local: "1.0.0", expected: ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.local, func(t *testing.T) {
+			version, err := previousVersion(c.local, revisions)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, version)
+		})
+	}
+
+	_, err := previousVersion("invalid", revisions)
+	assert.Error(t, err)
+}
+
+func TestFieldCapsConflicts(t *testing.T) {
+	body := `{
+  "indices": [".ds-logs-apache.access-ep-2024.01.01-000001", ".ds-logs-apache.access-ep-2024.01.01-000002"],
+  "fields": {
+    "message": {
+      "match_only_text": {"type": "match_only_text", "searchable": true, "aggregatable": false}
+    },
+    "http.response.status_code": {
+      "long": {"type": "long", "indices": [".ds-logs-apache.access-ep-2024.01.01-000001"]},
+      "keyword": {"type": "keyword", "indices": [".ds-logs-apache.access-ep-2024.01.01-000002"]}
+    }
+  }
+}`
+
+	var fieldCaps fieldCapsResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &fieldCaps))
+	assert.Equal(t, []string{"http.response.status_code"}, fieldCaps.conflicts())
+}
+
+func TestNewConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	config, err := newConfig(dir)
+	require.NoError(t, err)
+	assert.Empty(t, config.Version)
+	assert.Nil(t, config.Skip)
+
+	content := `version: 1.2.0
+data_streams:
+  access:
+    input: logfile
+    vars:
+      paths:
+        - /var/log/apache2/access.log*
+    data_stream:
+      vars:
+        preserve_original_event: true
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yml"), []byte(content), 0644))
+
+	config, err = newConfig(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0", config.Version)
+	require.Contains(t, config.DataStreams, "access")
+	assert.Equal(t, "logfile", config.DataStreams["access"].Input)
+	assert.Equal(t, true, config.DataStreams["access"].DataStream.Vars["preserve_original_event"])
+}
+
+func TestRestorePackage(t *testing.T) {
+	cases := []struct {
+		title    string
+		previous string
+		expected []string
+	}{
+		{
+			title:    "not installed before",
+			expected: []string{"GET /api/fleet/epm/packages/nginx", "DELETE /api/fleet/epm/packages/nginx/1.1.0"},
+		},
+		{
+			title:    "installed from registry before",
+			previous: `{"version":"1.2.0","status":"installed","installationInfo":{"version":"1.0.0","install_source":"registry"}}`,
+			expected: []string{"POST /api/fleet/epm/packages/nginx/1.0.0"},
+		},
+		{
+			title:    "uploaded before",
+			previous: `{"version":"1.2.0","status":"installed","installationInfo":{"version":"1.0.0","install_source":"upload"}}`,
+			expected: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.title, func(t *testing.T) {
+			var requests []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case kibana.StatusAPI:
+					fmt.Fprintln(w, `{"version":{"number":"8.15.0"}}`)
+					return
+				case "/api/fleet/epm/packages/nginx":
+					fmt.Fprintln(w, `{"item":{"version":"1.2.0","status":"installed","installationInfo":{"version":"1.1.0","install_source":"upload"}}}`)
+				default:
+					fmt.Fprintln(w, `{"items":[]}`)
+				}
+				requests = append(requests, r.Method+" "+r.URL.Path)
+			}))
+			t.Cleanup(server.Close)
+
+			client, err := kibana.NewClient(kibana.Address(server.URL))
+			require.NoError(t, err)
+
+			r := tester{kibanaClient: client, packageName: "nginx"}
+			if c.previous != "" {
+				require.NoError(t, json.Unmarshal([]byte(c.previous), &r.previousInstallation))
+			}
+			err = r.restorePackage(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, requests)
+		})
+	}
+}
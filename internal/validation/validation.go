@@ -13,10 +13,13 @@ import (
 
 	"github.com/elastic/package-spec/v3/code/go/pkg/specerrors"
 	"github.com/elastic/package-spec/v3/code/go/pkg/validator"
+
+	"github.com/elastic/elastic-package/internal/fields"
+	"github.com/elastic/elastic-package/internal/packages/pipelinesnippets"
 )
 
 func ValidateFromPath(rootPath string) error {
-	return validator.ValidateFromPath(rootPath)
+	return validateFromPath(rootPath)
 }
 
 func ValidateFromZip(packagePath string) error {
@@ -24,7 +27,7 @@ func ValidateFromZip(packagePath string) error {
 }
 
 func ValidateAndFilterFromPath(rootPath string) (error, error) {
-	allErrors := validator.ValidateFromPath(rootPath)
+	allErrors := validateFromPath(rootPath)
 	if allErrors == nil {
 		return nil, nil
 	}
@@ -37,6 +40,51 @@ func ValidateAndFilterFromPath(rootPath string) (error, error) {
 	return result.Processed, result.Removed
 }
 
+// ValidateFieldsAndFilterFromPath lints the field definitions of the package, skipping
+// the checks excluded in its validation.yml file.
+func ValidateFieldsAndFilterFromPath(rootPath string) (error, error) {
+	allErrors, err := fields.LintPackageFields(rootPath)
+	if err != nil {
+		return fmt.Errorf("linting fields failed: %w", err), nil
+	}
+	if len(allErrors) == 0 {
+		return nil, nil
+	}
+
+	result, err := filterErrors(allErrors, os.DirFS(rootPath))
+	if err != nil {
+		return err, nil
+	}
+	return result.Processed, result.Removed
+}
+
+// FilterErrorsFromPath filters the errors found in the package, skipping the checks
+// excluded in its validation.yml file.
+func FilterErrorsFromPath(rootPath string, allErrors error) (error, error) {
+	if allErrors == nil {
+		return nil, nil
+	}
+
+	result, err := filterErrors(allErrors, os.DirFS(rootPath))
+	if err != nil {
+		return err, nil
+	}
+	return result.Processed, result.Removed
+}
+
+// validateFromPath validates the package in the given path as it is after building it, with
+// its pipeline snippets expanded.
+func validateFromPath(rootPath string) error {
+	info, err := os.Stat(rootPath)
+	if err != nil {
+		return fmt.Errorf("no package found at path [%v]: %w", rootPath, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("no package folder found at path [%v]", rootPath)
+	}
+	return validator.ValidateFromFS(rootPath, pipelinesnippets.PackageFS(rootPath))
+}
+
 func ValidateAndFilterFromZip(packagePath string) (error, error) {
 	allErrors := validator.ValidateFromZip(packagePath)
 	if allErrors == nil {